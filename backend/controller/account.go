@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/mail"
+	"github.com/TalkSphere/backend/pkg/session"
+	"github.com/TalkSphere/backend/pkg/usertoken"
+	"github.com/TalkSphere/backend/service"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// VerifyEmailParams 邮箱验证请求参数
+type VerifyEmailParams struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordParams 忘记密码请求参数
+type ForgotPasswordParams struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordParams 重置密码请求参数
+type ResetPasswordParams struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// VerifyEmail 使用邮件中的令牌完成邮箱验证
+func (h *UserController) VerifyEmail(c *gin.Context) {
+	var params VerifyEmailParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	_, err := usertoken.Consume(params.Token, models.TokenPurposeVerifyEmail, func(tx *gorm.DB, userID int64) error {
+		now := time.Now()
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userID).
+			Update("email_verified_at", &now).Error
+	})
+	if err != nil {
+		if errors.Is(err, usertoken.ErrInvalidToken) {
+			ResponseError(c, CodeInvalidToken)
+			return
+		}
+		zap.L().Error("验证邮箱失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	ResponseSuccess(c, nil)
+}
+
+// ResendVerificationEmail 重新发送邮箱验证邮件
+func (h *UserController) ResendVerificationEmail(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		ResponseError(c, CodeUserNotExist)
+		return
+	}
+	if user.EmailVerified() {
+		ResponseSuccess(c, gin.H{"email_verified": true})
+		return
+	}
+
+	if err := sendVerificationEmail(c.Request.Context(), user); err != nil {
+		zap.L().Error("发送验证邮件失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	ResponseSuccess(c, gin.H{"email_verified": false})
+}
+
+// ForgotPassword 发送重置密码邮件
+// 无论邮箱是否存在都返回成功，避免被用来探测已注册的邮箱
+func (h *UserController) ForgotPassword(c *gin.Context) {
+	var params ForgotPasswordParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	user, err := h.users.GetByEmail(c.Request.Context(), params.Email)
+	if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		zap.L().Error("查询用户失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	if err == nil && user.Status == service.UserStatusActive {
+		if err := sendPasswordResetEmail(c.Request.Context(), user); err != nil {
+			zap.L().Error("发送重置密码邮件失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		}
+	}
+
+	ResponseSuccess(c, nil)
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码，并让该用户所有已登录的会话失效
+func (h *UserController) ResetPassword(c *gin.Context) {
+	var params ResetPasswordParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	passwordHash, err := encrypt.HashPassword(params.NewPassword)
+	if err != nil {
+		zap.L().Error("生成密码哈希失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	userID, err := usertoken.Consume(params.Token, models.TokenPurposeResetPassword, func(tx *gorm.DB, userID int64) error {
+		// 能收到重置邮件也就证明了邮箱归属
+		now := time.Now()
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password_hash":     passwordHash,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", now),
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, usertoken.ErrInvalidToken) {
+			ResponseError(c, CodeInvalidToken)
+			return
+		}
+		zap.L().Error("重置密码失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	if err := session.RevokeUser(userID); err != nil {
+		zap.L().Error("吊销用户会话失败", zap.Int64("user_id", userID), zap.Error(err))
+	}
+
+	ResponseSuccess(c, nil)
+}
+
+func sendVerificationEmail(ctx context.Context, user *models.User) error {
+	ttl := time.Duration(setting.Conf.MailConfig.VerifyTokenExpire) * time.Second
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	token, err := usertoken.Issue(user.ID, models.TokenPurposeVerifyEmail, ttl)
+	if err != nil {
+		return err
+	}
+	link := mailLink("/verify-email", token)
+	return mail.Send(ctx, &mail.Message{
+		To:      []string{user.Email},
+		Subject: "TalkSphere 邮箱验证",
+		Text: fmt.Sprintf("%s，你好：\n\n请在 %s 内打开以下链接完成邮箱验证：\n%s\n\n如果这不是你的操作，请忽略本邮件。",
+			user.Username, ttl, link),
+	})
+}
+
+func sendPasswordResetEmail(ctx context.Context, user *models.User) error {
+	ttl := time.Duration(setting.Conf.MailConfig.ResetTokenExpire) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	token, err := usertoken.Issue(user.ID, models.TokenPurposeResetPassword, ttl)
+	if err != nil {
+		return err
+	}
+	link := mailLink("/reset-password", token)
+	return mail.Send(ctx, &mail.Message{
+		To:      []string{user.Email},
+		Subject: "TalkSphere 重置密码",
+		Text: fmt.Sprintf("%s，你好：\n\n请在 %s 内打开以下链接重置密码：\n%s\n\n如果这不是你的操作，请忽略本邮件，你的密码不会被修改。",
+			user.Username, ttl, link),
+	})
+}
+
+func mailLink(path, token string) string {
+	return setting.Conf.MailConfig.BaseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -49,22 +50,37 @@ func GetActiveUsers(c *gin.Context) {
 		timeRange = "daily"
 	}
 
-	// 构建查询SQL
+	// 时间范围在这里计算好再作为参数传入，不依赖数据库的日期函数
+	now := time.Now()
+	today := startOfDay(now)
+	var since, until time.Time
+	switch timeRange {
+	case "daily":
+		since, until = today, today.AddDate(0, 0, 1)
+	case "weekly":
+		since, until = today.AddDate(0, 0, -7), today.AddDate(0, 0, 1)
+	case "monthly":
+		since, until = today.AddDate(0, 0, -30), today.AddDate(0, 0, 1)
+	default:
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
 	query := `
         WITH user_stats AS (
             SELECT 
-                u.id,
+                u.id as user_id,
                 u.username,
                 u.avatar_url,
                 u.last_login_at,
                 COUNT(DISTINCT p.id) as post_count,
-                COALESCE(SUM(p.like_count), 0) as total_likes,
-                COALESCE(SUM(p.favorite_count), 0) as total_favorites,
+                COALESCE(SUM(p.like_count), 0) as like_count,
+                COALESCE(SUM(p.favorite_count), 0) as favorite_count,
                 (
                     0.3 * (CASE 
-                        WHEN u.last_login_at >= DATE_SUB(NOW(), INTERVAL 24 HOUR) THEN 100
-                        WHEN u.last_login_at >= DATE_SUB(NOW(), INTERVAL 72 HOUR) THEN 70
-                        WHEN u.last_login_at >= DATE_SUB(NOW(), INTERVAL 7 DAY) THEN 40
+                        WHEN u.last_login_at >= ? THEN 100
+                        WHEN u.last_login_at >= ? THEN 70
+                        WHEN u.last_login_at >= ? THEN 40
                         ELSE 10
                     END) +
                     0.3 * COUNT(DISTINCT p.id) * 10 +
@@ -74,23 +90,7 @@ func GetActiveUsers(c *gin.Context) {
             FROM users u
             LEFT JOIN posts p ON u.id = p.author_id AND p.status = 1
             WHERE u.status = 1
-    `
-
-	// 根据时间范围添加条件
-	switch timeRange {
-	case "daily":
-		query += " AND (p.created_at IS NULL OR DATE(p.created_at) = CURDATE())"
-	case "weekly":
-		query += " AND (p.created_at IS NULL OR p.created_at >= DATE_SUB(CURDATE(), INTERVAL 7 DAY))"
-	case "monthly":
-		query += " AND (p.created_at IS NULL OR p.created_at >= DATE_SUB(CURDATE(), INTERVAL 30 DAY))"
-	default:
-		ResponseError(c, CodeInvalidParam)
-		return
-	}
-
-	// 完成查询SQL
-	query += `
+            AND (p.created_at IS NULL OR (p.created_at >= ? AND p.created_at < ?))
             GROUP BY u.id, u.username, u.avatar_url, u.last_login_at
         )
         SELECT *
@@ -102,7 +102,12 @@ func GetActiveUsers(c *gin.Context) {
 
 	// 执行查询
 	var activeUsers []ActiveUser
-	err := mysql.DB.Raw(query).Scan(&activeUsers).Error
+	err := mysql.Replica(c.Request.Context()).Raw(query,
+		now.Add(-24*time.Hour),
+		now.Add(-72*time.Hour),
+		now.AddDate(0, 0, -7),
+		since, until,
+	).Scan(&activeUsers).Error
 
 	if err != nil {
 		zap.L().Error("get active users failed", zap.Error(err))
@@ -118,12 +123,7 @@ func GetActiveUsers(c *gin.Context) {
 
 	// 格式化时间
 	for i := range activeUsers {
-		if activeUsers[i].LastLoginAt != "" {
-			t, err := time.Parse(time.RFC3339, activeUsers[i].LastLoginAt)
-			if err == nil {
-				activeUsers[i].LastLoginAt = t.Format("2006-01-02 15:04:05")
-			}
-		}
+		activeUsers[i].LastLoginAt = formatDBTime(activeUsers[i].LastLoginAt)
 	}
 
 	ResponseSuccess(c, gin.H{
@@ -134,132 +134,7 @@ func GetActiveUsers(c *gin.Context) {
 
 // GetUsersGrowth 用户增长量
 func GetUsersGrowth(c *gin.Context) {
-	// 获取最近7天的完整日期列表
-	now := time.Now()
-	dailyGrowth := make([]GrowthData, 7)
-	weeklyGrowth := make([]GrowthData, 7)
-	monthlyGrowth := make([]GrowthData, 6)
-
-	// 初始化日期和默认值
-	for i := 0; i < 7; i++ {
-		// 填充每日数据
-		date := now.AddDate(0, 0, -i)
-		dailyGrowth[i] = GrowthData{
-			Date:  date.Format("2006-01-02"),
-			Count: 0,
-		}
-
-		// 填充每周数据
-		if i < 7 {
-			weekDate := now.AddDate(0, 0, -i*7)
-			weekYear, weekNum := weekDate.ISOWeek()
-			weeklyGrowth[i] = GrowthData{
-				Date:  fmt.Sprintf("%d-W%02d", weekYear, weekNum),
-				Count: 0,
-			}
-		}
-
-		// 填充每月数据
-		if i < 6 {
-			monthDate := now.AddDate(0, -i, 0)
-			monthlyGrowth[i] = GrowthData{
-				Date:  monthDate.Format("2006-01"),
-				Count: 0,
-			}
-		}
-	}
-
-	// 查询每日数据
-	var dailyResults []GrowthData
-	err := mysql.DB.Raw(`
-        SELECT 
-            DATE(created_at) as date,
-            COUNT(*) as count
-        FROM users
-        WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 6 DAY)
-        GROUP BY DATE(created_at)
-        ORDER BY date DESC
-    `).Find(&dailyResults).Error
-
-	if err != nil {
-		zap.L().Error("failed to query daily growth", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 修改周数据查询
-	var weeklyResults []GrowthData
-	err = mysql.DB.Raw(`
-        SELECT 
-            CONCAT(
-                YEAR(MIN(created_at)),
-                '-W',
-                LPAD(WEEK(MIN(created_at), 1), 2, '0')
-            ) as date,
-            COUNT(*) as count
-        FROM users
-        WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 7 WEEK)
-        GROUP BY YEAR(created_at), WEEK(created_at, 1)
-        ORDER BY YEAR(created_at) DESC, WEEK(created_at, 1) DESC
-    `).Find(&weeklyResults).Error
-
-	if err != nil {
-		zap.L().Error("failed to query weekly growth", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 修改月数据查询
-	var monthlyResults []GrowthData
-	err = mysql.DB.Raw(`
-        SELECT 
-            DATE_FORMAT(MIN(created_at), '%Y-%m') as date,
-            COUNT(*) as count
-        FROM users
-        WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 6 MONTH)
-        GROUP BY YEAR(created_at), MONTH(created_at)
-        ORDER BY YEAR(created_at) DESC, MONTH(created_at) DESC
-    `).Find(&monthlyResults).Error
-
-	if err != nil {
-		zap.L().Error("failed to query monthly growth", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 更新日增长数据
-	for _, result := range dailyResults {
-		resultDate := result.Date[:10]
-		for i := range dailyGrowth {
-			if dailyGrowth[i].Date == resultDate {
-				dailyGrowth[i].Count = result.Count
-			}
-		}
-	}
-
-	// 更新周增长数据
-	for _, result := range weeklyResults {
-		for i := range weeklyGrowth {
-			if weeklyGrowth[i].Date == result.Date {
-				weeklyGrowth[i].Count = result.Count
-			}
-		}
-	}
-
-	// 更新月增长数据
-	for _, result := range monthlyResults {
-		for i := range monthlyGrowth {
-			if monthlyGrowth[i].Date == result.Date {
-				monthlyGrowth[i].Count = result.Count
-			}
-		}
-	}
-
-	ResponseSuccess(c, gin.H{
-		"daily_growth":   dailyGrowth,
-		"weekly_growth":  weeklyGrowth,
-		"monthly_growth": monthlyGrowth,
-	})
+	respondGrowth(c, "users", "")
 }
 
 // GetActivePosts 活跃帖子
@@ -285,22 +160,44 @@ func GetActivePosts(c *gin.Context) {
 		timeRange = "daily"
 	}
 
-	// 获取最新帖子的时间作为参考点
-	var latestPostTime time.Time
-	err := mysql.DB.Raw("SELECT MAX(created_at) FROM posts WHERE status = 1").Scan(&latestPostTime).Error
+	// 获取最新帖子的时间作为参考点，没有帖子时使用当前时间
+	latestPostTime := time.Now()
+	var latest []time.Time
+	err := mysql.Replica(c.Request.Context()).Table("posts").
+		Where("status = 1").
+		Order("created_at DESC").
+		Limit(1).
+		Pluck("created_at", &latest).Error
 	if err != nil {
 		zap.L().Error("get latest post time failed", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
+	if len(latest) > 0 {
+		latestPostTime = latest[0]
+	}
+
+	// 根据时间范围计算条件
+	latestDay := startOfDay(latestPostTime)
+	var since, until time.Time
+	switch timeRange {
+	case "daily":
+		since, until = latestDay, latestDay.AddDate(0, 0, 1)
+	case "weekly":
+		since, until = latestDay.AddDate(0, 0, -7), latestDay.AddDate(0, 0, 1)
+	case "monthly":
+		since, until = latestDay.AddDate(0, 0, -30), latestDay.AddDate(0, 0, 1)
+	default:
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
 
-	// 构建查询SQL
 	query := `
         WITH post_stats AS (
             SELECT 
                 p.id as post_id,
                 p.title,
-                LEFT(p.content, 200) as content,
+                SUBSTR(p.content, 1, 200) as content,
                 p.author_id,
                 u.username as author_name,
                 u.avatar_url as author_avatar,
@@ -311,9 +208,9 @@ func GetActivePosts(c *gin.Context) {
                 (
                     10 + /* 基础分 */
                     0.4 * (CASE 
-                        WHEN p.created_at >= DATE_SUB(?, INTERVAL 24 HOUR) THEN 100
-                        WHEN p.created_at >= DATE_SUB(?, INTERVAL 72 HOUR) THEN 70
-                        WHEN p.created_at >= DATE_SUB(?, INTERVAL 7 DAY) THEN 40
+                        WHEN p.created_at >= ? THEN 100
+                        WHEN p.created_at >= ? THEN 70
+                        WHEN p.created_at >= ? THEN 40
                         ELSE 10
                     END) +
                     0.3 * COALESCE(p.like_count, 0) +
@@ -323,26 +220,7 @@ func GetActivePosts(c *gin.Context) {
             FROM posts p
             LEFT JOIN users u ON p.author_id = u.id
             WHERE p.status = 1
-    `
-
-	// 根据时间范围添加条件
-	var timeCondition string
-	switch timeRange {
-	case "daily":
-		timeCondition = "DATE(p.created_at) = DATE(?)"
-	case "weekly":
-		timeCondition = "p.created_at >= DATE_SUB(DATE(?), INTERVAL 7 DAY)"
-	case "monthly":
-		timeCondition = "p.created_at >= DATE_SUB(DATE(?), INTERVAL 30 DAY)"
-	default:
-		ResponseError(c, CodeInvalidParam)
-		return
-	}
-
-	query += " AND " + timeCondition
-
-	// 完成查询SQL
-	query += `
+            AND p.created_at >= ? AND p.created_at < ?
         )
         SELECT *
         FROM post_stats
@@ -352,11 +230,11 @@ func GetActivePosts(c *gin.Context) {
 
 	// 执行查询，使用最新帖子时间作为参考点
 	var activePosts []ActivePost
-	err = mysql.DB.Raw(query,
-		latestPostTime, // 用于24小时判断
-		latestPostTime, // 用于72小时判断
-		latestPostTime, // 用于7天判断
-		latestPostTime, // 用于时间范围条件
+	err = mysql.Replica(c.Request.Context()).Raw(query,
+		latestPostTime.Add(-24*time.Hour), // 用于24小时判断
+		latestPostTime.Add(-72*time.Hour), // 用于72小时判断
+		latestPostTime.AddDate(0, 0, -7),  // 用于7天判断
+		since, until,                      // 用于时间范围条件
 	).Scan(&activePosts).Error
 
 	if err != nil {
@@ -373,12 +251,7 @@ func GetActivePosts(c *gin.Context) {
 
 	// 格式化时间
 	for i := range activePosts {
-		if activePosts[i].CreatedAt != "" {
-			t, err := time.Parse(time.RFC3339, activePosts[i].CreatedAt)
-			if err == nil {
-				activePosts[i].CreatedAt = t.Format("2006-01-02 15:04:05")
-			}
-		}
+		activePosts[i].CreatedAt = formatDBTime(activePosts[i].CreatedAt)
 		// 截断内容
 		if len(activePosts[i].Content) > 200 {
 			activePosts[i].Content = activePosts[i].Content[:200] + "..."
@@ -393,135 +266,98 @@ func GetActivePosts(c *gin.Context) {
 
 // GetPostsGrowth 帖子增长量
 func GetPostsGrowth(c *gin.Context) {
+	respondGrowth(c, "posts", "status = 1")
+}
+
+// respondGrowth 统计 table 最近 7 天、7 周、6 个月每个周期新增的记录数，where 为额外的过滤条件
+func respondGrowth(c *gin.Context, table, where string) {
 	// 获取最近7天的完整日期列表
 	now := time.Now()
+	today := startOfDay(now)
 	dailyGrowth := make([]GrowthData, 7)
 	weeklyGrowth := make([]GrowthData, 7)
 	monthlyGrowth := make([]GrowthData, 6)
 
-	// 初始化日期和默认值
+	// 初始化日期和默认值，格式与 Dialect 生成的分组键一致
 	for i := 0; i < 7; i++ {
-		// 填充每日数据
-		date := now.AddDate(0, 0, -i)
-		dailyGrowth[i] = GrowthData{
-			Date:  date.Format("2006-01-02"),
-			Count: 0,
-		}
+		dailyGrowth[i] = GrowthData{Date: now.AddDate(0, 0, -i).Format("2006-01-02")}
 
-		// 填充每周数据
-		if i < 7 {
-			weekDate := now.AddDate(0, 0, -i*7)
-			weekYear, weekNum := weekDate.ISOWeek()
-			weeklyGrowth[i] = GrowthData{
-				Date:  fmt.Sprintf("%d-W%02d", weekYear, weekNum),
-				Count: 0,
-			}
-		}
+		weekYear, weekNum := now.AddDate(0, 0, -i*7).ISOWeek()
+		weeklyGrowth[i] = GrowthData{Date: fmt.Sprintf("%d-W%02d", weekYear, weekNum)}
 
-		// 填充每月数据
 		if i < 6 {
-			monthDate := now.AddDate(0, -i, 0)
-			monthlyGrowth[i] = GrowthData{
-				Date:  monthDate.Format("2006-01"),
-				Count: 0,
-			}
+			monthlyGrowth[i] = GrowthData{Date: now.AddDate(0, -i, 0).Format("2006-01")}
 		}
 	}
 
-	// 查询每日数据
-	var dailyResults []GrowthData
-	err := mysql.DB.Raw(`
-        SELECT 
-            DATE(created_at) as date,
-            COUNT(*) as count
-        FROM posts
-        WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 6 DAY)
-        AND status = 1
-        GROUP BY DATE(created_at)
-        ORDER BY date DESC
-    `).Find(&dailyResults).Error
-
-	if err != nil {
-		zap.L().Error("failed to query daily growth", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
+	dialect := mysql.Current()
+	periods := []struct {
+		name   string
+		key    string
+		since  time.Time
+		growth []GrowthData
+	}{
+		{"daily", dialect.DayKey("created_at"), today.AddDate(0, 0, -6), dailyGrowth},
+		{"weekly", dialect.WeekKey("created_at"), today.AddDate(0, 0, -7*7), weeklyGrowth},
+		{"monthly", dialect.MonthKey("created_at"), today.AddDate(0, -6, 0), monthlyGrowth},
+	}
+	for _, p := range periods {
+		if err := fillGrowth(c.Request.Context(), table, where, p.key, p.since, p.growth); err != nil {
+			zap.L().Error("查询增长量失败",
+				zap.String("table", table), zap.String("period", p.name), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+			return
+		}
 	}
 
-	// 查询每周数据
-	var weeklyResults []GrowthData
-	err = mysql.DB.Raw(`
-        SELECT 
-            CONCAT(
-                YEAR(MIN(created_at)),
-                '-W',
-                LPAD(WEEK(MIN(created_at), 1), 2, '0')
-            ) as date,
-            COUNT(*) as count
-        FROM posts
-        WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 7 WEEK)
-        AND status = 1
-        GROUP BY YEAR(created_at), WEEK(created_at, 1)
-        ORDER BY YEAR(created_at) DESC, WEEK(created_at, 1) DESC
-    `).Find(&weeklyResults).Error
+	ResponseSuccess(c, gin.H{
+		"daily_growth":   dailyGrowth,
+		"weekly_growth":  weeklyGrowth,
+		"monthly_growth": monthlyGrowth,
+	})
+}
 
-	if err != nil {
-		zap.L().Error("failed to query weekly growth", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
+// fillGrowth 按 key 表达式分组统计 since 之后新增的记录数，填入 growth 中日期相同的项
+func fillGrowth(ctx context.Context, table, where, key string, since time.Time, growth []GrowthData) error {
+	query := mysql.Replica(ctx).Table(table).
+		Select(key+" as date, COUNT(*) as count").
+		Where("created_at >= ?", since)
+	if where != "" {
+		query = query.Where(where)
 	}
 
-	// 查询每月数据
-	var monthlyResults []GrowthData
-	err = mysql.DB.Raw(`
-        SELECT 
-            DATE_FORMAT(MIN(created_at), '%Y-%m') as date,
-            COUNT(*) as count
-        FROM posts
-        WHERE created_at >= DATE_SUB(CURDATE(), INTERVAL 6 MONTH)
-        AND status = 1
-        GROUP BY YEAR(created_at), MONTH(created_at)
-        ORDER BY YEAR(created_at) DESC, MONTH(created_at) DESC
-    `).Find(&monthlyResults).Error
-
-	if err != nil {
-		zap.L().Error("failed to query monthly growth", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
+	var results []GrowthData
+	if err := query.Group(key).Find(&results).Error; err != nil {
+		return err
 	}
-
-	// 更新日增长数据
-	for _, result := range dailyResults {
-		resultDate := result.Date[:10]
-		for i := range dailyGrowth {
-			if dailyGrowth[i].Date == resultDate {
-				dailyGrowth[i].Count = result.Count
+	for _, result := range results {
+		for i := range growth {
+			if growth[i].Date == result.Date {
+				growth[i].Count = result.Count
 			}
 		}
 	}
+	return nil
+}
 
-	// 更新周增长数据
-	for _, result := range weeklyResults {
-		for i := range weeklyGrowth {
-			if weeklyGrowth[i].Date == result.Date {
-				weeklyGrowth[i].Count = result.Count
-			}
-		}
-	}
+// startOfDay 当天零点
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
 
-	// 更新月增长数据
-	for _, result := range monthlyResults {
-		for i := range monthlyGrowth {
-			if monthlyGrowth[i].Date == result.Date {
-				monthlyGrowth[i].Count = result.Count
-			}
+// dbTimeLayouts 时间列扫描到字符串后可能的格式：MySQL、PostgreSQL 驱动返回 time.Time，
+// 转成字符串后是 RFC3339；SQLite 在 CTE 等表达式中直接返回保存的文本
+var dbTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05.999999999-07:00", time.DateTime}
+
+// formatDBTime 把查询结果中的时间统一格式化为 2006-01-02 15:04:05，无法解析时原样返回
+func formatDBTime(s string) string {
+	for _, layout := range dbTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.DateTime)
 		}
 	}
-
-	ResponseSuccess(c, gin.H{
-		"daily_growth":   dailyGrowth,
-		"weekly_growth":  weeklyGrowth,
-		"monthly_growth": monthlyGrowth,
-	})
+	return s
 }
 
 // GetPostsWordCloud 词云图
@@ -531,7 +367,7 @@ func GetPostsWordCloud(c *gin.Context) {
 		Content string
 	}
 
-	if err := mysql.DB.Table("posts").
+	if err := mysql.Replica(c.Request.Context()).Table("posts").
 		Select("content").
 		Where("status = 1").
 		Find(&posts).Error; err != nil {
@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/TalkSphere/backend/repository"
 	"go.uber.org/zap"
 
 	"github.com/TalkSphere/backend/models"
@@ -12,8 +15,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// BoardController 板块及版主相关接口
+type BoardController struct {
+	repos *repository.Repositories
+}
+
+func NewBoardController(repos *repository.Repositories) *BoardController {
+	return &BoardController{repos: repos}
+}
+
 // CreateBoard 创建板块
-func CreateBoard(c *gin.Context) {
+func (h *BoardController) CreateBoard(c *gin.Context) {
 	var board models.Board
 	if err := c.ShouldBindJSON(&board); err != nil {
 		ResponseError(c, CodeInvalidParam)
@@ -28,30 +40,48 @@ func CreateBoard(c *gin.Context) {
 
 	board.CreatorID = userID
 
-	if err := mysql.DB.Create(&board).Error; err != nil {
+	if err := h.repos.Boards.Create(c.Request.Context(), &board); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, audit.ActionBoardCreate, audit.TargetBoard, strconv.FormatInt(board.ID, 10), nil, board)
 	ResponseSuccess(c, board)
 }
 
 // DeleteBoard 删除板块
-func DeleteBoard(c *gin.Context) {
+func (h *BoardController) DeleteBoard(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	if err := mysql.DB.Delete(&models.Board{}, id).Error; err != nil {
+	before, err := h.repos.Boards.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			ResponseError(c, CodeBoardNotExist)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	moderators, _ := rbac.GetBoardModerators(strconv.FormatInt(id, 10))
+
+	if err := h.repos.Boards.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// 板块删除后其版主任命随之失效
+	if err := rbac.RemoveBoardModerators(strconv.FormatInt(id, 10)); err != nil {
+		zap.L().Error("移除板块版主失败", zap.Int64("boardID", id), zap.Error(err))
+	}
+	recordAudit(c, audit.ActionBoardDelete, audit.TargetBoard, strconv.FormatInt(id, 10),
+		gin.H{"board": before, "moderators": moderators}, nil)
 	ResponseSuccess(c, gin.H{"message": "删除成功"})
 }
 
 // UpdateBoard 修改板块
-func UpdateBoard(c *gin.Context) {
+func (h *BoardController) UpdateBoard(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		ResponseError(c, CodeInvalidParam)
@@ -64,25 +94,36 @@ func UpdateBoard(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repos.Boards.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			ResponseError(c, CodeBoardNotExist)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	board.ID = id
-	if err := mysql.DB.Model(&board).Updates(board).Error; err != nil {
+	if err := h.repos.Boards.Update(c.Request.Context(), &board); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if after, err := h.repos.Boards.GetByID(c.Request.Context(), id); err == nil {
+		recordAudit(c, audit.ActionBoardUpdate, audit.TargetBoard, strconv.FormatInt(id, 10), before, after)
+	}
 
 	ResponseSuccess(c, board)
 }
 
 // GetAllBoards 查询所有板块
-func GetAllBoards(c *gin.Context) {
+func (h *BoardController) GetAllBoards(c *gin.Context) {
 	zap.L().Info("开始获取所有板块")
 
-	var boards []models.Board
-	result := mysql.DB.Find(&boards)
-
-	if result.Error != nil {
+	boards, err := h.repos.Boards.List(c.Request.Context())
+	if err != nil {
 		zap.L().Error("查询板块失败",
-			zap.Error(result.Error))
+			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -92,3 +133,144 @@ func GetAllBoards(c *gin.Context) {
 
 	ResponseSuccess(c, boards)
 }
+
+// AddBoardModeratorRequest 任命版主请求
+type AddBoardModeratorRequest struct {
+	UserID int64 `json:"user_id" binding:"required"`
+}
+
+// BoardModerator 版主信息
+type BoardModerator struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// GetBoardModerators 获取板块的版主列表
+func (h *BoardController) GetBoardModerators(c *gin.Context) {
+	boardID, ok := h.findBoardID(c)
+	if !ok {
+		return
+	}
+	h.respondBoardModerators(c, boardID)
+}
+
+// AddBoardModerator 任命版主
+func (h *BoardController) AddBoardModerator(c *gin.Context) {
+	boardID, ok := h.findBoardID(c)
+	if !ok {
+		return
+	}
+	var req AddBoardModeratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	exists, err := h.repos.Users.Exists(c.Request.Context(), req.UserID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", req.UserID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	if !exists {
+		ResponseError(c, CodeUserNotExist)
+		return
+	}
+
+	before, _ := rbac.GetBoardModerators(strconv.FormatInt(boardID, 10))
+	if _, err := rbac.AddBoardModerator(strconv.FormatInt(req.UserID, 10), strconv.FormatInt(boardID, 10)); err != nil {
+		zap.L().Error("任命版主失败",
+			zap.Int64("boardID", boardID),
+			zap.Int64("userID", req.UserID),
+			zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	zap.L().Info("任命版主",
+		zap.Int64("boardID", boardID),
+		zap.Int64("userID", req.UserID),
+		zap.String("operator", c.GetString(CtxtUserID)))
+	after, _ := rbac.GetBoardModerators(strconv.FormatInt(boardID, 10))
+	recordAudit(c, audit.ActionModeratorAdd, audit.TargetBoard, strconv.FormatInt(boardID, 10), before, after)
+	h.respondBoardModerators(c, boardID)
+}
+
+// RemoveBoardModerator 移除版主
+func (h *BoardController) RemoveBoardModerator(c *gin.Context) {
+	boardID, ok := h.findBoardID(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	before, _ := rbac.GetBoardModerators(strconv.FormatInt(boardID, 10))
+	if _, err := rbac.RemoveBoardModerator(strconv.FormatInt(userID, 10), strconv.FormatInt(boardID, 10)); err != nil {
+		zap.L().Error("移除版主失败",
+			zap.Int64("boardID", boardID),
+			zap.Int64("userID", userID),
+			zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	zap.L().Info("移除版主",
+		zap.Int64("boardID", boardID),
+		zap.Int64("userID", userID),
+		zap.String("operator", c.GetString(CtxtUserID)))
+	after, _ := rbac.GetBoardModerators(strconv.FormatInt(boardID, 10))
+	recordAudit(c, audit.ActionModeratorRemove, audit.TargetBoard, strconv.FormatInt(boardID, 10), before, after)
+	h.respondBoardModerators(c, boardID)
+}
+
+// findBoardID 解析路径中的板块ID并确认板块存在，失败时已写入响应
+func (h *BoardController) findBoardID(c *gin.Context) (int64, bool) {
+	boardID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return 0, false
+	}
+	exists, err := h.repos.Boards.Exists(c.Request.Context(), boardID, false)
+	if err != nil {
+		zap.L().Error("查询板块失败", zap.Int64("boardID", boardID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return 0, false
+	}
+	if !exists {
+		ResponseError(c, CodeBoardNotExist)
+		return 0, false
+	}
+	return boardID, true
+}
+
+func (h *BoardController) respondBoardModerators(c *gin.Context, boardID int64) {
+	ids, err := rbac.GetBoardModerators(strconv.FormatInt(boardID, 10))
+	if err != nil {
+		zap.L().Error("获取版主列表失败", zap.Int64("boardID", boardID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	userIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if userID, err := strconv.ParseInt(id, 10, 64); err == nil {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	users, err := h.repos.Users.ListBriefs(c.Request.Context(), userIDs)
+	if err != nil {
+		zap.L().Error("查询版主信息失败", zap.Int64("boardID", boardID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	moderators := make([]BoardModerator, 0, len(users))
+	for _, u := range users {
+		moderators = append(moderators, BoardModerator{ID: u.ID, Username: u.Username, AvatarURL: u.AvatarURL})
+	}
+	ResponseSuccess(c, gin.H{
+		"board_id":   boardID,
+		"moderators": moderators,
+	})
+}
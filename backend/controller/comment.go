@@ -1,17 +1,28 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/TalkSphere/backend/models"
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/TalkSphere/backend/service"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
+// CommentController 评论相关接口
+type CommentController struct {
+	comments *service.CommentService
+}
+
+func NewCommentController(comments *service.CommentService) *CommentController {
+	return &CommentController{comments: comments}
+}
+
 // CreateCommentRequest 创建评论请求
 type CreateCommentRequest struct {
 	PostID   int64  `json:"post_id" binding:"required" example:"1"`      // 帖子ID
@@ -32,7 +43,7 @@ type CreateCommentRequest struct {
 // @Failure 403 {object} Response "无权限"
 // @Failure 500 {object} Response "服务器内部错误"
 // @Router /comments [post]
-func CreateComment(c *gin.Context) {
+func (h *CommentController) CreateComment(c *gin.Context) {
 	var req CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ResponseError(c, CodeInvalidParam)
@@ -45,87 +56,27 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 开启事务
-	tx := mysql.DB.Begin()
-
-	// 检查帖子是否存在且未删除
-	var postExists int64
-	if err := tx.Model(&models.Post{}).
-		Where("id = ? AND status != -1", req.PostID).
-		Count(&postExists).Error; err != nil {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-	if postExists == 0 {
-		tx.Rollback()
-		ResponseError(c, CodePostNotExist)
-		return
-	}
-
-	comment := &models.Comment{
+	comment, err := h.comments.Create(c.Request.Context(), service.CreateCommentInput{
 		PostID:   req.PostID,
 		UserID:   userID,
 		Content:  req.Content,
 		ParentID: req.ParentID,
-		Status:   1,
-	}
-
-	// 如果是回复其他评论
-	if req.ParentID != nil {
-		var parentComment models.Comment
-		if err := tx.Where("id = ? AND status = 1", *req.ParentID).
-			First(&parentComment).Error; err != nil {
-			tx.Rollback()
-			if err == gorm.ErrRecordNotFound {
-				ResponseError(c, CodeCommentNotExist)
-				return
-			}
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-
-		// 设置根评论ID
-		if parentComment.RootID == nil {
-			// 如果父评论是顶级评论，则根评论ID为父评论ID
-			comment.RootID = req.ParentID
-		} else {
-			// 否则继承父评论的根评论ID
-			comment.RootID = parentComment.RootID
-		}
-
-		// 如果是回复其他评论，更新父评论的回复数
-		if err := tx.Model(&models.Comment{}).
-			Where("id = ?", *req.ParentID).
-			UpdateColumn("reply_count", gorm.Expr("reply_count + ?", 1)).
-			Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("更新父评论回复数失败",
-				zap.Error(err),
-				zap.Int64("parent_id", *req.ParentID))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPostNotFound):
+			ResponseError(c, CodePostNotExist)
+		case errors.Is(err, service.ErrPostLocked):
+			ResponseError(c, CodePostLocked)
+		case errors.Is(err, service.ErrCommentNotFound):
+			ResponseError(c, CodeCommentNotExist)
+		default:
+			zap.L().Error("创建评论失败", zap.Int64("post_id", req.PostID), zap.Error(err))
 			ResponseError(c, CodeServerBusy)
-			return
 		}
-	}
-
-	// 创建评论
-	if err := tx.Create(comment).Error; err != nil {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 更新帖子评论数
-	if err := tx.Model(&models.Post{}).
-		Where("id = ?", req.PostID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1)).
-		Error; err != nil {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	tx.Commit()
 	ResponseSuccess(c, comment)
 }
 
@@ -142,7 +93,7 @@ func CreateComment(c *gin.Context) {
 // @Failure 400 {object} Response "参数错误"
 // @Failure 500 {object} Response "服务器内部错误"
 // @Router /comments/post/{post_id} [get]
-func GetPostComments(c *gin.Context) {
+func (h *CommentController) GetPostComments(c *gin.Context) {
 	postIDStr := c.Param("post_id")
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
@@ -153,131 +104,17 @@ func GetPostComments(c *gin.Context) {
 
 	sortBy := c.DefaultQuery("sort", "hot") // hot, new, top
 
-	// 1. 首先获取所有顶级评论（分页）
+	// 分页获取顶级评论，每条顶级评论带完整的回复树
 	page, size := getPageInfo(c)
-	var rootComments []models.Comment
-	var total int64
-
-	query := mysql.DB.Model(&models.Comment{}).
-		Where("post_id = ? AND status = 1 AND parent_id IS NULL", postID)
-
-	// 计算总数
-	query.Count(&total)
-
-	// 根据排序方式查询
-	switch sortBy {
-	case "hot":
-		query = query.Order("score DESC, created_at DESC")
-	case "new":
-		query = query.Order("created_at DESC")
-	case "top":
-		query = query.Order("score DESC")
-	}
-
-	// 获取分页后的顶级评论
-	if err := query.Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&rootComments).Error; err != nil {
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	if len(rootComments) == 0 {
-		ResponseSuccess(c, gin.H{
-			"comments": []models.Comment{},
-			"total":    0,
-		})
-		return
-	}
-
-	// 2. 获取这些顶级评论的所有子评论（包括所有层级）
-	var allChildComments []models.Comment
-	if err := mysql.DB.Where("post_id = ? AND status = 1 AND parent_id IS NOT NULL", postID).
-		Order("created_at ASC").
-		Find(&allChildComments).Error; err != nil {
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 3. 获取所有相关用户信息
-	var userIDs []int64
-	userIDMap := make(map[int64]struct{})
-
-	// 添加根评论的用户ID
-	for _, comment := range rootComments {
-		if _, exists := userIDMap[comment.UserID]; !exists {
-			userIDs = append(userIDs, comment.UserID)
-			userIDMap[comment.UserID] = struct{}{}
-		}
-	}
-
-	// 添加所有子评论的用户ID
-	for _, comment := range allChildComments {
-		if _, exists := userIDMap[comment.UserID]; !exists {
-			userIDs = append(userIDs, comment.UserID)
-			userIDMap[comment.UserID] = struct{}{}
-		}
-	}
-
-	var users []models.User
-	if err := mysql.DB.Where("id IN ?", userIDs).
-		Select("id, username, avatar_url").
-		Find(&users).Error; err != nil {
+	comments, total, err := h.comments.ListByPost(c.Request.Context(), postID, sortBy, int(page), int(size))
+	if err != nil {
+		zap.L().Error("获取评论列表失败", zap.Int64("post_id", postID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	// 构建用户映射
-	userMap := make(map[int64]*models.User)
-	for i := range users {
-		userMap[users[i].ID] = &users[i]
-	}
-
-	// 4. 构建评论树
-	commentMap := make(map[int64]*models.Comment)
-
-	// 处理顶级评论
-	for i := range rootComments {
-		rootComments[i].User = userMap[rootComments[i].UserID]
-		rootComments[i].Children = make([]models.Comment, 0)
-		commentMap[rootComments[i].ID] = &rootComments[i]
-	}
-
-	// 按照父评论ID组织子评论
-	childrenByParent := make(map[int64][]*models.Comment)
-	for i := range allChildComments {
-		comment := &allChildComments[i]
-		comment.User = userMap[comment.UserID]
-		comment.Children = make([]models.Comment, 0)
-
-		if comment.ParentID != nil {
-			parentID := *comment.ParentID
-			childrenByParent[parentID] = append(childrenByParent[parentID], comment)
-		}
-	}
-
-	// 递归构建评论树
-	var buildCommentTree func(parentID int64) []models.Comment
-	buildCommentTree = func(parentID int64) []models.Comment {
-		children := childrenByParent[parentID]
-		result := make([]models.Comment, 0, len(children))
-
-		for _, child := range children {
-			childCopy := *child // 创建一个副本
-			childCopy.Children = buildCommentTree(child.ID)
-			result = append(result, childCopy)
-		}
-
-		return result
-	}
-
-	// 为每个根评论构建子评论树
-	for i := range rootComments {
-		rootComments[i].Children = buildCommentTree(rootComments[i].ID)
-	}
-
 	ResponseSuccess(c, gin.H{
-		"comments": rootComments,
+		"comments": comments,
 		"total":    total,
 	})
 }
@@ -295,72 +132,30 @@ func GetPostComments(c *gin.Context) {
 // @Failure 403 {object} Response "无权限"
 // @Failure 500 {object} Response "服务器内部错误"
 // @Router /comments/{id} [delete]
-func DeleteComment(c *gin.Context) {
+func (h *CommentController) DeleteComment(c *gin.Context) {
 	commentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	userID, err := getCurrentUserIDInt64(c)
-	if err != nil {
-		ResponseError(c, CodeNeedLogin)
-		return
-	}
-
-	tx := mysql.DB.Begin()
-
-	// 获取评论信息
-	var comment models.Comment
-	if err := tx.First(&comment, commentID).Error; err != nil {
-		tx.Rollback()
-		if err == gorm.ErrRecordNotFound {
-			ResponseError(c, CodeCommentNotExist)
-			return
-		}
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 检查权限
-	if comment.UserID != userID {
-		tx.Rollback()
-		ResponseError(c, CodeNoPermision)
+	// 评论作者、管理员等按资源级规则检查
+	res, ok := authorize(c, rbac.ResourceComment, commentID, rbac.ActDelete)
+	if !ok {
 		return
 	}
+	comment := res.Object.(*models.Comment)
 
-	// 软删除评论及其所有子评论
-	if err := tx.Model(&models.Comment{}).
-		Where("id = ? OR root_id = ?", commentID, commentID).
-		Update("status", -1).Error; err != nil {
-		tx.Rollback()
+	if err := h.comments.Delete(c.Request.Context(), comment); err != nil {
+		zap.L().Error("删除评论失败", zap.Int64("comment_id", commentID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	// 更新帖子评论数
-	if err := tx.Model(&models.Post{}).
-		Where("id = ?", comment.PostID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count - 1")).
-		Error; err != nil {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
-		return
+	// 作者删除自己的评论不记录，管理员、版主删除他人评论时记录
+	if !isResourceOwner(c, res) {
+		recordAudit(c, audit.ActionCommentDelete, audit.TargetComment, res.ID,
+			gin.H{"content": comment.Content, "user_id": comment.UserID, "post_id": comment.PostID}, nil)
 	}
-
-	// 更新父评论回复数
-	if comment.ParentID != nil {
-		if err := tx.Model(&models.Comment{}).
-			Where("id = ?", *comment.ParentID).
-			UpdateColumn("reply_count", gorm.Expr("reply_count - ?", 1)).
-			Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("更新父评论回复数失败", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-	}
-
-	tx.Commit()
 	ResponseSuccess(c, nil)
 }
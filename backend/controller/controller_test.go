@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/loginguard"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/service"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	setting.Conf.EncryptConfig = &setting.EncryptConfig{Algorithm: encrypt.AlgorithmBcrypt, BcryptCost: 4}
+	setting.Conf.DefaultAvatar = &setting.DefaultAvatar{}
+	setting.Conf.MailConfig = &setting.MailConfig{}
+	if err := encrypt.Init(setting.Conf.EncryptConfig); err != nil {
+		panic(err)
+	}
+	if err := snowflake.Init("2024-01-01", 1); err != nil {
+		panic(err)
+	}
+	if err := loginguard.Init(nil); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestControllers 基于内存仓储的接口
+func newTestControllers() (*Controllers, *repository.Repositories, *service.Services) {
+	repos := repository.NewMemory()
+	services := service.New(repos)
+	return NewControllers(repos, services), repos, services
+}
+
+// serve 调用 handler 处理一个 JSON 请求，userID 不为空时视为该用户已登录
+func serve(t *testing.T, handler gin.HandlerFunc, method, userID string, body interface{}) Response {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := gin.New()
+	r.Handle(method, "/", func(c *gin.Context) {
+		if userID != "" {
+			c.Set(CtxtUserID, userID)
+		}
+		handler(c)
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, "/", &buf)
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return resp
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/service"
+)
+
+// Controllers 通过注入的服务和仓储访问数据库的各组接口
+type Controllers struct {
+	Post     *PostController
+	Comment  *CommentController
+	Like     *LikeController
+	Favorite *FavoriteController
+	Board    *BoardController
+	User     *UserController
+	Stats    *StatsController
+}
+
+func NewControllers(repos *repository.Repositories, services *service.Services) *Controllers {
+	return &Controllers{
+		Post:     NewPostController(services.Posts, repos.Boards),
+		Comment:  NewCommentController(services.Comments),
+		Like:     NewLikeController(services.Interactions),
+		Favorite: NewFavoriteController(services.Interactions),
+		Board:    NewBoardController(repos),
+		User:     NewUserController(services.Users),
+		Stats:    NewStatsController(repos),
+	}
+}
@@ -1,15 +1,24 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 
-	"github.com/TalkSphere/backend/models"
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/service"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+	"go.uber.org/zap"
 )
 
+// FavoriteController 收藏相关接口
+type FavoriteController struct {
+	interactions *service.InteractionService
+}
+
+func NewFavoriteController(interactions *service.InteractionService) *FavoriteController {
+	return &FavoriteController{interactions: interactions}
+}
+
 // @Summary 收藏/取消收藏帖子
 // @Description 收藏或取消收藏指定帖子
 // @Tags 收藏
@@ -23,7 +32,7 @@ import (
 // @Failure 404 {object} Response "帖子不存在"
 // @Failure 500 {object} Response "服务器内部错误"
 // @Router /favorites/post/{post_id} [post]
-func CreateFavorite(c *gin.Context) {
+func (h *FavoriteController) CreateFavorite(c *gin.Context) {
 	postIDStr := c.Param("post_id")
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
@@ -37,74 +46,22 @@ func CreateFavorite(c *gin.Context) {
 		return
 	}
 
-	// 开启事务
-	tx := mysql.DB.Begin()
-
-	// 检查帖子是否存在
-	var post models.Post
-	if err := tx.First(&post, postID).Error; err != nil {
-		tx.Rollback()
-		if err == gorm.ErrRecordNotFound {
+	favorited, err := h.interactions.ToggleFavorite(c.Request.Context(), userID, postID)
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
 			ResponseError(c, CodePostNotExist)
 			return
 		}
+		zap.L().Error("收藏帖子失败", zap.Int64("post_id", postID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	// 检查是否已收藏
-	var favorite models.Favorite
-	err = tx.Where("user_id = ? AND post_id = ?", userID, postID).First(&favorite).Error
-
-	if err == nil {
-		// 已收藏，取消收藏
-		if err := tx.Delete(&favorite).Error; err != nil {
-			tx.Rollback()
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-
-		// 更新帖子收藏数
-		if err := tx.Model(&post).UpdateColumn("favorite_count",
-			gorm.Expr("favorite_count - ?", 1)).Error; err != nil {
-			tx.Rollback()
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-
-		tx.Commit()
-		ResponseSuccess(c, gin.H{"status": "unfavorited"})
-		return
-	}
-
-	if err != gorm.ErrRecordNotFound {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 创建收藏
-	favorite = models.Favorite{
-		UserID: userID,
-		PostID: postID,
-	}
-
-	if err := tx.Create(&favorite).Error; err != nil {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 更新帖子收藏数
-	if err := tx.Model(&post).UpdateColumn("favorite_count",
-		gorm.Expr("favorite_count + ?", 1)).Error; err != nil {
-		tx.Rollback()
-		ResponseError(c, CodeServerBusy)
-		return
+	status := "unfavorited"
+	if favorited {
+		status = "favorited"
 	}
-
-	tx.Commit()
-	ResponseSuccess(c, gin.H{"status": "favorited"})
+	ResponseSuccess(c, gin.H{"status": status})
 }
 
 // @Summary 获取用户收藏列表
@@ -119,7 +76,7 @@ func CreateFavorite(c *gin.Context) {
 // @Failure 401 {object} Response "未授权"
 // @Failure 500 {object} Response "服务器内部错误"
 // @Router /favorites [get]
-func GetUserFavorites(c *gin.Context) {
+func (h *FavoriteController) GetUserFavorites(c *gin.Context) {
 	userID, err := getCurrentUserIDInt64(c)
 	if err != nil {
 		ResponseError(c, CodeNeedLogin)
@@ -128,16 +85,8 @@ func GetUserFavorites(c *gin.Context) {
 
 	page, size := getPageInfo(c)
 
-	var favorites []models.Favorite
-	var total int64
-
-	db := mysql.DB.Model(&models.Favorite{}).Where("user_id = ?", userID)
-	db.Count(&total)
-
-	if err := db.Preload("Post").Preload("Post.Tags").
-		Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&favorites).Error; err != nil {
+	favorites, total, err := h.interactions.ListFavorites(c.Request.Context(), userID, int(page), int(size))
+	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
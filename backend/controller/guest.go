@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"github.com/TalkSphere/backend/pkg/jwt"
+	"github.com/TalkSphere/backend/pkg/rbac"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// IssueGuestToken 为未登录访客签发访客令牌
+// 访客令牌不代表任何用户，只用来区分访客以便限流，访客的权限由 Casbin 中 anonymous 所属的角色决定
+func IssueGuestToken(c *gin.Context) {
+	token, claims, err := jwt.GenGuestToken()
+	if err != nil {
+		zap.L().Error("签发访客令牌失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	roles, err := rbac.GetUserRoles(rbac.AnonymousSubject)
+	if err != nil {
+		zap.L().Error("获取访客角色失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, gin.H{
+		"guest_token": token,
+		"expires_in":  claims.ExpiresAt - claims.IssuedAt,
+		"roles":       roles,
+	})
+}
@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/TalkSphere/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS 发布 token 校验公钥（RFC 7517），其他服务据此校验 TalkSphere 签发的 token
+// 按照规范直接返回 JWK Set，不包装为通用响应结构
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwt.JWKS())
+}
@@ -1,17 +1,25 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 
-	"github.com/TalkSphere/backend/models"
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/service"
 
 	"go.uber.org/zap"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// LikeController 点赞相关接口
+type LikeController struct {
+	interactions *service.InteractionService
+}
+
+func NewLikeController(interactions *service.InteractionService) *LikeController {
+	return &LikeController{interactions: interactions}
+}
+
 // LikeRequest 点赞请求
 type LikeRequest struct {
 	TargetID   int64 `json:"target_id" binding:"required"`
@@ -30,7 +38,7 @@ type LikeRequest struct {
 // @Failure 401 {object} Response "未授权"
 // @Failure 500 {object} Response "服务器内部错误"
 // @Router /likes [post]
-func CreateLike(c *gin.Context) {
+func (h *LikeController) CreateLike(c *gin.Context) {
 	var req LikeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ResponseError(c, CodeInvalidParam)
@@ -43,93 +51,29 @@ func CreateLike(c *gin.Context) {
 		return
 	}
 
-	// 开启事务
-	tx := mysql.DB.Begin()
-
-	// 检查是否已点赞
-	var like models.Like
-	err = tx.Where("user_id = ? AND target_id = ? AND target_type = ?",
-		userID, req.TargetID, req.TargetType).First(&like).Error
-
-	if err == nil {
-		// 已点赞，取消点赞
-		if err := tx.Delete(&like).Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("取消点赞失败", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
+	liked, err := h.interactions.ToggleLike(c.Request.Context(), userID, req.TargetID, req.TargetType)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTarget) {
+			ResponseError(c, CodeInvalidParam)
 			return
 		}
-
-		// 更新目标点赞数
-		if req.TargetType == 1 {
-			if err := tx.Model(&models.Post{}).Where("id = ?", req.TargetID).
-				UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("更新评论点赞数失败", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
-		} else {
-			if err := tx.Model(&models.Comment{}).Where("id = ?", req.TargetID).
-				UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("更新评论点赞数失败", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
-		}
-
-		tx.Commit()
-		ResponseSuccess(c, gin.H{"status": "unliked"})
-		return
-	}
-
-	if err != gorm.ErrRecordNotFound {
-		tx.Rollback()
-		zap.L().Error("查询点赞失败", zap.Error(err))
+		zap.L().Error("点赞失败",
+			zap.Int64("target_id", req.TargetID),
+			zap.Int8("target_type", req.TargetType),
+			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	// 创建点赞
-	like = models.Like{
-		UserID:     userID,
-		TargetID:   req.TargetID,
-		TargetType: req.TargetType,
-	}
-
-	if err := tx.Create(&like).Error; err != nil {
-		tx.Rollback()
-		zap.L().Error("创建点赞失败", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 更新目标点赞数
-	if req.TargetType == 1 {
-		if err := tx.Model(&models.Post{}).Where("id = ?", req.TargetID).
-			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("更新点赞数失败", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-	} else {
-		if err := tx.Model(&models.Comment{}).Where("id = ?", req.TargetID).
-			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("更新点赞数失败", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
+	status := "unliked"
+	if liked {
+		status = "liked"
 	}
-
-	tx.Commit()
-	ResponseSuccess(c, gin.H{"status": "liked"})
+	ResponseSuccess(c, gin.H{"status": status})
 }
 
 // GetLikeStatus 获取点赞状态
-func GetLikeStatus(c *gin.Context) {
+func (h *LikeController) GetLikeStatus(c *gin.Context) {
 	// 从 URL 参数获取目标 ID 和类型
 	targetID := c.Query("target_id")
 	targetType := c.Query("target_type")
@@ -147,32 +91,16 @@ func GetLikeStatus(c *gin.Context) {
 		return
 	}
 
-	targetTypeInt, err := strconv.Atoi(targetType)
+	targetTypeInt, err := strconv.ParseInt(targetType, 10, 8)
 	if err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
 	// 查询点赞状态
-	var CheckLikeStatus func(userID int64, targetID int64, targetType int) (bool, error)
-	CheckLikeStatus = func(userID int64, targetID int64, targetType int) (bool, error) {
-		var exists bool
-		err := mysql.DB.Model(&models.Like{}).
-			Select("count(*) > 0").
-			Where("user_id = ? AND target_id = ? AND target_type = ?", userID, targetID, targetType).
-			Find(&exists).
-			Error
-
-		if err != nil {
-			zap.L().Error("检查点赞状态失败", zap.Error(err))
-			return false, err
-		}
-
-		return exists, nil
-	}
-
-	liked, err := CheckLikeStatus(userID, targetIDInt, targetTypeInt)
+	liked, err := h.interactions.IsLiked(c.Request.Context(), userID, targetIDInt, int8(targetTypeInt))
 	if err != nil {
+		zap.L().Error("检查点赞状态失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/loginguard"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LockoutResponse 登录失败记录
+type LockoutResponse struct {
+	Key         string     `json:"key"`
+	Failures    int64      `json:"failures"`
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until"`
+}
+
+// GetLoginLockouts 管理员查看登录失败记录和锁定状态
+func GetLoginLockouts(c *gin.Context) {
+	entries, err := loginguard.List()
+	if err != nil {
+		zap.L().Error("查询登录锁定记录失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	now := time.Now()
+	list := make([]LockoutResponse, 0, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		item := LockoutResponse{
+			Key:      e.Key,
+			Failures: e.Failures,
+			Locked:   e.Locked(now),
+		}
+		if item.Locked {
+			item.LockedUntil = &e.LockedUntil
+		}
+		list = append(list, item)
+	}
+	ResponseSuccess(c, list)
+}
+
+// ClearLoginLockout 管理员解除锁定，key 形如 user:alice 或 ip:1.2.3.4
+func ClearLoginLockout(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	if err := loginguard.Clear(key); err != nil {
+		zap.L().Error("解除登录锁定失败", zap.String("key", key), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	zap.L().Info("解除登录锁定",
+		zap.String("key", key),
+		zap.String("operator", c.GetString(CtxtUserID)))
+	recordAudit(c, audit.ActionLockoutClear, audit.TargetLockout, key, nil, nil)
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/jwt"
+	"github.com/TalkSphere/backend/pkg/mfa"
+	"github.com/TalkSphere/backend/pkg/session"
+	"github.com/TalkSphere/backend/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LoginMFAParams 登录第二步的请求参数，code 和 recovery_code 二选一
+type LoginMFAParams struct {
+	Ticket       string `json:"ticket" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFATicketParams 登录时绑定两步验证的请求参数
+type MFATicketParams struct {
+	Ticket string `json:"ticket" binding:"required"`
+	Code   string `json:"code"`
+}
+
+// MFACodeParams 已登录用户操作两步验证的请求参数，code 可以是验证码或恢复码
+type MFACodeParams struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// responseMFATicket 密码校验通过但还需要两步验证，返回短期票据而不是令牌
+func responseMFATicket(c *gin.Context, userID int64, purpose, flag string) {
+	ticket, err := jwt.GenTicket(userID, purpose)
+	if err != nil {
+		zap.L().Error("生成两步验证票据失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, gin.H{
+		flag:         true,
+		"ticket":     ticket,
+		"expires_in": int64(jwt.TicketExpire.Seconds()),
+	})
+}
+
+// ticketUser 解析票据并加载用户，票据签发后被封禁的用户同样不能继续登录
+func (h *UserController) ticketUser(c *gin.Context, ticket, purpose string) (*models.User, bool) {
+	claims, err := jwt.ParseTicket(ticket, purpose)
+	if err != nil {
+		ResponseError(c, CodeInvalidToken)
+		return nil, false
+	}
+	userID, err := strconv.ParseInt(claims.UserID, 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidToken)
+		return nil, false
+	}
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		ResponseError(c, CodeUserNotExist)
+		return nil, false
+	}
+	if user.Status != service.UserStatusActive {
+		ResponseError(c, CodeUserDisabled)
+		return nil, false
+	}
+	return user, true
+}
+
+// responseMFAError 把 mfa 包的错误转换为响应码
+func responseMFAError(c *gin.Context, userID int64, err error) {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		ResponseError(c, CodeInvalidMFACode)
+	case errors.Is(err, mfa.ErrNotEnrolled), errors.Is(err, mfa.ErrAlreadyEnabled):
+		ResponseError(c, CodeInvalidParam)
+	case errors.Is(err, mfa.ErrRequiredByRole):
+		ResponseError(c, CodeMFARequired)
+	default:
+		zap.L().Error("两步验证操作失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+	}
+}
+
+// LoginMFA 登录第二步，校验验证码或恢复码后签发令牌
+func (h *UserController) LoginMFA(c *gin.Context) {
+	var params LoginMFAParams
+	if err := c.ShouldBindJSON(&params); err != nil || (params.Code == "") == (params.RecoveryCode == "") {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	user, ok := h.ticketUser(c, params.Ticket, jwt.TicketPurposeVerify)
+	if !ok {
+		return
+	}
+	// 验证码只有 6 位，与密码共用失败计数，防止拿到密码后暴力猜测
+	if !checkLoginGuard(c, user.Username) {
+		return
+	}
+
+	var err error
+	if params.Code != "" {
+		err = mfa.Verify(user.ID, params.Code)
+	} else {
+		err = mfa.VerifyRecovery(user.ID, params.RecoveryCode)
+		if err == nil {
+			zap.L().Info("使用恢复码登录", zap.Int64("user_id", user.ID))
+		}
+	}
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			recordLoginFailure(c, user.Username)
+		}
+		responseMFAError(c, user.ID, err)
+		return
+	}
+
+	data, err := h.completeLogin(c, user)
+	if err != nil {
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// LoginMFAEnroll 角色要求两步验证的用户在登录过程中开始绑定
+func (h *UserController) LoginMFAEnroll(c *gin.Context) {
+	var params MFATicketParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	user, ok := h.ticketUser(c, params.Ticket, jwt.TicketPurposeEnroll)
+	if !ok {
+		return
+	}
+
+	enrollment, err := mfa.Enroll(user)
+	if err != nil {
+		responseMFAError(c, user.ID, err)
+		return
+	}
+	ResponseSuccess(c, enrollment)
+}
+
+// LoginMFAActivate 登录过程中确认绑定，成功后签发令牌并返回恢复码
+func (h *UserController) LoginMFAActivate(c *gin.Context) {
+	var params MFATicketParams
+	if err := c.ShouldBindJSON(&params); err != nil || params.Code == "" {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	user, ok := h.ticketUser(c, params.Ticket, jwt.TicketPurposeEnroll)
+	if !ok {
+		return
+	}
+	if !checkLoginGuard(c, user.Username) {
+		return
+	}
+
+	codes, err := mfa.Activate(user.ID, params.Code)
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			recordLoginFailure(c, user.Username)
+		}
+		responseMFAError(c, user.ID, err)
+		return
+	}
+
+	data, err := h.completeLogin(c, user)
+	if err != nil {
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	data["recovery_codes"] = codes
+	ResponseSuccess(c, data)
+}
+
+// GetMFAStatus 获取当前用户的两步验证状态
+func (h *UserController) GetMFAStatus(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	enabled, err := mfa.Enabled(userID)
+	if err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	required, err := mfa.Required(userID)
+	if err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	var remaining int64
+	if enabled {
+		if remaining, err = mfa.RemainingRecoveryCodes(userID); err != nil {
+			responseMFAError(c, userID, err)
+			return
+		}
+	}
+
+	ResponseSuccess(c, gin.H{
+		"enabled":                  enabled,
+		"required":                 required,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// EnrollMFA 开始绑定两步验证，返回密钥和二维码 URI
+func (h *UserController) EnrollMFA(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		ResponseError(c, CodeUserNotExist)
+		return
+	}
+
+	enrollment, err := mfa.Enroll(user)
+	if err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	ResponseSuccess(c, enrollment)
+}
+
+// ActivateMFA 确认绑定两步验证，返回恢复码（只展示这一次）
+func (h *UserController) ActivateMFA(c *gin.Context) {
+	var params MFACodeParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	codes, err := mfa.Activate(userID, params.Code)
+	if err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	ResponseSuccess(c, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA 关闭两步验证，成功后吊销该用户的所有会话
+func (h *UserController) DisableMFA(c *gin.Context) {
+	var params MFACodeParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	if err := mfa.Disable(userID, params.Code); err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	if err := session.RevokeUser(userID); err != nil {
+		zap.L().Error("吊销用户会话失败", zap.Int64("user_id", userID), zap.Error(err))
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，需要提供当前验证码
+func (h *UserController) RegenerateRecoveryCodes(c *gin.Context) {
+	var params MFACodeParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	if err := mfa.Verify(userID, params.Code); err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	codes, err := mfa.RegenerateRecoveryCodes(userID)
+	if err != nil {
+		responseMFAError(c, userID, err)
+		return
+	}
+	ResponseSuccess(c, gin.H{"recovery_codes": codes})
+}
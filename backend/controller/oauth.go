@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/oauth"
+	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/TalkSphere/backend/pkg/usertoken"
+	"github.com/TalkSphere/backend/service"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	oauthFlowCookie = "oauth_flow"
+	// oauthLoginCodeExpire 回调跳转到前端后，用一次性登录码换取令牌的有效期
+	oauthLoginCodeExpire = 2 * time.Minute
+)
+
+// 回调失败时带给前端的错误原因
+const (
+	oauthErrFailed        = "oauth_failed"
+	oauthErrInvalidState  = "invalid_state"
+	oauthErrUserDisabled  = "user_disabled"
+	oauthErrEmailExists   = "email_exists"
+	oauthErrIdentityInUse = "identity_in_use"
+	oauthErrAlreadyLinked = "already_linked"
+)
+
+// OAuthExchangeParams 用回调中的一次性登录码换取令牌
+type OAuthExchangeParams struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// GetOAuthProviders 获取可用的第三方登录方式
+func (h *UserController) GetOAuthProviders(c *gin.Context) {
+	ResponseSuccess(c, oauth.Providers())
+}
+
+// OAuthLogin 跳转到第三方授权页面
+func (h *UserController) OAuthLogin(c *gin.Context) {
+	p, err := oauth.Get(c.Param("provider"))
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	authURL, ok := startOAuthFlow(c, p, 0)
+	if !ok {
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// startOAuthFlow 创建授权流程并写入 cookie，返回第三方授权地址
+// 流程只保存在发起请求的浏览器中，回调时必须带上同一个 cookie
+func startOAuthFlow(c *gin.Context, p *oauth.Provider, linkUserID int64) (string, bool) {
+	flow, err := oauth.NewFlow(p.Name(), linkUserID)
+	if err != nil {
+		zap.L().Error("创建 OAuth 流程失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return "", false
+	}
+	authURL, err := p.AuthCodeURL(c.Request.Context(), flow)
+	if err != nil {
+		zap.L().Error("生成授权地址失败", zap.String("provider", p.Name()), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return "", false
+	}
+	sealed, err := flow.Seal()
+	if err != nil {
+		zap.L().Error("保存 OAuth 流程失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return "", false
+	}
+
+	// 回调是从第三方站点跳转回来的顶级导航，需要 Lax 才能带上 cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthFlowCookie, sealed, int(oauth.FlowExpire.Seconds()), "/api/oauth", "", c.Request.TLS != nil, true)
+	return authURL, true
+}
+
+// OAuthCallback 第三方授权回调，处理完成后跳转回前端
+// 登录时带上一次性登录码，前端通过 OAuthExchange 换取令牌；绑定时带上绑定结果
+func (h *UserController) OAuthCallback(c *gin.Context) {
+	provider := c.Param("provider")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthFlowCookie, "", -1, "/api/oauth", "", c.Request.TLS != nil, true)
+
+	sealed, err := c.Cookie(oauthFlowCookie)
+	if err != nil {
+		redirectOAuthResult(c, url.Values{"error": {oauthErrInvalidState}})
+		return
+	}
+	flow, err := oauth.OpenFlow(sealed, provider, c.Query("state"))
+	if err != nil {
+		redirectOAuthResult(c, url.Values{"error": {oauthErrInvalidState}})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		zap.L().Info("第三方授权被拒绝", zap.String("provider", provider), zap.String("error", e))
+		redirectOAuthResult(c, url.Values{"error": {oauthErrFailed}})
+		return
+	}
+
+	p, err := oauth.Get(provider)
+	if err != nil {
+		redirectOAuthResult(c, url.Values{"error": {oauthErrFailed}})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	identity, err := p.Exchange(ctx, flow, c.Query("code"))
+	if err != nil {
+		zap.L().Error("获取第三方账号信息失败", zap.String("provider", provider), zap.Error(err))
+		redirectOAuthResult(c, url.Values{"error": {oauthErrFailed}})
+		return
+	}
+
+	// 绑定第三方账号
+	if flow.LinkUserID != 0 {
+		if err := h.users.LinkIdentity(ctx, flow.LinkUserID, identity); err != nil {
+			redirectOAuthError(c, provider, err)
+			return
+		}
+		redirectOAuthResult(c, url.Values{"linked": {provider}})
+		return
+	}
+
+	// 第三方登录
+	user, err := h.resolveIdentity(ctx, p, identity)
+	if err != nil {
+		redirectOAuthError(c, provider, err)
+		return
+	}
+	if user.Status != service.UserStatusActive {
+		redirectOAuthResult(c, url.Values{"error": {oauthErrUserDisabled}})
+		return
+	}
+	code, err := usertoken.Issue(user.ID, models.TokenPurposeOAuthLogin, oauthLoginCodeExpire)
+	if err != nil {
+		zap.L().Error("生成登录码失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		redirectOAuthResult(c, url.Values{"error": {oauthErrFailed}})
+		return
+	}
+	redirectOAuthResult(c, url.Values{"code": {code}})
+}
+
+// OAuthExchange 用一次性登录码换取令牌，需要两步验证时与密码登录一样先返回票据
+func (h *UserController) OAuthExchange(c *gin.Context) {
+	var params OAuthExchangeParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	userID, err := usertoken.Consume(params.Code, models.TokenPurposeOAuthLogin, nil)
+	if err != nil {
+		if errors.Is(err, usertoken.ErrInvalidToken) {
+			ResponseError(c, CodeInvalidToken)
+			return
+		}
+		zap.L().Error("校验登录码失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		ResponseError(c, CodeUserNotExist)
+		return
+	}
+	if user.Status != service.UserStatusActive {
+		ResponseError(c, CodeUserDisabled)
+		return
+	}
+	h.respondLogin(c, user)
+}
+
+// LinkOAuthProvider 已登录用户绑定第三方账号
+// 绑定流程直接写入当前浏览器的 cookie，不能通过链接传递，否则攻击者可以把自己的绑定链接发给别人，
+// 让对方的第三方账号绑定到攻击者名下。返回的授权地址需要在同一个浏览器中打开
+func (h *UserController) LinkOAuthProvider(c *gin.Context) {
+	p, err := oauth.Get(c.Param("provider"))
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	authURL, ok := startOAuthFlow(c, p, userID)
+	if !ok {
+		return
+	}
+	ResponseSuccess(c, gin.H{"url": authURL})
+}
+
+// GetUserIdentities 获取当前用户已绑定的第三方账号
+func (h *UserController) GetUserIdentities(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	identities, err := h.users.ListIdentities(c.Request.Context(), userID)
+	if err != nil {
+		zap.L().Error("查询第三方账号失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, identities)
+}
+
+// UnlinkOAuthProvider 解绑第三方账号，没有设置密码时不能解绑最后一个第三方账号
+func (h *UserController) UnlinkOAuthProvider(c *gin.Context) {
+	provider := c.Param("provider")
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	err = h.users.UnlinkIdentity(c.Request.Context(), userID, provider)
+	switch {
+	case err == nil:
+		ResponseSuccess(c, nil)
+	case errors.Is(err, service.ErrLastLoginMethod):
+		ResponseError(c, CodeLastLoginMethod)
+	case errors.Is(err, service.ErrIdentityNotFound), errors.Is(err, service.ErrUserNotFound):
+		ResponseError(c, CodeInvalidParam)
+	default:
+		zap.L().Error("解绑第三方账号失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+	}
+}
+
+// resolveIdentity 查找第三方账号对应的本地用户，不存在时创建，并与注册接口一样设置默认角色
+func (h *UserController) resolveIdentity(ctx context.Context, p *oauth.Provider, id *oauth.Identity) (*models.User, error) {
+	user, err := h.users.ResolveIdentity(ctx, id, p.TrustEmail())
+	if !errors.Is(err, service.ErrUserNotFound) {
+		return user, err
+	}
+
+	user, err = h.users.CreateFromIdentity(ctx, id, setting.Conf.DefaultAvatar.AvatarURL)
+	if err != nil {
+		return nil, err
+	}
+	assignDefaultRole(rbac.Enforcer, user.ID)
+	zap.L().Info("通过第三方登录创建用户",
+		zap.Int64("user_id", user.ID),
+		zap.String("provider", id.Provider))
+	return user, nil
+}
+
+// redirectOAuthError 可以直接告诉前端的错误带上原因，其他错误记录日志后统一返回 oauth_failed
+func redirectOAuthError(c *gin.Context, provider string, err error) {
+	reason := oauthErrFailed
+	switch {
+	case errors.Is(err, service.ErrEmailExists):
+		reason = oauthErrEmailExists
+	case errors.Is(err, service.ErrIdentityInUse):
+		reason = oauthErrIdentityInUse
+	case errors.Is(err, service.ErrIdentityLinked):
+		reason = oauthErrAlreadyLinked
+	default:
+		zap.L().Error("处理第三方登录失败", zap.String("provider", provider), zap.Error(err))
+	}
+	redirectOAuthResult(c, url.Values{"error": {reason}})
+}
+
+// redirectOAuthResult 跳转回前端的回调页面
+func redirectOAuthResult(c *gin.Context, q url.Values) {
+	base := ""
+	if cfg := setting.Conf.OAuthConfig; cfg != nil {
+		base = strings.TrimRight(cfg.FrontendURL, "/")
+	}
+	c.Redirect(http.StatusFound, base+"/oauth/callback?"+q.Encode())
+}
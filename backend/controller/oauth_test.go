@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/TalkSphere/backend/pkg/oauth"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+func initMockProvider(t *testing.T) {
+	t.Helper()
+	cfg := &setting.OAuthConfig{Providers: []setting.OAuthProvider{{
+		Name:        "mock",
+		ClientID:    "client",
+		RedirectURL: "http://localhost/api/oauth/mock/callback",
+		AuthURL:     "http://idp.test/authorize",
+		TokenURL:    "http://idp.test/token",
+		UserInfoURL: "http://idp.test/userinfo",
+	}}}
+	if err := oauth.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { oauth.Init(nil) })
+}
+
+// startFlow 调用发起授权的 handler，用授权地址中的 state 解开写入 cookie 的流程
+// 授权地址取自跳转的 Location，或者 JSON 响应中的 url
+func startFlow(t *testing.T, handler gin.HandlerFunc, method, target, userID string) *oauth.Flow {
+	t.Helper()
+	r := gin.New()
+	r.Handle(method, "/api/oauth/:provider/:action", func(c *gin.Context) {
+		if userID != "" {
+			c.Set(CtxtUserID, userID)
+		}
+		handler(c)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+
+	authURL := w.Header().Get("Location")
+	if w.Code != http.StatusFound {
+		var resp struct {
+			Code ResCode           `json:"code"`
+			Data map[string]string `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != CodeSuccess {
+			t.Fatalf("%s %s: %s", method, target, w.Body.String())
+		}
+		authURL = resp.Data["url"]
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == oauthFlowCookie {
+			// 与 c.Cookie 一样，gin 写入 cookie 时做了转义
+			sealed, err := url.QueryUnescape(cookie.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			flow, err := oauth.OpenFlow(sealed, "mock", u.Query().Get("state"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return flow
+		}
+	}
+	t.Fatalf("%s %s: no flow cookie", method, target)
+	return nil
+}
+
+// 绑定流程由已登录用户的请求直接写入 cookie
+func TestLinkOAuthProviderSetsFlowCookie(t *testing.T) {
+	initMockProvider(t)
+	ctrl, _, _ := newTestControllers()
+
+	flow := startFlow(t, ctrl.User.LinkOAuthProvider, http.MethodPost, "/api/oauth/mock/link", "42")
+	if flow.LinkUserID != 42 {
+		t.Fatalf("link user = %d, want 42", flow.LinkUserID)
+	}
+}
+
+// 登录链接不能携带绑定身份，否则把链接发给别人就能让对方的第三方账号绑定到自己名下
+func TestOAuthLoginIgnoresLinkTicket(t *testing.T) {
+	initMockProvider(t)
+	ctrl, _, _ := newTestControllers()
+
+	target := "/api/oauth/mock/login?" + url.Values{"link_ticket": {"attacker-ticket"}}.Encode()
+	flow := startFlow(t, ctrl.User.OAuthLogin, http.MethodGet, target, "")
+	if flow.LinkUserID != 0 {
+		t.Fatalf("login flow links user %d", flow.LinkUserID)
+	}
+}
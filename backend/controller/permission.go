@@ -3,7 +3,9 @@ package controller
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/TalkSphere/backend/pkg/audit"
 	"github.com/TalkSphere/backend/pkg/rbac"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -37,14 +39,14 @@ func GetUserPermissions(c *gin.Context) {
 	}
 
 	// 获取当前用户角色
-	currentRole, err := rbac.GetUserRole(currentUserIDStr)
+	currentRoles, err := rbac.GetUserRoles(currentUserIDStr)
 	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 只有超级管理员和管理员可以查看用户权限
-	if currentRole != "super_admin" && currentRole != "admin" {
+	if !rbac.HasRole(currentRoles, "super_admin", "admin") {
 		ResponseError(c, CodeNoPermision)
 		return
 	}
@@ -82,23 +84,30 @@ func UpdateUserPermissions(c *gin.Context) {
 	}
 
 	// 获取当前用户角色
-	currentRole, err := rbac.GetUserRole(currentUserIDStr)
+	currentRoles, err := rbac.GetUserRoles(currentUserIDStr)
 	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 只有超级管理员可以修改用户权限
-	if currentRole != "super_admin" {
+	if !rbac.HasRole(currentRoles, "super_admin") {
 		ResponseError(c, CodeNoPermision)
 		return
 	}
 
+	oldPermissions, err := rbac.GetUserPermissions(targetUserID)
+	if err != nil {
+		ResponseError(c, CodeServerBusy)
+		return
+	}
 	err = rbac.UpdateUserPermissions(targetUserID, req.Permissions)
 	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
+	newPermissions, _ := rbac.GetUserPermissions(targetUserID)
+	recordAudit(c, audit.ActionUserPermissionUpdate, audit.TargetUser, targetUserID, oldPermissions, newPermissions)
 
 	ResponseSuccess(c, nil)
 }
@@ -126,14 +135,14 @@ func CheckPermission(c *gin.Context) {
 	}
 
 	// 获取当前用户角色
-	currentRole, err := rbac.GetUserRole(currentUserIDStr)
+	currentRoles, err := rbac.GetUserRoles(currentUserIDStr)
 	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 只有超级管理员和管理员可以查看用户权限
-	if currentRole != "super_admin" && currentRole != "admin" {
+	if !rbac.HasRole(currentRoles, "super_admin", "admin") {
 		ResponseError(c, CodeNoPermision)
 		return
 	}
@@ -152,6 +161,56 @@ func CheckPermission(c *gin.Context) {
 	})
 }
 
+// ExplainPermission 解释用户访问某个接口时的权限判断过程，用于排查 403
+func ExplainPermission(c *gin.Context) {
+	targetUserID := c.Param("user_id")
+	path := c.Query("path")
+	method := c.Query("method")
+	if targetUserID == "" || !strings.HasPrefix(path, "/") || method == "" {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	currentUserID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+	currentRoles, err := rbac.GetUserRoles(currentUserID)
+	if err != nil {
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	// 只有超级管理员和管理员可以查看用户权限
+	if !rbac.HasRole(currentRoles, "super_admin", "admin") {
+		ResponseError(c, CodeNoPermision)
+		return
+	}
+
+	trace, err := rbac.Explain(targetUserID, path, method)
+	if err != nil {
+		zap.L().Error("解释权限失败",
+			zap.String("user_id", targetUserID),
+			zap.String("path", path),
+			zap.String("method", method),
+			zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, trace)
+}
+
+// GetPolicyVersion 获取本实例的策略版本和同步状态，用于排查多实例之间策略不一致
+func GetPolicyVersion(c *gin.Context) {
+	status, err := rbac.GetWatcherStatus()
+	if err != nil {
+		zap.L().Error("获取策略版本失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, status)
+}
+
 // GetRolePermissions 获取角色权限
 func GetRolePermissions(c *gin.Context) {
 	role := c.Query("role")
@@ -164,6 +223,76 @@ func GetRolePermissions(c *gin.Context) {
 	ResponseSuccess(c, permissions)
 }
 
+// convertUserIDToString 将用户ID转换为字符串
+func convertUserIDToString(userIDInterface interface{}) (string, error) {
+	switch v := userIDInterface.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid user ID type: %T", v)
+	}
+}
+
+// requireRoleManager 只有超级管理员可以管理用户角色，失败时已写入响应
+func requireRoleManager(c *gin.Context) (string, bool) {
+	currentUserID, err := getCurrentUserID(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return "", false
+	}
+	currentRoles, err := rbac.GetUserRoles(currentUserID)
+	if err != nil {
+		zap.L().Error("获取当前用户角色失败",
+			zap.String("current_user_id", currentUserID),
+			zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return "", false
+	}
+	if !rbac.HasRole(currentRoles, "super_admin") {
+		zap.L().Warn("非超级管理员尝试修改用户角色",
+			zap.String("current_user_id", currentUserID),
+			zap.Strings("current_roles", currentRoles))
+		ResponseError(c, CodeNoPermision)
+		return "", false
+	}
+	return currentUserID, true
+}
+
+// checkAssignableRole 只能分配已经创建的角色，失败时已写入响应
+func checkAssignableRole(c *gin.Context, role string) bool {
+	exists, err := rbac.RoleExists(role)
+	if err != nil {
+		zap.L().Error("查询角色失败", zap.String("role", role), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return false
+	}
+	if !exists {
+		zap.L().Error("无效的角色", zap.String("role", role))
+		ResponseError(c, CodeRoleNotExist)
+		return false
+	}
+	return true
+}
+
+// userRolesResponse 用户的角色信息，roles 包含继承得到的角色，direct_roles 只包含直接分配的角色
+func userRolesResponse(userID string) (gin.H, error) {
+	direct, err := rbac.GetDirectRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := rbac.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"user_id":      userID,
+		"roles":        roles,
+		"direct_roles": direct,
+	}, nil
+}
+
 // GetUserRole 获取用户角色
 func GetUserRole(c *gin.Context) {
 	// 获取目标用户ID
@@ -174,63 +303,40 @@ func GetUserRole(c *gin.Context) {
 	}
 
 	// 检查当前用户是否有权限查看目标用户的角色
-	currentUserID, exists := c.Get(CtxtUserID)
-	if !exists {
-		ResponseError(c, CodeNeedLogin)
-		return
-	}
-
-	currentUserIDStr, err := convertUserIDToString(currentUserID)
+	currentUserID, err := getCurrentUserID(c)
 	if err != nil {
-		ResponseError(c, CodeInvalidParam)
+		ResponseError(c, CodeNeedLogin)
 		return
 	}
-
-	// 获取当前用户角色
-	currentRole, err := rbac.GetUserRole(currentUserIDStr)
+	currentRoles, err := rbac.GetUserRoles(currentUserID)
 	if err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 只有超级管理员和管理员可以查看用户角色
-	if currentRole != "super_admin" && currentRole != "admin" {
+	if !rbac.HasRole(currentRoles, "super_admin", "admin") {
 		ResponseError(c, CodeNoPermision)
 		return
 	}
 
 	// 获取目标用户角色
-	role, err := rbac.GetUserRole(targetUserID)
+	data, err := userRolesResponse(targetUserID)
 	if err != nil {
 		zap.L().Error("get user role failed", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	ResponseSuccess(c, gin.H{
-		"user_id": targetUserID,
-		"role":    role,
-	})
-}
-
-// convertUserIDToString 将用户ID转换为字符串
-func convertUserIDToString(userIDInterface interface{}) (string, error) {
-	switch v := userIDInterface.(type) {
-	case int64:
-		return strconv.FormatInt(v, 10), nil
-	case string:
-		return v, nil
-	default:
-		return "", fmt.Errorf("invalid user ID type: %T", v)
-	}
+	ResponseSuccess(c, data)
 }
 
 // UpdateUserRoleRequest 更新用户角色的请求
 type UpdateUserRoleRequest struct {
-	Role string `json:"role" binding:"required"`
+	Roles []string `json:"roles" binding:"required"`
 }
 
-// UpdateUserRole 更新用户角色
+// UpdateUserRole 用给定的角色集合替换用户直接分配的角色
 func UpdateUserRole(c *gin.Context) {
 	// 获取目标用户ID
 	targetUserID := c.Param("user_id")
@@ -239,152 +345,173 @@ func UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	zap.L().Info("开始更新用户角色",
-		zap.String("target_user_id", targetUserID))
-
-	// 检查当前用户是否有权限修改目标用户的角色
-	currentUserID, exists := c.Get(CtxtUserID)
-	if !exists {
-		zap.L().Error("当前用户未登录")
-		ResponseError(c, CodeNeedLogin)
+	currentUserID, ok := requireRoleManager(c)
+	if !ok {
 		return
 	}
 
-	currentUserIDStr, err := convertUserIDToString(currentUserID)
-	if err != nil {
-		zap.L().Error("当前用户ID转换失败",
-			zap.Any("current_user_id", currentUserID),
-			zap.Error(err))
+	// 解析请求体
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zap.L().Error("请求参数解析失败", zap.Error(err))
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	// 获取当前用户角色
-	currentRole, err := rbac.GetUserRole(currentUserIDStr)
+	// 验证角色是否有效并去重
+	roles := make([]string, 0, len(req.Roles))
+	seen := make(map[string]bool, len(req.Roles))
+	for _, role := range req.Roles {
+		if !checkAssignableRole(c, role) {
+			return
+		}
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+
+	oldRoles, err := rbac.GetDirectRoles(targetUserID)
 	if err != nil {
-		zap.L().Error("获取当前用户角色失败",
-			zap.String("current_user_id", currentUserIDStr),
+		zap.L().Error("获取目标用户当前角色失败",
+			zap.String("target_user_id", targetUserID),
 			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	zap.L().Info("当前用户信息",
-		zap.String("current_user_id", currentUserIDStr),
-		zap.String("current_role", currentRole))
-
-	// 只有超级管理员可以修改用户角色
-	if currentRole != "super_admin" {
-		zap.L().Warn("非超级管理员尝试修改用户角色",
-			zap.String("current_user_id", currentUserIDStr),
-			zap.String("current_role", currentRole))
+	// 超级管理员不能移除自己的超级管理员角色，避免系统失去管理员
+	if targetUserID == currentUserID && rbac.HasRole(oldRoles, "super_admin") && !seen["super_admin"] {
 		ResponseError(c, CodeNoPermision)
 		return
 	}
 
-	// 解析请求体
-	var req UpdateUserRoleRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		zap.L().Error("请求参数解析失败", zap.Error(err))
-		ResponseError(c, CodeInvalidParam)
+	if err := rbac.SetUserRoles(targetUserID, roles); err != nil {
+		zap.L().Error("更新用户角色失败",
+			zap.String("target_user_id", targetUserID),
+			zap.Strings("roles", roles),
+			zap.Error(err))
+		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	zap.L().Info("请求的角色更新信息",
+	zap.L().Info("用户角色更新成功",
 		zap.String("target_user_id", targetUserID),
-		zap.String("new_role", req.Role))
+		zap.Strings("old_roles", oldRoles),
+		zap.Strings("new_roles", roles))
+	recordAudit(c, audit.ActionUserRoleUpdate, audit.TargetUser, targetUserID, oldRoles, roles)
+
+	respondUserRoles(c, targetUserID)
+}
+
+// UserRoleRequest 添加单个角色的请求
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AddUserRole 为用户添加一个角色，保留已有角色
+func AddUserRole(c *gin.Context) {
+	targetUserID := c.Param("user_id")
+	if targetUserID == "" {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
 
-	// 验证角色是否有效
-	validRoles := map[string]bool{
-		"user":        true,
-		"admin":       true,
-		"super_admin": true,
+	if _, ok := requireRoleManager(c); !ok {
+		return
 	}
-	if !validRoles[req.Role] {
-		zap.L().Error("无效的角色",
-			zap.String("role", req.Role))
+
+	var req UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
+	if !checkAssignableRole(c, req.Role) {
+		return
+	}
 
-	// 获取用户当前角色
-	currentUserRole, err := rbac.GetUserRole(targetUserID)
-	if err != nil {
-		zap.L().Error("获取目标用户当前角色失败",
+	oldRoles, ok := directRolesForAudit(c, targetUserID)
+	if !ok {
+		return
+	}
+	if _, err := rbac.Enforcer.AddRoleForUser(targetUserID, req.Role); err != nil {
+		zap.L().Error("添加用户角色失败",
 			zap.String("target_user_id", targetUserID),
+			zap.String("role", req.Role),
 			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	zap.L().Info("目标用户当前角色",
+	zap.L().Info("添加用户角色成功",
 		zap.String("target_user_id", targetUserID),
-		zap.String("current_role", currentUserRole))
+		zap.String("role", req.Role))
+	newRoles, _ := rbac.GetDirectRoles(targetUserID)
+	recordAudit(c, audit.ActionUserRoleAdd, audit.TargetUser, targetUserID, oldRoles, newRoles)
 
-	// 如果角色没有变化，直接返回成功
-	if currentUserRole == req.Role {
-		zap.L().Info("用户角色未发生变化，无需更新",
-			zap.String("target_user_id", targetUserID),
-			zap.String("role", currentUserRole))
-		ResponseSuccess(c, nil)
+	respondUserRoles(c, targetUserID)
+}
+
+// RemoveUserRole 移除用户的一个角色，保留其他角色
+func RemoveUserRole(c *gin.Context) {
+	targetUserID := c.Param("user_id")
+	role := c.Param("role")
+	if targetUserID == "" || role == "" {
+		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	// 移除用户的当前角色（如果是 guest，不需要移除）
-	if currentUserRole != "" && currentUserRole != "guest" {
-		zap.L().Info("开始移除用户当前角色",
-			zap.String("target_user_id", targetUserID),
-			zap.String("current_role", currentUserRole))
-
-		if !rbac.RemoveRole(targetUserID, currentUserRole) {
-			zap.L().Error("移除用户当前角色失败",
-				zap.String("target_user_id", targetUserID),
-				zap.String("current_role", currentUserRole))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-
-		zap.L().Info("成功移除用户当前角色",
-			zap.String("target_user_id", targetUserID),
-			zap.String("removed_role", currentUserRole))
+	currentUserID, ok := requireRoleManager(c)
+	if !ok {
+		return
 	}
 
-	// 添加新角色
-	zap.L().Info("开始添加新角色",
-		zap.String("target_user_id", targetUserID),
-		zap.String("new_role", req.Role))
+	// 超级管理员不能移除自己的超级管理员角色，避免系统失去管理员
+	if targetUserID == currentUserID && role == "super_admin" {
+		ResponseError(c, CodeNoPermision)
+		return
+	}
 
-	if !rbac.AddRole(targetUserID, req.Role) {
-		zap.L().Error("添加新角色失败",
+	oldRoles, ok := directRolesForAudit(c, targetUserID)
+	if !ok {
+		return
+	}
+	if _, err := rbac.Enforcer.DeleteRoleForUser(targetUserID, role); err != nil {
+		zap.L().Error("移除用户角色失败",
 			zap.String("target_user_id", targetUserID),
-			zap.String("new_role", req.Role))
+			zap.String("role", role),
+			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	// 验证角色是否设置成功
-	newRole, err := rbac.GetUserRole(targetUserID)
+	zap.L().Info("移除用户角色成功",
+		zap.String("target_user_id", targetUserID),
+		zap.String("role", role))
+	newRoles, _ := rbac.GetDirectRoles(targetUserID)
+	recordAudit(c, audit.ActionUserRoleRemove, audit.TargetUser, targetUserID, oldRoles, newRoles)
+
+	respondUserRoles(c, targetUserID)
+}
+
+// directRolesForAudit 修改前用户直接分配的角色，用于审计记录，失败时已写入响应
+func directRolesForAudit(c *gin.Context, userID string) ([]string, bool) {
+	roles, err := rbac.GetDirectRoles(userID)
 	if err != nil {
-		zap.L().Error("验证新角色设置失败",
-			zap.String("target_user_id", targetUserID),
-			zap.Error(err))
+		zap.L().Error("获取用户角色失败", zap.String("user_id", userID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
-		return
+		return nil, false
 	}
+	return roles, true
+}
 
-	if newRole != req.Role {
-		zap.L().Error("角色设置验证失败",
-			zap.String("target_user_id", targetUserID),
-			zap.String("expected_role", req.Role),
-			zap.String("actual_role", newRole))
+// respondUserRoles 返回用户修改后的角色
+func respondUserRoles(c *gin.Context, userID string) {
+	data, err := userRolesResponse(userID)
+	if err != nil {
+		zap.L().Error("获取用户角色失败", zap.String("user_id", userID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
-
-	zap.L().Info("用户角色更新成功",
-		zap.String("target_user_id", targetUserID),
-		zap.String("old_role", currentUserRole),
-		zap.String("new_role", newRole))
-
-	ResponseSuccess(c, nil)
+	ResponseSuccess(c, data)
 }
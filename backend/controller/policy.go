@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/rbac"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 策略文件格式
+const (
+	policyFormatCSV  = "csv"
+	policyFormatJSON = "json"
+)
+
+// PolicyImportRequest 导入策略的请求，content 为 CSV 文本或 JSON 字符串
+type PolicyImportRequest struct {
+	Format  string `json:"format"`
+	Content string `json:"content" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// PolicySnapshotRequest 手动保存快照或回滚的请求
+type PolicySnapshotRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ExportPolicy 导出当前策略
+// format 为 csv（默认，下载文件）或 json，include_users=1 时包含用户的角色分配
+func ExportPolicy(c *gin.Context) {
+	format := c.DefaultQuery("format", policyFormatCSV)
+	if format != policyFormatCSV && format != policyFormatJSON {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	rules, err := rbac.ExportRules(c.Query("include_users") == "1")
+	if err != nil {
+		zap.L().Error("导出策略失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	if format == policyFormatJSON {
+		ResponseSuccess(c, rbac.FormatJSON(rules))
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="rbac_policy.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(rbac.FormatCSV(rules)))
+}
+
+// PreviewPolicyImport 预览导入后的差异，不修改策略
+func PreviewPolicyImport(c *gin.Context) {
+	importPolicy(c, true)
+}
+
+// ImportPolicy 导入策略，整体替换当前策略（用户的角色分配除外）并保存快照
+func ImportPolicy(c *gin.Context) {
+	importPolicy(c, false)
+}
+
+func importPolicy(c *gin.Context, dryRun bool) {
+	currentUserID, ok := requireRoleManager(c)
+	if !ok {
+		return
+	}
+	var req PolicyImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	var (
+		rules [][]string
+		err   error
+	)
+	switch strings.ToLower(req.Format) {
+	case "", policyFormatCSV:
+		rules, err = rbac.ParseCSV(req.Content)
+	case policyFormatJSON:
+		rules, err = rbac.ParseJSON([]byte(req.Content))
+	default:
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	if err != nil {
+		responsePolicyError(c, err)
+		return
+	}
+
+	result, err := rbac.ImportPolicy(rules, snapshotAuthor(c, currentUserID), req.Comment, dryRun)
+	if err != nil {
+		zap.L().Error("导入策略失败", zap.String("current_user_id", currentUserID), zap.Error(err))
+		responsePolicyError(c, err)
+		return
+	}
+	if result.Snapshot != nil {
+		// before、after 分别为删除和新增的规则，target_id 为导入后保存的快照
+		recordAudit(c, audit.ActionPolicyImport, audit.TargetPolicy, strconv.FormatInt(result.Snapshot.ID, 10),
+			result.Diff.Removed, result.Diff.Added)
+	}
+	ResponseSuccess(c, result)
+}
+
+// GetPolicySnapshots 分页列出策略快照
+func GetPolicySnapshots(c *gin.Context) {
+	page, size := getPageInfo(c)
+	snaps, total, err := rbac.ListSnapshots(int(page), int(size))
+	if err != nil {
+		zap.L().Error("获取策略快照失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, gin.H{
+		"snapshots": snaps,
+		"total":     total,
+	})
+}
+
+// GetPolicySnapshot 获取单个快照及其规则
+func GetPolicySnapshot(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	snap, err := rbac.GetSnapshot(id)
+	if err != nil {
+		responsePolicyError(c, err)
+		return
+	}
+	ResponseSuccess(c, snap)
+}
+
+// CreatePolicySnapshot 手动保存当前策略
+func CreatePolicySnapshot(c *gin.Context) {
+	currentUserID, ok := requireRoleManager(c)
+	if !ok {
+		return
+	}
+	var req PolicySnapshotRequest
+	_ = c.ShouldBindJSON(&req)
+	snap, err := rbac.CreateSnapshot(snapshotAuthor(c, currentUserID), req.Comment)
+	if err != nil {
+		zap.L().Error("保存策略快照失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	recordAudit(c, audit.ActionPolicySnapshot, audit.TargetPolicy, strconv.FormatInt(snap.ID, 10),
+		nil, gin.H{"comment": snap.Comment, "rule_count": snap.RuleCount})
+	ResponseSuccess(c, snap)
+}
+
+// RollbackPolicySnapshot 回滚到指定快照，dry_run=1 时只返回差异
+func RollbackPolicySnapshot(c *gin.Context) {
+	currentUserID, ok := requireRoleManager(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	var req PolicySnapshotRequest
+	_ = c.ShouldBindJSON(&req)
+
+	result, err := rbac.RollbackSnapshot(id, snapshotAuthor(c, currentUserID), req.Comment, c.Query("dry_run") == "1")
+	if err != nil {
+		zap.L().Error("回滚策略失败",
+			zap.String("current_user_id", currentUserID),
+			zap.Int64("snapshot_id", id),
+			zap.Error(err))
+		responsePolicyError(c, err)
+		return
+	}
+	if result.Snapshot != nil {
+		// target_id 为回滚到的快照
+		recordAudit(c, audit.ActionPolicyRollback, audit.TargetPolicy, strconv.FormatInt(id, 10),
+			result.Diff.Removed, result.Diff.Added)
+	}
+	ResponseSuccess(c, result)
+}
+
+// snapshotAuthor 当前用户作为快照的作者
+func snapshotAuthor(c *gin.Context, userID string) rbac.SnapshotAuthor {
+	author := rbac.SnapshotAuthor{Name: c.GetString(CtxUserName)}
+	if id, err := convertUserIDToInt64(userID); err == nil {
+		author.ID = &id
+	}
+	if author.Name == "" {
+		author.Name = userID
+	}
+	return author
+}
+
+func responsePolicyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rbac.ErrInvalidPolicyFile):
+		ResponseErrorWithMsg(c, CodeInvalidPolicyFile, err.Error())
+	case errors.Is(err, rbac.ErrSnapshotNotFound):
+		ResponseError(c, CodePolicySnapshotNotExist)
+	default:
+		ResponseError(c, CodeServerBusy)
+	}
+}
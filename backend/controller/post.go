@@ -1,24 +1,34 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/TalkSphere/backend/models"
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/rbac"
 	"github.com/TalkSphere/backend/pkg/upload"
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/microcosm-cc/bluemonday"
 	"go.uber.org/zap"
-	"golang.org/x/net/html"
-	"gorm.io/gorm"
 )
 
+// PostController 帖子相关接口
+type PostController struct {
+	posts  *service.PostService
+	boards repository.BoardRepository
+}
+
+func NewPostController(posts *service.PostService, boards repository.BoardRepository) *PostController {
+	return &PostController{posts: posts, boards: boards}
+}
+
 // CreatePostRequest 创建帖子请求参数
 type CreatePostRequest struct {
 	Title    string   `json:"title" binding:"required,min=3,max=100"`
@@ -38,7 +48,7 @@ type UpdatePostRequest struct {
 }
 
 // CreatePost 创建帖子
-func CreatePost(c *gin.Context) {
+func (h *PostController) CreatePost(c *gin.Context) {
 	var req CreatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ResponseError(c, CodeInvalidParam)
@@ -51,142 +61,32 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
-	// 对内容进行 XSS 清理
-	p := bluemonday.UGCPolicy()
-	// 允许常用的富文本标签和属性
-	p.AllowStandardURLs()
-	p.AllowStandardAttributes()
-	p.AllowImages()
-	p.AllowLists()
-	p.AllowTables()
-	p.AllowStyles("text-align", "color", "background-color", "font-size", "margin", "padding")
-	p.AllowAttrs("class").Globally()
-
-	sanitizedContent := p.Sanitize(req.Content)
-
-	// 生成摘要
-	div := strings.NewReader(sanitizedContent)
-	doc, err := html.Parse(div)
+	// 正文的 XSS 清理和摘要生成在 service 中完成
+	post, err := h.posts.Create(c.Request.Context(), service.CreatePostInput{
+		AuthorID: userID,
+		BoardID:  req.BoardID,
+		Title:    req.Title,
+		Content:  req.Content,
+		Tags:     req.Tags,
+		ImageIDs: req.ImageIDs,
+	})
 	if err != nil {
-		zap.L().Error("parse html failed", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	var text string
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		if n.Type == html.TextNode {
-			text += n.Data
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(doc)
-
-	// 清理文本并生成摘要
-	text = strings.TrimSpace(text)
-	runeText := []rune(text)
-	const maxExcerptLength = 100 // 减小长度以确保不超过数据库限制
-
-	var excerpt string
-	if len(runeText) > maxExcerptLength {
-		excerpt = string(runeText[:maxExcerptLength]) + "..."
-	} else {
-		excerpt = text
-	}
-
-	// 检查是否包含图片
-	if strings.Contains(sanitizedContent, "<img") {
-		// 确保添加图片标记后不超过数据库字段长度限制
-		imgText := " [图片]"
-		if len([]rune(excerpt))+len([]rune(imgText)) <= 250 { // 留一些余量
-			excerpt += imgText
+		if errors.Is(err, service.ErrInvalidImages) {
+			ResponseError(c, CodeInvalidParam)
+			return
 		}
-	}
-
-	// 创建帖子
-	post := &models.Post{
-		Title:    req.Title,
-		Content:  sanitizedContent,
-		Excerpt:  excerpt,
-		BoardID:  &req.BoardID,
-		AuthorID: &userID,
-	}
-
-	// 开启事务
-	tx := mysql.DB.Begin()
-	if err := tx.Create(post).Error; err != nil {
-		tx.Rollback()
 		zap.L().Error("create post failed", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
-	// 处理标签
-	if len(req.Tags) > 0 {
-		var tags []models.Tag
-		for _, tagName := range req.Tags {
-			var tag models.Tag
-			// 查找或创建标签
-			if err := tx.Where("name = ?", tagName).FirstOrCreate(&tag, models.Tag{Name: tagName}).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("create post failed", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
-			tags = append(tags, tag)
-		}
-		if err := tx.Model(post).Association("Tags").Replace(tags); err != nil {
-			tx.Rollback()
-			zap.L().Error("create post failed", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-	}
-
-	// 处理图片
-	if len(req.ImageIDs) > 0 {
-		// 验证图片所属权并更新关联
-		var images []models.PostImage
-		if err := tx.Where("id IN ? AND user_id = ? AND status = 1 AND (post_id = 0 OR post_id = ?)",
-			req.ImageIDs, userID, post.ID).Find(&images).Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("find images failed", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
-
-		// 确保所有图片都存在且属于当前用户
-		if len(images) != len(req.ImageIDs) {
-			tx.Rollback()
-			ResponseError(c, CodeInvalidParam)
-			return
-		}
-
-		// 更新图片关联
-		for i, img := range images {
-			if err := tx.Model(&img).Updates(map[string]interface{}{
-				"post_id":    post.ID,
-				"sort_order": i,
-			}).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("update image failed", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
-		}
-	}
-
-	tx.Commit()
 	ResponseSuccess(c, gin.H{
 		"post_id": post.ID,
 	})
 }
 
 // GetPostDetail 获取帖子详情
-func GetPostDetail(c *gin.Context) {
+func (h *PostController) GetPostDetail(c *gin.Context) {
 	// 1. 参数验证
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseInt(postIDStr, 10, 64)
@@ -196,18 +96,12 @@ func GetPostDetail(c *gin.Context) {
 		return
 	}
 
-	// 2. 查询帖子
-	var post models.Post
-	result := mysql.DB.Preload("Tags").
-		Preload("Images", func(db *gorm.DB) *gorm.DB {
-			return db.Where("status = ?", 1).Order("sort_order")
-		}).
-		Where("status != ?", -1). // 不查询已删除的帖子
-		First(&post, postID)
+	// 2. 查询帖子，同时在后台增加浏览量
+	post, err := h.posts.Get(c.Request.Context(), postID)
 
 	// 3. 错误处理
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
 			// 帖子不存在
 			zap.L().Info("post not found", zap.Int64("post_id", postID))
 			ResponseError(c, CodePostNotExist)
@@ -216,7 +110,7 @@ func GetPostDetail(c *gin.Context) {
 		// 数据库错误
 		zap.L().Error("query post failed",
 			zap.Int64("post_id", postID),
-			zap.Error(result.Error))
+			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -258,228 +152,198 @@ func GetPostDetail(c *gin.Context) {
 		response.ImageURLs = append(response.ImageURLs, img.ImageURL)
 	}
 
-	// 6. 异步更新浏览量
-	go func() {
-		if err := mysql.DB.Model(&post).
-			UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).
-			Error; err != nil {
-			zap.L().Error("update view count failed",
-				zap.Int64("post_id", postID),
-				zap.Error(err))
-		}
-	}()
-
 	fmt.Println(response)
 
 	ResponseSuccess(c, response)
 }
 
 // DeletePost 删除帖子
-func DeletePost(c *gin.Context) {
+func (h *PostController) DeletePost(c *gin.Context) {
 	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	userID, err := getCurrentUserIDInt64(c)
-	if err != nil {
-		ResponseError(c, CodeNeedLogin)
+	// 作者、管理员等按资源级规则检查
+	res, ok := authorize(c, rbac.ResourcePost, postID, rbac.ActDelete)
+	if !ok {
 		return
 	}
+	post := res.Object.(*models.Post)
 
-	var post models.Post
-	if err := mysql.DB.First(&post, postID).Error; err != nil {
-		ResponseError(c, CodeInvalidParam)
+	// 软删除帖子
+	if err := h.posts.Delete(c.Request.Context(), post.ID); err != nil {
+		ResponseError(c, CodeServerBusy)
 		return
 	}
+	// 作者删除自己的帖子不记录，管理员、版主删除他人帖子时记录
+	if !isResourceOwner(c, res) {
+		recordAudit(c, audit.ActionPostDelete, audit.TargetPost, res.ID,
+			gin.H{"title": post.Title, "author_id": post.AuthorID, "board_id": res.BoardID}, nil)
+	}
+
+	ResponseSuccess(c, nil)
+}
+
+// LockPostRequest 锁定帖子请求
+type LockPostRequest struct {
+	Locked *bool `json:"locked" binding:"required"`
+}
 
-	// 检查是否是帖子作者
-	if *post.AuthorID != userID {
-		ResponseError(c, CodeNoPermision)
+// PinPostRequest 置顶帖子请求
+type PinPostRequest struct {
+	Pinned *bool `json:"pinned" binding:"required"`
+}
+
+// MovePostRequest 移动帖子请求
+type MovePostRequest struct {
+	BoardID int64 `json:"board_id" binding:"required"`
+}
+
+// LockPost 锁定或解锁帖子，锁定后不能再评论
+func (h *PostController) LockPost(c *gin.Context) {
+	var req LockPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
 		return
 	}
+	h.moderatePost(c, rbac.ActLock, "is_locked", *req.Locked)
+}
 
-	// 软删除帖子
-	if err := mysql.DB.Model(&post).Update("status", -1).Error; err != nil {
-		ResponseError(c, CodeServerBusy)
+// PinPost 置顶或取消置顶帖子
+func (h *PostController) PinPost(c *gin.Context) {
+	var req PinPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
 		return
 	}
-
-	ResponseSuccess(c, nil)
+	h.moderatePost(c, rbac.ActPin, "is_pinned", *req.Pinned)
 }
 
-// UpdatePost 更新帖子
-func UpdatePost(c *gin.Context) {
-	var req UpdatePostRequest
+// MovePost 把帖子移动到其他板块，需要同时有原板块和目标板块的管理权限
+func (h *PostController) MovePost(c *gin.Context) {
+	var req MovePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
-
 	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	userID, err := getCurrentUserIDInt64(c)
-	if err != nil {
-		ResponseError(c, CodeNeedLogin)
+	res, ok := authorize(c, rbac.ResourcePost, postID, rbac.ActMove)
+	if !ok {
 		return
 	}
-
-	// 获取帖子信息
-	var post models.Post
-	if err := mysql.DB.First(&post, postID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ResponseError(c, CodeInvalidParam)
-		} else {
-			zap.L().Error("get post failed", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-		}
+	if !authorizeMove(c, h.boards, res, req.BoardID) {
 		return
 	}
+	post := res.Object.(*models.Post)
 
-	// 检查是否是作者
-	if *post.AuthorID != userID {
-		ResponseError(c, CodeNoPermision)
+	if err := h.posts.Move(c.Request.Context(), postID, req.BoardID); err != nil {
+		zap.L().Error("移动帖子失败", zap.Int64("postID", postID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
 		return
 	}
+	post.BoardID = &req.BoardID
+	zap.L().Info("移动帖子",
+		zap.Int64("postID", postID),
+		zap.String("from", res.BoardID),
+		zap.Int64("to", req.BoardID),
+		zap.String("operator", c.GetString(CtxtUserID)))
+	recordAudit(c, audit.ActionPostMove, audit.TargetPost, res.ID,
+		gin.H{"board_id": res.BoardID}, gin.H{"board_id": req.BoardID})
+	ResponseSuccess(c, post)
+}
 
-	// 对内容进行 XSS 清理
-	p := bluemonday.UGCPolicy()
-	p.AllowStandardURLs()
-	p.AllowStandardAttributes()
-	p.AllowImages()
-	p.AllowLists()
-	p.AllowTables()
-	p.AllowStyles("text-align", "color", "background-color", "font-size", "margin", "padding")
-	p.AllowAttrs("class").Globally()
-
-	// 更新帖子内容
-	updates := make(map[string]interface{})
-	if req.Title != "" {
-		updates["title"] = req.Title
+// moderatePost 版主对帖子的锁定、置顶等开关类操作
+func (h *PostController) moderatePost(c *gin.Context, act, column string, value bool) {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
 	}
-	if req.Content != "" {
-		sanitizedContent := p.Sanitize(req.Content)
-		updates["content"] = sanitizedContent
-
-		// 生成摘要
-		div := strings.NewReader(sanitizedContent)
-		doc, err := html.Parse(div)
-		if err != nil {
-			zap.L().Error("parse html failed", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
 
-		var text string
-		var f func(*html.Node)
-		f = func(n *html.Node) {
-			if n.Type == html.TextNode {
-				text += n.Data
-			}
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				f(c)
-			}
-		}
-		f(doc)
-
-		// 清理文本并生成摘要
-		text = strings.TrimSpace(text)
-		runeText := []rune(text)
-		const maxExcerptLength = 100
-
-		var excerpt string
-		if len(runeText) > maxExcerptLength {
-			excerpt = string(runeText[:maxExcerptLength]) + "..."
-		} else {
-			excerpt = text
-		}
-
-		// 检查是否包含图片
-		if strings.Contains(sanitizedContent, "<img") {
-			imgText := " [图片]"
-			if len([]rune(excerpt))+len([]rune(imgText)) <= 250 {
-				excerpt += imgText
-			}
-		}
-
-		updates["excerpt"] = excerpt
+	res, ok := authorize(c, rbac.ResourcePost, postID, act)
+	if !ok {
+		return
 	}
-	if req.BoardID != 0 {
-		updates["board_id"] = req.BoardID
+	post := res.Object.(*models.Post)
+	action, before := audit.ActionPostLock, gin.H{column: post.IsLocked}
+	if act == rbac.ActPin {
+		action, before = audit.ActionPostPin, gin.H{column: post.IsPinned}
 	}
 
-	// 开启事务
-	tx := mysql.DB.Begin()
-
-	// 更新帖子基本信息
-	if err := tx.Model(&post).Updates(updates).Error; err != nil {
-		tx.Rollback()
-		zap.L().Error("update post failed", zap.Error(err))
+	update := h.posts.SetLocked
+	if act == rbac.ActPin {
+		update = h.posts.SetPinned
+	}
+	if err := update(c.Request.Context(), postID, value); err != nil {
+		zap.L().Error("修改帖子状态失败",
+			zap.Int64("postID", postID),
+			zap.String("column", column),
+			zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
+	if act == rbac.ActPin {
+		post.IsPinned = value
+	} else {
+		post.IsLocked = value
+	}
+	zap.L().Info("管理帖子",
+		zap.Int64("postID", postID),
+		zap.String("act", act),
+		zap.Bool("value", value),
+		zap.String("operator", c.GetString(CtxtUserID)))
+	recordAudit(c, action, audit.TargetPost, res.ID, before, gin.H{column: value})
+	ResponseSuccess(c, post)
+}
 
-	// 更新标签
-	if req.Tags != nil {
-		// 删除原有标签关联
-		if err := tx.Where("post_id = ?", postID).Delete(&models.PostTag{}).Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("delete post tags failed", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
-			return
-		}
+// UpdatePost 更新帖子
+func (h *PostController) UpdatePost(c *gin.Context) {
+	var req UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
 
-		// 添加新标签
-		for _, tagName := range req.Tags {
-			// 查找或创建标签
-			var tag models.Tag
-			if err := tx.Where("name = ?", tagName).FirstOrCreate(&tag, models.Tag{Name: tagName}).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("create tag failed", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
 
-			// 创建帖子-标签关联
-			if err := tx.Create(&models.PostTag{PostID: postID, TagID: tag.ID}).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("create post tag failed", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
-		}
+	// 作者本人才能编辑帖子，规则见 Casbin 中的 p2 策略
+	res, ok := authorize(c, rbac.ResourcePost, postID, rbac.ActUpdate)
+	if !ok {
+		return
 	}
+	post := res.Object.(*models.Post)
 
-	// 更新图片关联
-	if req.ImageIDs != nil {
-		// 删除原有图片关联
-		if err := tx.Where("post_id = ?", postID).Delete(&models.PostImage{}).Error; err != nil {
-			tx.Rollback()
-			zap.L().Error("delete post images failed", zap.Error(err))
-			ResponseError(c, CodeServerBusy)
+	// 修改板块相当于移动帖子
+	if req.BoardID != 0 && (post.BoardID == nil || *post.BoardID != req.BoardID) {
+		if !authorizeMove(c, h.boards, res, req.BoardID) {
 			return
 		}
-
-		// 添加新图片关联
-		for _, imageID := range req.ImageIDs {
-			if err := tx.Create(&models.PostImage{PostID: postID, ID: imageID}).Error; err != nil {
-				tx.Rollback()
-				zap.L().Error("create post image failed", zap.Error(err))
-				ResponseError(c, CodeServerBusy)
-				return
-			}
-		}
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		zap.L().Error("commit transaction failed", zap.Error(err))
+	err = h.posts.Update(c.Request.Context(), postID, service.UpdatePostInput{
+		Title:    req.Title,
+		Content:  req.Content,
+		BoardID:  req.BoardID,
+		Tags:     req.Tags,
+		ImageIDs: req.ImageIDs,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImages) {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		zap.L().Error("update post failed", zap.Int64("postID", postID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -488,11 +352,15 @@ func UpdatePost(c *gin.Context) {
 }
 
 // GetBoardPosts 获取板块帖子列表
-func GetBoardPosts(c *gin.Context) {
+func (h *PostController) GetBoardPosts(c *gin.Context) {
 	// 获取板块ID
-	boardID := c.Param("board_id")
+	boardID, err := strconv.ParseInt(c.Param("board_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
 	zap.L().Info("开始获取板块帖子列表",
-		zap.String("board_id", boardID),
+		zap.Int64("board_id", boardID),
 		zap.String("search_query", c.Query("search_query")),
 		zap.String("search_type", c.Query("search_type")))
 
@@ -504,50 +372,18 @@ func GetBoardPosts(c *gin.Context) {
 	searchQuery := c.Query("search_query")
 	searchType := c.Query("search_type")
 
-	// 构建查询条件
-	query := mysql.DB.Model(&models.Post{}).Where("posts.board_id = ? AND posts.status != -1", boardID)
-
-	// 根据搜索类型添加搜索条件
-	if searchQuery != "" {
-		switch searchType {
-		case "username":
-			// 关联用户表进行用户名搜索
-			query = query.Joins("JOIN users ON posts.author_id = users.id").
-				Where("users.username LIKE ?", "%"+searchQuery+"%")
-		case "content":
-			// 搜索帖子内容
-			query = query.Where("posts.content LIKE ?", "%"+searchQuery+"%")
-		case "all":
-			// 同时搜索标题和内容
-			query = query.Where("(posts.title LIKE ? OR posts.content LIKE ?)",
-				"%"+searchQuery+"%", "%"+searchQuery+"%")
-		}
-	}
-
-	// 获取总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		zap.L().Error("获取帖子总数失败",
-			zap.Error(err),
-			zap.String("board_id", boardID),
-			zap.String("search_query", searchQuery),
-			zap.String("search_type", searchType))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 分页查询帖子
-	var posts []models.Post
-	if err := query.
-		Preload("Author"). // 预加载作者信息
-		Preload("Images"). // 预加载图片信息
-		Offset((page - 1) * size).
-		Limit(size).
-		Order("created_at DESC").
-		Find(&posts).Error; err != nil {
+	// 分页查询帖子，带作者和图片信息
+	posts, total, err := h.posts.ListByBoard(c.Request.Context(), repository.PostQuery{
+		BoardID:    boardID,
+		Search:     searchQuery,
+		SearchType: searchType,
+		Page:       page,
+		Size:       size,
+	})
+	if err != nil {
 		zap.L().Error("查询帖子列表失败",
 			zap.Error(err),
-			zap.String("board_id", boardID),
+			zap.Int64("board_id", boardID),
 			zap.String("search_query", searchQuery),
 			zap.String("search_type", searchType),
 			zap.Int("page", page),
@@ -611,7 +447,7 @@ func GetBoardPosts(c *gin.Context) {
 }
 
 // UploadPostImage 上传帖子图片
-func UploadPostImage(c *gin.Context) {
+func (h *PostController) UploadPostImage(c *gin.Context) {
 	zap.L().Info("开始处理图片上传请求")
 
 	// 打印所有接收到的表单字段名
@@ -658,17 +494,12 @@ func UploadPostImage(c *gin.Context) {
 	zap.L().Info("图片成功上传到OSS",
 		zap.String("image_url", imageURL))
 
-	postImage := &models.PostImage{
-		UserID:    userID,
-		ImageURL:  imageURL,
-		Status:    1,
-		SortOrder: 0,
-	}
-
-	if err := mysql.DB.Create(postImage).Error; err != nil {
+	postImage, err := h.posts.SaveImage(c.Request.Context(), userID, imageURL)
+	if err != nil {
 		zap.L().Error("保存图片记录到数据库失败",
 			zap.Error(err),
-			zap.Any("post_image", postImage))
+			zap.Int64("user_id", userID),
+			zap.String("image_url", imageURL))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -683,7 +514,7 @@ func UploadPostImage(c *gin.Context) {
 }
 
 // GetUserPosts 获取用户的帖子列表
-func GetUserPosts(c *gin.Context) {
+func (h *PostController) GetUserPosts(c *gin.Context) {
 	userID, err := getCurrentUserIDInt64(c)
 	if err != nil {
 		ResponseError(c, CodeNeedLogin)
@@ -698,30 +529,8 @@ func GetUserPosts(c *gin.Context) {
 		zap.Int64("page", page),
 		zap.Int64("size", size))
 
-	var posts []models.Post
-	var total int64
-
-	// 构建查询
-	db := mysql.DB.Model(&models.Post{}).Where("author_id = ? AND status != -1", userID)
-
-	// 获取总数
-	if err := db.Count(&total).Error; err != nil {
-		zap.L().Error("获取帖子总数失败",
-			zap.Error(err),
-			zap.Int64("user_id", userID))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-	zap.L().Info("获取到帖子总数", zap.Int64("total", total))
-
-	// 查询帖子数据
-	if err := db.Preload("Author").
-		Preload("Tags").
-		Preload("Images").
-		Order("created_at DESC").
-		Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&posts).Error; err != nil {
+	posts, total, err := h.posts.ListByAuthor(c.Request.Context(), userID, int(page), int(size))
+	if err != nil {
 		zap.L().Error("查询用户帖子失败",
 			zap.Error(err),
 			zap.Int64("user_id", userID),
@@ -755,7 +564,7 @@ func GetUserPosts(c *gin.Context) {
 }
 
 // GetUserLikedPosts 获取用户点赞的帖子
-func GetUserLikedPosts(c *gin.Context) {
+func (h *PostController) GetUserLikedPosts(c *gin.Context) {
 	userID, err := getCurrentUserIDInt64(c)
 	if err != nil {
 		ResponseError(c, CodeNeedLogin)
@@ -771,7 +580,7 @@ func GetUserLikedPosts(c *gin.Context) {
 		zap.Int64("size", size))
 
 	// 获取帖子和总数
-	posts, total, err := getUserLikedPosts(userID, page, size)
+	posts, total, err := h.getUserLikedPosts(c.Request.Context(), userID, page, size)
 	if err != nil {
 		zap.L().Error("获取用户点赞帖子失败",
 			zap.Error(err),
@@ -793,38 +602,13 @@ func GetUserLikedPosts(c *gin.Context) {
 	})
 }
 
-func getUserLikedPosts(userID int64, page, size int64) ([]models.Post, int64, error) {
+func (h *PostController) getUserLikedPosts(ctx context.Context, userID int64, page, size int64) ([]models.Post, int64, error) {
 	zap.L().Info("开始查询用户点赞帖子",
 		zap.Int64("user_id", userID),
 		zap.Int64("page", page),
 		zap.Int64("size", size))
 
-	var posts []models.Post
-	var total int64
-
-	// 构建基础查询
-	db := mysql.DB.Table("posts").
-		Joins("JOIN likes ON posts.id = likes.target_id").
-		Where("likes.user_id = ? AND likes.target_type = 1 AND posts.status != -1", userID)
-
-	// 获取总数
-	if err := db.Count(&total).Error; err != nil {
-		zap.L().Error("获取点赞帖子总数失败",
-			zap.Error(err),
-			zap.Int64("user_id", userID))
-		return nil, 0, err
-	}
-	zap.L().Info("获取到点赞帖子总数", zap.Int64("total", total))
-
-	// 查询帖子数据
-	err := db.Preload("Author").
-		Preload("Tags").
-		Preload("Images").
-		Order("likes.created_at DESC").
-		Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&posts).Error
-
+	posts, total, err := h.posts.ListLikedBy(ctx, userID, int(page), int(size))
 	if err != nil {
 		zap.L().Error("查询点赞帖子详情失败",
 			zap.Error(err),
@@ -853,7 +637,7 @@ func getUserLikedPosts(userID int64, page, size int64) ([]models.Post, int64, er
 }
 
 // GetUserFavoritePosts 获取用户收藏的帖子
-func GetUserFavoritePosts(c *gin.Context) {
+func (h *PostController) GetUserFavoritePosts(c *gin.Context) {
 	userID, err := getCurrentUserIDInt64(c)
 	if err != nil {
 		ResponseError(c, CodeNeedLogin)
@@ -869,7 +653,7 @@ func GetUserFavoritePosts(c *gin.Context) {
 		zap.Int64("size", size))
 
 	// 获取帖子和总数
-	posts, total, err := getUserFavoritePosts(userID, page, size)
+	posts, total, err := h.getUserFavoritePosts(c.Request.Context(), userID, page, size)
 	if err != nil {
 		zap.L().Error("获取用户收藏帖子失败",
 			zap.Error(err),
@@ -891,38 +675,13 @@ func GetUserFavoritePosts(c *gin.Context) {
 	})
 }
 
-func getUserFavoritePosts(userID int64, page, size int64) ([]models.Post, int64, error) {
+func (h *PostController) getUserFavoritePosts(ctx context.Context, userID int64, page, size int64) ([]models.Post, int64, error) {
 	zap.L().Info("开始查询用户收藏帖子",
 		zap.Int64("user_id", userID),
 		zap.Int64("page", page),
 		zap.Int64("size", size))
 
-	var posts []models.Post
-	var total int64
-
-	// 构建基础查询
-	db := mysql.DB.Table("posts").
-		Joins("JOIN favorites ON posts.id = favorites.post_id").
-		Where("favorites.user_id = ? AND posts.status != -1", userID)
-
-	// 获取总数
-	if err := db.Count(&total).Error; err != nil {
-		zap.L().Error("获取收藏帖子总数失败",
-			zap.Error(err),
-			zap.Int64("user_id", userID))
-		return nil, 0, err
-	}
-	zap.L().Info("获取到收藏帖子总数", zap.Int64("total", total))
-
-	// 查询帖子数据
-	err := db.Preload("Author").
-		Preload("Tags").
-		Preload("Images").
-		Order("favorites.created_at DESC").
-		Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&posts).Error
-
+	posts, total, err := h.posts.ListFavoritedBy(ctx, userID, int(page), int(size))
 	if err != nil {
 		zap.L().Error("查询收藏帖子详情失败",
 			zap.Error(err),
@@ -951,7 +710,7 @@ func getUserFavoritePosts(userID int64, page, size int64) ([]models.Post, int64,
 }
 
 // GetUserCommentedPosts 获取用户评论过的帖子
-func GetUserCommentedPosts(c *gin.Context) {
+func (h *PostController) GetUserCommentedPosts(c *gin.Context) {
 	userID, err := getCurrentUserIDInt64(c)
 	if err != nil {
 		ResponseError(c, CodeNeedLogin)
@@ -967,7 +726,7 @@ func GetUserCommentedPosts(c *gin.Context) {
 		zap.Int64("size", size))
 
 	// 获取帖子和总数
-	posts, total, err := getUserCommentedPosts(userID, page, size)
+	posts, total, err := h.getUserCommentedPosts(c.Request.Context(), userID, page, size)
 	if err != nil {
 		zap.L().Error("获取用户评论过的帖子失败",
 			zap.Error(err),
@@ -989,39 +748,13 @@ func GetUserCommentedPosts(c *gin.Context) {
 	})
 }
 
-func getUserCommentedPosts(userID int64, page, size int64) ([]models.Post, int64, error) {
+func (h *PostController) getUserCommentedPosts(ctx context.Context, userID int64, page, size int64) ([]models.Post, int64, error) {
 	zap.L().Info("开始查询用户评论过的帖子",
 		zap.Int64("user_id", userID),
 		zap.Int64("page", page),
 		zap.Int64("size", size))
 
-	var posts []models.Post
-	var total int64
-
-	// 构建基础查询
-	db := mysql.DB.Table("posts").
-		Joins("JOIN comments ON posts.id = comments.post_id").
-		Where("comments.user_id = ? AND comments.status = 1 AND posts.status != -1", userID).
-		Group("posts.id") // 使用 GROUP BY 去重，因为一个用户可能对同一个帖子评论多次
-
-	// 获取总数
-	if err := db.Count(&total).Error; err != nil {
-		zap.L().Error("获取评论过的帖子总数失败",
-			zap.Error(err),
-			zap.Int64("user_id", userID))
-		return nil, 0, err
-	}
-	zap.L().Info("获取到评论过的帖子总数", zap.Int64("total", total))
-
-	// 查询帖子数据
-	err := db.Preload("Author").
-		Preload("Tags").
-		Preload("Images").
-		Order("MAX(comments.created_at) DESC"). // 按最新评论时间排序
-		Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&posts).Error
-
+	posts, total, err := h.posts.ListCommentedBy(ctx, userID, int(page), int(size))
 	if err != nil {
 		zap.L().Error("查询评论过的帖子详情失败",
 			zap.Error(err),
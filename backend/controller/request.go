@@ -5,12 +5,18 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/TalkSphere/backend/pkg/rbac"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 const CtxtUserID = "userID"
 const CtxUserName = "userName"
+const CtxSessionID = "sessionID"
+const CtxTokenScopes = "tokenScopes" // 使用个人访问令牌时的 scope 列表
+const CtxGuestID = "guestID"         // 持有访客令牌的未登录访客
+const CtxRoles = "roles"             // 当前身份的全部角色（包括继承得到的）
+const CtxRequestID = "requestID"     // 请求ID，用于关联日志和审计记录
 
 var ErrorUserNotLogin = errors.New("用户未登录")
 
@@ -22,7 +28,7 @@ func getCurrentUserID(c *gin.Context) (userID string, err error) {
 		return
 	}
 	userID, ok = uid.(string)
-	if !ok {
+	if !ok || userID == rbac.AnonymousSubject {
 		err = ErrorUserNotLogin
 		return
 	}
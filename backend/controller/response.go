@@ -28,21 +28,51 @@ const (
 	CodeNoPermision
 	CodePostNotExist
 	CodeCommentNotExist
+	CodeUserDisabled
+	CodeEmailNotVerified
+	CodeInvalidMFACode
+	CodeMFARequired
+	CodeLastLoginMethod
+	CodeTooManyAttempts
+	CodeTooManyRequests
+	CodeRoleNotExist
+	CodeRoleExist
+	CodeRoleProtected
+	CodeRoleInUse
+	CodeBoardNotExist
+	CodePostLocked
+	CodeInvalidPolicyFile
+	CodePolicySnapshotNotExist
 )
 
 var codeMsgMap = map[ResCode]string{
-	CodeSuccess:         "success",
-	CodeInvalidParam:    "请求参数错误",
-	CodeUserExist:       "用户名存在",
-	CodeUserNotExist:    "用户名不存在",
-	CodeInvalidPassword: "用户名或密码错误",
-	CodeServerBusy:      "服务繁忙",
-	CodeEmailExist:      "邮箱已存在",
-	CodeNeedLogin:       "需要登录",
-	CodeInvalidToken:    "无效的 token",
-	CodeNoPermision:     "权限不足",
-	CodePostNotExist:    "帖子不存在",
-	CodeCommentNotExist: "评论不存在",
+	CodeSuccess:                "success",
+	CodeInvalidParam:           "请求参数错误",
+	CodeUserExist:              "用户名存在",
+	CodeUserNotExist:           "用户名不存在",
+	CodeInvalidPassword:        "用户名或密码错误",
+	CodeServerBusy:             "服务繁忙",
+	CodeEmailExist:             "邮箱已存在",
+	CodeNeedLogin:              "需要登录",
+	CodeInvalidToken:           "无效的 token",
+	CodeNoPermision:            "权限不足",
+	CodePostNotExist:           "帖子不存在",
+	CodeCommentNotExist:        "评论不存在",
+	CodeUserDisabled:           "账号已被禁用",
+	CodeEmailNotVerified:       "邮箱未验证",
+	CodeInvalidMFACode:         "两步验证码错误",
+	CodeMFARequired:            "当前角色必须启用两步验证",
+	CodeLastLoginMethod:        "不能解除唯一的登录方式",
+	CodeTooManyAttempts:        "尝试次数过多，请稍后再试",
+	CodeTooManyRequests:        "请求过于频繁，请稍后再试",
+	CodeRoleNotExist:           "角色不存在",
+	CodeRoleExist:              "角色已存在",
+	CodeRoleProtected:          "超级管理员角色不能修改，内置角色不能删除",
+	CodeRoleInUse:              "角色仍有用户或子角色，不能删除",
+	CodeBoardNotExist:          "板块不存在",
+	CodePostLocked:             "帖子已锁定，不能评论",
+	CodeInvalidPolicyFile:      "策略文件格式错误",
+	CodePolicySnapshotNotExist: "策略快照不存在",
 }
 
 func (rc ResCode) Msg() string {
@@ -62,6 +92,15 @@ func ResponseError(c *gin.Context, code ResCode) {
 	})
 }
 
+// ResponseErrorWithMsg 返回错误响应，使用自定义的提示信息
+func ResponseErrorWithMsg(c *gin.Context, code ResCode, msg string) {
+	c.JSON(http.StatusOK, &Response{
+		Code: code,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
 // ResponseSuccess 返回成功响应
 func ResponseSuccess(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, &Response{
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/rbac"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateRoleRequest 创建角色的请求
+type CreateRoleRequest struct {
+	Name string `json:"name" binding:"required"`
+	rbac.RoleSpec
+}
+
+// CloneRoleRequest 复制角色的请求
+type CloneRoleRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// GetRoles 获取全部角色及其权限、继承关系
+func GetRoles(c *gin.Context) {
+	roles, err := rbac.ListRoles()
+	if err != nil {
+		zap.L().Error("获取角色列表失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, roles)
+}
+
+// GetRoleDetail 获取单个角色
+func GetRoleDetail(c *gin.Context) {
+	role, err := rbac.GetRole(c.Param("name"))
+	if err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	ResponseSuccess(c, role)
+}
+
+// CreateRole 创建自定义角色
+func CreateRole(c *gin.Context) {
+	if _, ok := requireRoleManager(c); !ok {
+		return
+	}
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	role, err := rbac.CreateRole(req.Name, req.RoleSpec)
+	if err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	recordAudit(c, audit.ActionRoleCreate, audit.TargetRole, role.Name, nil, role)
+	ResponseSuccess(c, role)
+}
+
+// UpdateRole 修改角色的描述、权限和继承关系
+func UpdateRole(c *gin.Context) {
+	if _, ok := requireRoleManager(c); !ok {
+		return
+	}
+	var req rbac.RoleSpec
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	before, err := rbac.GetRole(c.Param("name"))
+	if err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	role, err := rbac.UpdateRole(c.Param("name"), req)
+	if err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	recordAudit(c, audit.ActionRoleUpdate, audit.TargetRole, role.Name, before, role)
+	ResponseSuccess(c, role)
+}
+
+// CloneRole 以已有角色为模板创建新角色
+func CloneRole(c *gin.Context) {
+	if _, ok := requireRoleManager(c); !ok {
+		return
+	}
+	var req CloneRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	role, err := rbac.CloneRole(c.Param("name"), req.Name, req.Description)
+	if err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	recordAudit(c, audit.ActionRoleClone, audit.TargetRole, role.Name, gin.H{"source": c.Param("name")}, role)
+	ResponseSuccess(c, role)
+}
+
+// DeleteRole 删除自定义角色
+func DeleteRole(c *gin.Context) {
+	if _, ok := requireRoleManager(c); !ok {
+		return
+	}
+	before, err := rbac.GetRole(c.Param("name"))
+	if err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	if err := rbac.DeleteRole(c.Param("name")); err != nil {
+		responseRoleError(c, err)
+		return
+	}
+	recordAudit(c, audit.ActionRoleDelete, audit.TargetRole, before.Name, before, nil)
+	ResponseSuccess(c, nil)
+}
+
+func responseRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rbac.ErrRoleNotFound):
+		ResponseError(c, CodeRoleNotExist)
+	case errors.Is(err, rbac.ErrRoleExists):
+		ResponseError(c, CodeRoleExist)
+	case errors.Is(err, rbac.ErrProtectedRole):
+		ResponseError(c, CodeRoleProtected)
+	case errors.Is(err, rbac.ErrRoleInUse):
+		ResponseError(c, CodeRoleInUse)
+	case errors.Is(err, rbac.ErrInvalidRoleName),
+		errors.Is(err, rbac.ErrInvalidPolicy),
+		errors.Is(err, rbac.ErrRoleCycle):
+		ResponseError(c, CodeInvalidParam)
+	default:
+		zap.L().Error("角色操作失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+	}
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/session"
+	"github.com/TalkSphere/backend/pkg/useragent"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenParams 刷新令牌请求参数
+type RefreshTokenParams struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshTokenHandler 使用 refresh token 换取新的令牌对
+func RefreshTokenHandler(c *gin.Context) {
+	var params RefreshTokenParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	tokens, err := session.Refresh(params.RefreshToken, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, session.ErrInvalidRefreshToken) ||
+			errors.Is(err, session.ErrSessionExpired) ||
+			errors.Is(err, session.ErrSessionRevoked) ||
+			errors.Is(err, session.ErrRefreshTokenReused) {
+			ResponseError(c, CodeInvalidToken)
+			return
+		}
+		zap.L().Error("刷新令牌失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	ResponseSuccess(c, gin.H{
+		"token":         "Bearer " + tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// LogoutHandler 退出登录，吊销当前会话
+func LogoutHandler(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.GetString(CtxSessionID), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidToken)
+		return
+	}
+
+	if err := session.Revoke(sessionID); err != nil {
+		zap.L().Error("吊销会话失败", zap.Int64("session_id", sessionID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	ResponseSuccess(c, nil)
+}
+
+// SessionResponse 登录会话（设备）信息
+type SessionResponse struct {
+	ID         string           `json:"id"`
+	Device     useragent.Device `json:"device"`
+	UserAgent  string           `json:"user_agent"`
+	IP         string           `json:"ip"`
+	Current    bool             `json:"current"`
+	CreatedAt  time.Time        `json:"created_at"`
+	LastSeenAt *time.Time       `json:"last_seen_at"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+}
+
+// clientInfo 当前请求的客户端信息
+func clientInfo(c *gin.Context) session.Client {
+	return session.Client{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+// currentSessionID 当前请求所属的会话，使用个人访问令牌时为 0
+func currentSessionID(c *gin.Context) int64 {
+	id, _ := strconv.ParseInt(c.GetString(CtxSessionID), 10, 64)
+	return id
+}
+
+func toSessionResponses(sessions []models.UserSession, currentID int64) []SessionResponse {
+	list := make([]SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		s := &sessions[i]
+		list = append(list, SessionResponse{
+			ID:         strconv.FormatInt(s.ID, 10),
+			Device:     useragent.Parse(s.UserAgent),
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			Current:    s.ID == currentID,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+	return list
+}
+
+// GetUserSessions 获取当前用户已登录的设备
+func GetUserSessions(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	sessions, err := session.List(userID)
+	if err != nil {
+		zap.L().Error("查询会话失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, toSessionResponses(sessions, currentSessionID(c)))
+}
+
+// RevokeUserSession 退出某个设备
+func RevokeUserSession(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	revokeSession(c, userID, sessionID)
+}
+
+// RevokeOtherSessions 退出除当前设备外的所有设备
+func RevokeOtherSessions(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	n, err := session.RevokeOthers(userID, currentSessionID(c))
+	if err != nil {
+		zap.L().Error("退出其他设备失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, gin.H{"revoked": n})
+}
+
+// AdminGetUserSessions 管理员查看指定用户已登录的设备
+func AdminGetUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	sessions, err := session.List(userID)
+	if err != nil {
+		zap.L().Error("查询会话失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, toSessionResponses(sessions, 0))
+}
+
+// AdminRevokeUserSession 管理员让指定用户的某个设备下线
+func AdminRevokeUserSession(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	if revokeSession(c, userID, sessionID) {
+		recordAudit(c, audit.ActionUserSessionRevoke, audit.TargetUser, strconv.FormatInt(userID, 10),
+			nil, gin.H{"session_id": sessionID})
+	}
+}
+
+// AdminRevokeAllUserSessions 管理员让指定用户的所有设备下线
+func AdminRevokeAllUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	if err := session.RevokeUser(userID); err != nil {
+		zap.L().Error("吊销用户会话失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	zap.L().Info("管理员吊销用户全部会话",
+		zap.Int64("user_id", userID),
+		zap.String("operator", c.GetString(CtxtUserID)))
+	recordAudit(c, audit.ActionUserSessionRevoke, audit.TargetUser, strconv.FormatInt(userID, 10),
+		nil, gin.H{"session_id": "all"})
+	ResponseSuccess(c, nil)
+}
+
+// revokeSession 吊销用户的一个会话并写入响应，返回是否成功
+func revokeSession(c *gin.Context, userID, sessionID int64) bool {
+	if err := session.RevokeForUser(userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ResponseError(c, CodeInvalidParam)
+			return false
+		}
+		zap.L().Error("吊销会话失败", zap.Int64("session_id", sessionID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return false
+	}
+	ResponseSuccess(c, nil)
+	return true
+}
@@ -1,37 +1,51 @@
 package controller
 
 import (
-	"github.com/TalkSphere/backend/models"
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/repository"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func GetSystemStats(c *gin.Context) {
+// StatsController 管理后台的系统统计
+type StatsController struct {
+	repos *repository.Repositories
+}
+
+func NewStatsController(repos *repository.Repositories) *StatsController {
+	return &StatsController{repos: repos}
+}
+
+func (h *StatsController) GetSystemStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
 	// 获取用户总数
-	userCount, err := getUserCount()
+	userCount, err := h.repos.Users.CountActive(ctx)
 	if err != nil {
+		zap.L().Error("获取用户总数失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 获取帖子总数
-	postCount, err := getPostCount()
+	postCount, err := h.repos.Posts.CountPublished(ctx)
 	if err != nil {
+		zap.L().Error("获取帖子总数失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 获取板块总数
-	boardCount, err := getBoardCount()
+	boardCount, err := h.repos.Boards.Count(ctx)
 	if err != nil {
+		zap.L().Error("获取板块总数失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 获取评论数量
-	commentCount, err := getCommentCount()
+	commentCount, err := h.repos.Comments.CountActive(ctx)
 	if err != nil {
+		zap.L().Error("获取评论数量失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -43,43 +57,3 @@ func GetSystemStats(c *gin.Context) {
 		"commentCount": commentCount,
 	})
 }
-
-func getUserCount() (int64, error) {
-	var count int64
-	result := mysql.DB.Model(&models.User{}).Where("status = ?", 1).Count(&count)
-	if result.Error != nil {
-		zap.L().Error("get user count failed", zap.Error(result.Error))
-		return 0, result.Error
-	}
-	return count, nil
-}
-
-func getPostCount() (int64, error) {
-	var count int64
-	result := mysql.DB.Model(&models.Post{}).Where("status = ?", 1).Count(&count)
-	if result.Error != nil {
-		zap.L().Error("get post count failed", zap.Error(result.Error))
-		return 0, result.Error
-	}
-	return count, nil
-}
-
-func getBoardCount() (int64, error) {
-	var count int64
-	result := mysql.DB.Model(&models.Board{}).Count(&count)
-	if result.Error != nil {
-		zap.L().Error("get board count failed", zap.Error(result.Error))
-		return 0, result.Error
-	}
-	return count, nil
-}
-
-func getCommentCount() (int64, error) {
-	var count int64
-	result := mysql.DB.Model(&models.Comment{}).Where("status = ?", 1).Count(&count)
-	if result.Error != nil {
-		zap.L().Error("get comment count failed", zap.Error(result.Error))
-		return 0, result.Error
-	}
-	return count, nil
-}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/TalkSphere/backend/models"
+)
+
+func TestGetSystemStats(t *testing.T) {
+	ctx := context.Background()
+	ctrl, repos, services := newTestControllers()
+	registerTestUser(t, services, "alice")
+	disabled := registerTestUser(t, services, "bob")
+	if err := repos.Users.Update(ctx, disabled, map[string]interface{}{"status": 0}); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []*models.Board{{Name: "综合"}, {Name: "已停用", Status: 0}} {
+		if err := repos.Boards.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var posts []*models.Post
+	for i := 0; i < 3; i++ {
+		boardID := int64(1)
+		p := &models.Post{Title: "标题", Content: "正文", BoardID: &boardID}
+		if err := repos.Posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, p)
+	}
+	if err := repos.Posts.Update(ctx, posts[2].ID, map[string]interface{}{"status": -1}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := repos.Comments.Create(ctx, &models.Comment{PostID: posts[0].ID, UserID: 1, Content: "评论"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp := serve(t, ctrl.Stats.GetSystemStats, http.MethodGet, "", nil)
+	if resp.Code != CodeSuccess {
+		t.Fatalf("code = %d, want %d", resp.Code, CodeSuccess)
+	}
+	want := map[string]float64{"userCount": 1, "postCount": 2, "boardCount": 2, "commentCount": 2}
+	data := resp.Data.(map[string]interface{})
+	for key, n := range want {
+		if data[key] != n {
+			t.Errorf("%s = %v, want %v", key, data[key], n)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/TalkSphere/backend/pkg/pat"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CreateTokenParams 创建访问令牌的请求参数
+type CreateTokenParams struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// 有效天数，0 表示永不过期
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0,max=365"`
+}
+
+// TokenResponse 访问令牌信息
+type TokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// GetTokenScopes 获取可申请的 scope 列表
+func GetTokenScopes(c *gin.Context) {
+	ResponseSuccess(c, pat.Scopes())
+}
+
+// GetUserTokens 获取当前用户的访问令牌
+func GetUserTokens(c *gin.Context) {
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	tokens, err := pat.List(userID)
+	if err != nil {
+		zap.L().Error("查询访问令牌失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	list := make([]TokenResponse, 0, len(tokens))
+	for i := range tokens {
+		t := &tokens[i]
+		list = append(list, TokenResponse{
+			ID:         strconv.FormatInt(t.ID, 10),
+			Name:       t.Name,
+			Prefix:     t.Prefix,
+			Scopes:     t.ScopeList(),
+			ExpiresAt:  t.ExpiresAt,
+			LastUsedAt: t.LastUsedAt,
+			LastUsedIP: t.LastUsedIP,
+			CreatedAt:  t.CreatedAt,
+		})
+	}
+	ResponseSuccess(c, list)
+}
+
+// CreateToken 创建访问令牌，明文令牌只在这里返回一次
+func CreateToken(c *gin.Context) {
+	var params CreateTokenParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	ttl := time.Duration(params.ExpiresInDays) * 24 * time.Hour
+	plain, t, err := pat.Create(userID, params.Name, params.Scopes, ttl)
+	if err != nil {
+		if errors.Is(err, pat.ErrInvalidScope) || errors.Is(err, pat.ErrTooManyTokens) {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		zap.L().Error("创建访问令牌失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	zap.L().Info("创建访问令牌",
+		zap.Int64("user_id", userID),
+		zap.Int64("token_id", t.ID),
+		zap.Strings("scopes", t.ScopeList()))
+	ResponseSuccess(c, gin.H{
+		"token": plain,
+		"info": TokenResponse{
+			ID:        strconv.FormatInt(t.ID, 10),
+			Name:      t.Name,
+			Prefix:    t.Prefix,
+			Scopes:    t.ScopeList(),
+			ExpiresAt: t.ExpiresAt,
+			CreatedAt: t.CreatedAt,
+		},
+	})
+}
+
+// RevokeToken 吊销访问令牌
+func RevokeToken(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
+		return
+	}
+
+	if err := pat.Revoke(userID, tokenID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ResponseError(c, CodeInvalidParam)
+			return
+		}
+		zap.L().Error("吊销访问令牌失败", zap.Int64("token_id", tokenID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -1,15 +1,20 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"math"
 	"time"
 
 	"github.com/TalkSphere/backend/models"
-	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/audit"
 	"github.com/TalkSphere/backend/pkg/jwt"
-	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/loginguard"
+	"github.com/TalkSphere/backend/pkg/mfa"
 	"github.com/TalkSphere/backend/pkg/rbac"
-	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/pkg/session"
 	"github.com/TalkSphere/backend/pkg/upload"
+	"github.com/TalkSphere/backend/service"
 	"github.com/TalkSphere/backend/setting"
 
 	"strconv"
@@ -48,9 +53,18 @@ type ProfileResponse struct {
 	Bio      string `json:"bio"`
 }
 
-// TODO 未来对接邮箱注册
+// UserController 注册、登录、个人资料和用户管理相关接口
+// 两步验证、第三方账号、邮箱验证和找回密码的接口也挂在这里，查询用户都通过 UserService
+type UserController struct {
+	users *service.UserService
+}
+
+func NewUserController(users *service.UserService) *UserController {
+	return &UserController{users: users}
+}
 
-func RegisterHandler(c *gin.Context) {
+// Register 注册新用户
+func (h *UserController) Register(c *gin.Context) {
 	// 1. 获取参数和参数校验
 	var params RegisterParams
 	if err := c.ShouldBindJSON(&params); err != nil {
@@ -58,39 +72,29 @@ func RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	// 2. 检查用户名是否已存在
-	var user models.User
-	if result := mysql.DB.Where("username = ?", params.Username).First(&user); result.RowsAffected > 0 {
-		ResponseError(c, CodeUserExist)
-		return
-	}
-
-	// 3. 检查邮箱是否已存在
-	if result := mysql.DB.Where("email = ?", params.Email).First(&user); result.RowsAffected > 0 {
-		ResponseError(c, CodeEmailExist)
-		return
-	}
-
-	// 4. 创建用户
-	userID := snowflake.GenID()
+	// 2. 创建用户，用户名和邮箱不能重复
 	if params.Bio == "" {
 		params.Bio = "no bio"
 	}
 	if params.AvatarUrl == "" {
 		params.AvatarUrl = setting.Conf.DefaultAvatar.AvatarURL
 	}
-	user = models.User{
-		ID:           userID,
-		Username:     params.Username,
-		PasswordHash: encrypt.EncryptPassword(params.Password),
-		Email:        params.Email,
-		AvatarURL:    params.AvatarUrl,
-		Bio:          params.Bio,
-		Status:       1,
-		LastLoginAt:  nil,
-	}
-
-	if err := mysql.DB.Create(&user).Error; err != nil {
+	user, err := h.users.Register(c.Request.Context(), service.RegisterInput{
+		Username:  params.Username,
+		Password:  params.Password,
+		Email:     params.Email,
+		Bio:       params.Bio,
+		AvatarURL: params.AvatarUrl,
+	})
+	switch {
+	case errors.Is(err, service.ErrUsernameExists):
+		ResponseError(c, CodeUserExist)
+		return
+	case errors.Is(err, service.ErrEmailExists):
+		ResponseError(c, CodeEmailExist)
+		return
+	case err != nil:
+		zap.L().Error("创建用户失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -100,38 +104,42 @@ func RegisterHandler(c *gin.Context) {
 	if !exists {
 		enforcer = rbac.Enforcer
 	}
-	e := enforcer.(*casbin.Enforcer)
+	assignDefaultRole(enforcer.(*casbin.SyncedEnforcer), user.ID)
+
+	// 发送邮箱验证邮件，失败时用户可以稍后重新发送
+	go func(u models.User) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := sendVerificationEmail(ctx, &u); err != nil {
+			zap.L().Error("发送验证邮件失败", zap.Int64("user_id", u.ID), zap.Error(err))
+		}
+	}(*user)
+
+	ResponseSuccess(c, user)
+}
 
-	// 设置用户角色
-	var role string
-	role = "user" // 默认角色为普通用户
+// assignDefaultRole 为新注册的用户设置默认角色
+// 设置失败不会中断注册流程，登录时会再次尝试补上默认角色
+func assignDefaultRole(e *casbin.SyncedEnforcer, userID int64) {
+	role := "user" // 默认角色为普通用户
 
 	// 为用户添加角色
-	userIDStr := strconv.FormatInt(user.ID, 10)
+	userIDStr := strconv.FormatInt(userID, 10)
 	_, err := e.AddRoleForUser(userIDStr, role)
 	if err != nil {
 		zap.L().Error("设置用户角色失败",
 			zap.String("user_id", userIDStr),
 			zap.String("role", role),
 			zap.Error(err))
-		// 不要因为设置角色失败就中断注册流程
-	} else {
-		// 保存策略到数据库
-		if err := e.SavePolicy(); err != nil {
-			zap.L().Error("保存策略失败",
-				zap.String("user_id", userIDStr),
-				zap.Error(err))
-		} else {
-			zap.L().Info("用户角色设置成功",
-				zap.String("user_id", userIDStr),
-				zap.String("role", role))
-		}
+		return
 	}
-
-	ResponseSuccess(c, user)
+	zap.L().Info("用户角色设置成功",
+		zap.String("user_id", userIDStr),
+		zap.String("role", role))
 }
 
-func LoginHandler(c *gin.Context) {
+// Login 用户名密码登录
+func (h *UserController) Login(c *gin.Context) {
 	// 1. 获取参数和参数校验
 	var params LoginParams
 	if err := c.ShouldBindJSON(&params); err != nil {
@@ -139,91 +147,259 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	// 2. 查询用户是否存在
-	var user models.User
-	result := mysql.DB.Where("username = ?", params.Username).First(&user)
-	if result.RowsAffected == 0 {
-		ResponseError(c, CodeUserNotExist)
+	// 2. 用户名或 IP 失败次数过多时暂时锁定
+	if !checkLoginGuard(c, params.Username) {
 		return
 	}
 
-	// 3. 验证密码
-	if encrypt.EncryptPassword(params.Password) != user.PasswordHash {
+	// 3. 查询用户并验证密码
+	user, err := h.users.Authenticate(c.Request.Context(), params.Username, params.Password)
+	if errors.Is(err, service.ErrInvalidPassword) {
+		recordLoginFailure(c, params.Username)
 		ResponseError(c, CodeInvalidPassword)
 		return
 	}
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	// 4. 被封禁的账号不允许登录
+	if user.Status != 1 {
+		ResponseError(c, CodeUserDisabled)
+		return
+	}
+	if setting.Conf.MailConfig.RequireVerifiedEmail && !user.EmailVerified() {
+		ResponseError(c, CodeEmailNotVerified)
+		return
+	}
 
-	// 4. 生成Token
-	token, err := jwt.GenToken(user.ID, user.Username)
+	// 5. 签发令牌，需要两步验证时先返回票据
+	h.respondLogin(c, user)
+}
+
+// respondLogin 身份校验通过后的统一出口
+// 已启用两步验证或角色要求两步验证时，先返回票据，校验通过后再签发令牌
+func (h *UserController) respondLogin(c *gin.Context, user *models.User) {
+	enabled, err := mfa.Enabled(user.ID)
 	if err != nil {
+		zap.L().Error("查询两步验证状态失败", zap.Int64("user_id", user.ID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
+	if enabled {
+		responseMFATicket(c, user.ID, jwt.TicketPurposeVerify, "mfa_required")
+		return
+	}
+	required, err := mfa.Required(user.ID)
+	if err != nil {
+		zap.L().Error("查询两步验证策略失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	if required {
+		responseMFATicket(c, user.ID, jwt.TicketPurposeEnroll, "mfa_enrollment_required")
+		return
+	}
+
+	data, err := h.completeLogin(c, user)
+	if err != nil {
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	ResponseSuccess(c, data)
+}
+
+// checkLoginGuard 检查用户名和客户端 IP 是否处于锁定中，锁定时直接返回错误响应
+func checkLoginGuard(c *gin.Context, username string) bool {
+	wait, err := loginguard.Check(username, c.ClientIP())
+	if err != nil {
+		// 计数存储不可用时不阻止登录
+		zap.L().Error("检查登录失败次数失败", zap.Error(err))
+		return true
+	}
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ResponseError(c, CodeTooManyAttempts)
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure 记录一次登录失败
+func recordLoginFailure(c *gin.Context, username string) {
+	if err := loginguard.Fail(username, c.ClientIP()); err != nil {
+		zap.L().Error("记录登录失败次数失败", zap.Error(err))
+	}
+	zap.L().Info("登录失败", zap.String("username", username), zap.String("ip", c.ClientIP()))
+}
 
-	// 5. 更新最后登录时间
-	now := time.Now()
-	if err := mysql.DB.Model(&user).Update("last_login_at", &now).Error; err != nil {
+// completeLogin 创建登录会话并签发 access token 和 refresh token，返回登录响应
+func (h *UserController) completeLogin(c *gin.Context, user *models.User) (gin.H, error) {
+	// 登录流程全部完成（包括两步验证）才清除失败计数
+	if err := loginguard.Succeed(user.Username); err != nil {
+		zap.L().Error("清除登录失败次数失败", zap.Error(err))
+	}
+
+	tokens, err := session.Issue(user, clientInfo(c))
+	if err != nil {
+		zap.L().Error("创建登录会话失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		return nil, err
+	}
+
+	// 更新最后登录时间
+	if err := h.users.RecordLogin(c.Request.Context(), user.ID); err != nil {
 		zap.L().Error("更新最后登录时间失败", zap.Error(err))
 	}
 
-	// 6. 获取用户角色
+	// 获取用户角色
 	userIDStr := strconv.FormatInt(user.ID, 10)
-	role, err := rbac.GetUserRole(userIDStr)
+	roles, err := rbac.GetUserRoles(userIDStr)
 	if err != nil {
 		zap.L().Error("获取用户角色失败", zap.Error(err))
-		role = "user" // 默认角色
-
-		// 如果获取角色失败，尝试添加默认角色
-		if ok := rbac.AddRole(userIDStr, role); !ok {
-			zap.L().Error("设置默认用户角色失败",
-				zap.String("user_id", userIDStr),
-				zap.String("role", role))
-		} else {
-			zap.L().Info("成功设置默认用户角色",
-				zap.String("user_id", userIDStr),
-				zap.String("role", role))
-		}
+		return nil, err
 	}
 
-	ResponseSuccess(c, gin.H{
-		"token":    "Bearer " + token,
-		"userID":   userIDStr,
-		"username": user.Username,
-		"role":     role,
-	})
+	return gin.H{
+		"token":         "Bearer " + tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"userID":        userIDStr,
+		"username":      user.Username,
+		"roles":         roles,
+	}, nil
 }
 
-// UpdateUserBio 修改用户bio
-func UpdateUserBio(c *gin.Context) {
-	// 获取参数
-	var params UpdateProfileParams
+// ChangePasswordParams 修改密码请求参数
+type ChangePasswordParams struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ChangePassword 修改密码，成功后该用户所有已登录的会话都会失效
+func (h *UserController) ChangePassword(c *gin.Context) {
+	var params ChangePasswordParams
 	if err := c.ShouldBindJSON(&params); err != nil {
 		ResponseError(c, CodeInvalidParam)
 		return
 	}
 
-	// 获取当前用户ID
 	userID, err := getCurrentUserIDInt64(c)
 	if err != nil {
 		ResponseError(c, CodeNeedLogin)
 		return
 	}
 
-	// 检查用户是否存在
-	var user models.User
-	result := mysql.DB.Where("id = ?", userID).First(&user)
-	if result.Error != nil {
+	err = h.users.ChangePassword(c.Request.Context(), userID, params.OldPassword, params.NewPassword)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		ResponseError(c, CodeUserNotExist)
+		return
+	case errors.Is(err, service.ErrInvalidPassword):
+		ResponseError(c, CodeInvalidPassword)
+		return
+	case err != nil:
+		zap.L().Error("修改密码失败", zap.Int64("user_id", userID), zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
 	}
-	if result.RowsAffected == 0 {
-		ResponseError(c, CodeUserNotExist)
+
+	if err := session.RevokeUser(userID); err != nil {
+		zap.L().Error("吊销用户会话失败", zap.Int64("user_id", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+
+	ResponseSuccess(c, nil)
+}
+
+// UpdateUserStatusParams 修改用户状态请求参数
+type UpdateUserStatusParams struct {
+	Status *int8 `json:"status" binding:"required,oneof=0 1"`
+}
+
+// UpdateUserStatus 封禁或解封用户，封禁时立即吊销其所有会话
+func (h *UserController) UpdateUserStatus(c *gin.Context) {
+	targetUserID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	var params UpdateUserStatusParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	// 只有超级管理员可以修改超级管理员的状态，避免管理员封禁超级管理员
+	targetRoles, err := rbac.GetUserRoles(strconv.FormatInt(targetUserID, 10))
+	if err != nil {
+		zap.L().Error("获取用户角色失败", zap.Int64("user_id", targetUserID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	if rbac.HasRole(targetRoles, rbac.SuperAdminRole) {
+		currentUserID, err := getCurrentUserID(c)
+		if err != nil {
+			ResponseError(c, CodeNeedLogin)
+			return
+		}
+		currentRoles, err := rbac.GetUserRoles(currentUserID)
+		if err != nil {
+			zap.L().Error("获取当前用户角色失败", zap.String("current_user_id", currentUserID), zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+			return
+		}
+		if !rbac.HasRole(currentRoles, rbac.SuperAdminRole) {
+			zap.L().Warn("非超级管理员尝试修改超级管理员状态",
+				zap.String("current_user_id", currentUserID),
+				zap.Int64("target_user_id", targetUserID))
+			ResponseError(c, CodeNoPermision)
+			return
+		}
+	}
+
+	// 封禁时会立即吊销其所有会话
+	before, err := h.users.SetStatus(c.Request.Context(), targetUserID, *params.Status)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
+		zap.L().Error("更新用户状态失败", zap.Int64("user_id", targetUserID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	recordAudit(c, audit.ActionUserStatusUpdate, audit.TargetUser, strconv.FormatInt(targetUserID, 10),
+		gin.H{"status": before}, gin.H{"status": *params.Status})
+
+	ResponseSuccess(c, nil)
+}
+
+// UpdateUserBio 修改用户bio
+func (h *UserController) UpdateUserBio(c *gin.Context) {
+	// 获取参数
+	var params UpdateProfileParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		ResponseError(c, CodeInvalidParam)
+		return
+	}
+
+	// 获取当前用户ID
+	userID, err := getCurrentUserIDInt64(c)
+	if err != nil {
+		ResponseError(c, CodeNeedLogin)
 		return
 	}
 
 	// 更新bio
-	result = mysql.DB.Model(&user).Where("id = ?", userID).Update("bio", params.Bio)
-	if result.Error != nil {
+	if err := h.users.UpdateBio(c.Request.Context(), userID, params.Bio); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -233,7 +409,7 @@ func UpdateUserBio(c *gin.Context) {
 }
 
 // UpdateUserAvatar 修改用户头像
-func UpdateUserAvatar(c *gin.Context) {
+func (h *UserController) UpdateUserAvatar(c *gin.Context) {
 	// 获取上传的文件
 	file, err := c.FormFile("avatar")
 	if err != nil {
@@ -254,9 +430,7 @@ func UpdateUserAvatar(c *gin.Context) {
 	}
 
 	// 更新用户头像URL
-	var user models.User
-	result := mysql.DB.Model(&user).Where("id = ?", userID).Update("avatar_url", avatarURL)
-	if result.Error != nil {
+	if err := h.users.UpdateAvatar(c.Request.Context(), userID, avatarURL); err != nil {
 		ResponseError(c, CodeServerBusy)
 		return
 	}
@@ -267,92 +441,72 @@ func UpdateUserAvatar(c *gin.Context) {
 }
 
 // GetUserProfile 获取用户信息
-func GetUserProfile(c *gin.Context) {
-	// 尝试获取用户ID，但不处理错误
-	userID, _ := getCurrentUserIDInt64(c)
-	if userID == 0 {
-		// 如果是未登录状态，返回默认的 guest 用户信息
+// 未登录访客没有资料，只返回访客身份和角色
+func (h *UserController) GetUserProfile(c *gin.Context) {
+	userIDStr, err := getCurrentUserID(c)
+	if err != nil {
 		ResponseSuccess(c, gin.H{
-			"id":         0,
-			"username":   "momo",
-			"email":      "",
-			"avatar_url": "https://cube.elemecdn.com/3/7c/3ea6beec64369c2642b92c6726f1epng.png",
-			"bio":        "我是大帅哥",
-			"role":       "guest",
-			"status":     1,
+			"id":        0,
+			"anonymous": true,
+			"roles":     c.GetStringSlice(CtxRoles),
 		})
 		return
 	}
+	userID, err := convertUserIDToInt64(userIDStr)
+	if err != nil {
+		ResponseError(c, CodeInvalidToken)
+		return
+	}
 
-	var user models.User
-	if err := mysql.DB.First(&user, userID).Error; err != nil {
-		// 如果用户不存在，也返回默认的 guest 用户信息
-		ResponseSuccess(c, gin.H{
-			"id":         0,
-			"username":   "momo",
-			"email":      "",
-			"avatar_url": "https://cube.elemecdn.com/3/7c/3ea6beec64369c2642b92c6726f1epng.png",
-			"bio":        "我是大帅哥",
-			"role":       "guest",
-			"status":     1,
-		})
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			ResponseError(c, CodeUserNotExist)
+			return
+		}
+		zap.L().Error("获取用户信息失败", zap.Int64("userID", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
 		return
 	}
 
 	// 获取用户角色
-	userIDStr := strconv.FormatInt(userID, 10)
-	role, err := rbac.GetUserRole(userIDStr)
+	roles, err := rbac.GetUserRoles(userIDStr)
 	if err != nil {
-		role = "guest"
+		zap.L().Error("获取用户角色失败", zap.Int64("userID", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
+	}
+	moderatedBoards, err := rbac.GetModeratedBoards(userIDStr)
+	if err != nil {
+		zap.L().Error("获取用户担任版主的板块失败", zap.Int64("userID", userID), zap.Error(err))
+		ResponseError(c, CodeServerBusy)
+		return
 	}
 
 	ResponseSuccess(c, gin.H{
-		"id":         user.ID,
-		"username":   user.Username,
-		"email":      user.Email,
-		"avatar_url": user.AvatarURL,
-		"bio":        user.Bio,
-		"role":       role,
-		"status":     user.Status,
+		"id":               user.ID,
+		"username":         user.Username,
+		"email":            user.Email,
+		"avatar_url":       user.AvatarURL,
+		"bio":              user.Bio,
+		"roles":            roles,
+		"moderated_boards": moderatedBoards,
+		"status":           user.Status,
+		"email_verified":   user.EmailVerified(),
+		"anonymous":        false,
 	})
 }
 
-func GetUserLists(c *gin.Context) {
+func (h *UserController) GetUserLists(c *gin.Context) {
 	// 获取分页参数
 	page, size := getPageInfo(c)
 
 	// 获取搜索关键词
 	keyword := c.Query("keyword")
 
-	// 查询用户列表
-	var users []models.User
-	var total int64
-
-	// 构建查询条件
-	query := mysql.DB.Model(&models.User{}).Where("status = ?", 1)
-
-	// 如果有搜索关键词,添加模糊搜索条件
-	if keyword != "" {
-		query = query.Where(
-			"username LIKE ? OR id LIKE ? OR email LIKE ? OR bio LIKE ?",
-			"%"+keyword+"%",
-			"%"+keyword+"%",
-			"%"+keyword+"%",
-			"%"+keyword+"%",
-		)
-	}
-
-	// 计算总数
-	if err := query.Count(&total).Error; err != nil {
-		zap.L().Error("获取用户总数失败", zap.Error(err))
-		ResponseError(c, CodeServerBusy)
-		return
-	}
-
-	// 分页查询用户列表
-	if err := query.Offset(int((page - 1) * size)).
-		Limit(int(size)).
-		Find(&users).Error; err != nil {
+	// 查询用户列表，有搜索关键词时按用户名、ID、邮箱和简介模糊搜索
+	users, total, err := h.users.ListActive(c.Request.Context(), keyword, int(page), int(size))
+	if err != nil {
 		zap.L().Error("获取用户列表失败", zap.Error(err))
 		ResponseError(c, CodeServerBusy)
 		return
@@ -362,22 +516,33 @@ func GetUserLists(c *gin.Context) {
 	userList := make([]gin.H, 0, len(users))
 	for _, user := range users {
 		// 获取用户角色
-		role, err := rbac.GetUserRole(strconv.FormatInt(user.ID, 10))
+		userIDStr := strconv.FormatInt(user.ID, 10)
+		roles, err := rbac.GetUserRoles(userIDStr)
+		if err != nil {
+			zap.L().Error("get user role failed",
+				zap.Int64("user_id", user.ID),
+				zap.Error(err))
+			ResponseError(c, CodeServerBusy)
+			return
+		}
+		directRoles, err := rbac.GetDirectRoles(userIDStr)
 		if err != nil {
 			zap.L().Error("get user role failed",
 				zap.Int64("user_id", user.ID),
 				zap.Error(err))
-			role = "user" // 默认角色
+			ResponseError(c, CodeServerBusy)
+			return
 		}
 
 		userList = append(userList, gin.H{
-			"id":         strconv.FormatInt(user.ID, 10),
-			"username":   user.Username,
-			"email":      user.Email,
-			"avatar":     user.AvatarURL,
-			"bio":        user.Bio,
-			"role":       role,
-			"created_at": user.CreatedAt,
+			"id":           userIDStr,
+			"username":     user.Username,
+			"email":        user.Email,
+			"avatar":       user.AvatarURL,
+			"bio":          user.Bio,
+			"roles":        roles,
+			"direct_roles": directRoles,
+			"created_at":   user.CreatedAt,
 		})
 	}
 
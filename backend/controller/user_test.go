@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/TalkSphere/backend/service"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerTestUser(t *testing.T, services *service.Services, username string) int64 {
+	t.Helper()
+	user, err := services.Users.Register(context.Background(), service.RegisterInput{
+		Username: username,
+		Password: "secret123",
+		Email:    username + "@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	ctrl, _, services := newTestControllers()
+	registerTestUser(t, services, "alice")
+
+	tests := []struct {
+		name string
+		body gin.H
+		want ResCode
+	}{
+		{"missing password", gin.H{"username": "bob", "email": "bob@example.com"}, CodeInvalidParam},
+		{"same username", gin.H{"username": "alice", "password": "x", "email": "bob@example.com"}, CodeUserExist},
+		{"same email", gin.H{"username": "bob", "password": "x", "email": "alice@example.com"}, CodeEmailExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := serve(t, ctrl.User.Register, http.MethodPost, "", tt.body); resp.Code != tt.want {
+				t.Errorf("code = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginRejected(t *testing.T) {
+	ctrl, repos, services := newTestControllers()
+	registerTestUser(t, services, "alice")
+	disabled := registerTestUser(t, services, "bob")
+	if err := repos.Users.Update(context.Background(), disabled, map[string]interface{}{"status": service.UserStatusDisabled}); err != nil {
+		t.Fatal(err)
+	}
+	registerTestUser(t, services, "carol")
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		verify   bool
+		want     ResCode
+	}{
+		{"wrong password", "alice", "wrong", false, CodeInvalidPassword},
+		{"unknown user", "nobody", "secret123", false, CodeInvalidPassword},
+		{"disabled", "bob", "secret123", false, CodeUserDisabled},
+		{"email not verified", "carol", "secret123", true, CodeEmailNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting.Conf.MailConfig.RequireVerifiedEmail = tt.verify
+			defer func() { setting.Conf.MailConfig.RequireVerifiedEmail = false }()
+
+			body := gin.H{"username": tt.username, "password": tt.password}
+			if resp := serve(t, ctrl.User.Login, http.MethodPost, "", body); resp.Code != tt.want {
+				t.Errorf("code = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangePasswordRejected(t *testing.T) {
+	ctrl, _, services := newTestControllers()
+	id := strconv.FormatInt(registerTestUser(t, services, "alice"), 10)
+	body := gin.H{"old_password": "wrong", "new_password": "newsecret"}
+
+	if resp := serve(t, ctrl.User.ChangePassword, http.MethodPost, "", body); resp.Code != CodeNeedLogin {
+		t.Errorf("not logged in: code = %d, want %d", resp.Code, CodeNeedLogin)
+	}
+	if resp := serve(t, ctrl.User.ChangePassword, http.MethodPost, id, body); resp.Code != CodeInvalidPassword {
+		t.Errorf("wrong old password: code = %d, want %d", resp.Code, CodeInvalidPassword)
+	}
+	if resp := serve(t, ctrl.User.ChangePassword, http.MethodPost, "404", gin.H{"old_password": "secret123", "new_password": "newsecret"}); resp.Code != CodeUserNotExist {
+		t.Errorf("unknown user: code = %d, want %d", resp.Code, CodeUserNotExist)
+	}
+}
@@ -0,0 +1,28 @@
+// Package migrations 内嵌数据库迁移脚本，由 pkg/migrate 按版本号顺序执行
+//
+// 每种数据库一个目录，目录名与配置中的 mysql.driver 相同。各目录的版本号一一对应，
+// 新增迁移时需要在每个目录下各写一份
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// files 所有迁移脚本，文件名格式为 <版本号>_<说明>.up.sql 和 <版本号>_<说明>.down.sql
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// For 返回指定数据库的迁移脚本
+func For(driver string) (fs.FS, error) {
+	sub, err := fs.Sub(files, driver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.ReadDir(sub, "."); err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+	return sub, nil
+}
@@ -0,0 +1,12 @@
+-- 与 gorm-adapter 的 CasbinRule 结构一致，适配器启动时不会再修改表结构
+CREATE TABLE casbin_rule (
+    id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+    ptype VARCHAR(100),
+    v0 VARCHAR(100),
+    v1 VARCHAR(100),
+    v2 VARCHAR(100),
+    v3 VARCHAR(100),
+    v4 VARCHAR(100),
+    v5 VARCHAR(100),
+    UNIQUE KEY idx_casbin_rule (ptype, v0, v1, v2, v3, v4, v5)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_activities;
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS comments;
@@ -0,0 +1,55 @@
+CREATE TABLE comments (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    post_id BIGINT NOT NULL COMMENT '帖子ID',
+    user_id BIGINT NOT NULL COMMENT '评论作者ID',
+    content TEXT NOT NULL COMMENT '评论内容',
+    parent_id BIGINT DEFAULT NULL COMMENT '父评论ID，顶级评论为NULL',
+    root_id BIGINT DEFAULT NULL COMMENT '根评论ID，顶级评论为NULL',
+    like_count INT DEFAULT 0 COMMENT '点赞数',
+    reply_count INT DEFAULT 0 COMMENT '回复数',
+    score INT DEFAULT 0 COMMENT '评论得分(用于排序)',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    status TINYINT DEFAULT 1 COMMENT '状态：1正常 0隐藏 -1删除',
+    INDEX idx_comments_post_id (post_id),
+    INDEX idx_comments_user_id (user_id),
+    INDEX idx_comments_parent_id (parent_id),
+    INDEX idx_comments_root_id (root_id),
+    INDEX idx_comments_created_at (created_at),
+    INDEX idx_comments_score (score),
+    CONSTRAINT fk_comments_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_parent FOREIGN KEY (parent_id) REFERENCES comments (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_root FOREIGN KEY (root_id) REFERENCES comments (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 同一用户对同一目标只能点赞一次，并发的重复点赞由唯一索引拦截
+CREATE TABLE likes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    target_id BIGINT NOT NULL COMMENT '点赞目标ID',
+    target_type TINYINT NOT NULL COMMENT '1: post, 2: comment',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_like (user_id, target_id, target_type),
+    INDEX idx_likes_target (target_id, target_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE favorites (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    post_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_favorite (user_id, post_id),
+    INDEX idx_favorites_post (post_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE user_activities (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT,
+    date DATE,
+    post_count INT DEFAULT 0,
+    comment_count INT DEFAULT 0,
+    like_count INT DEFAULT 0,
+    view_count INT DEFAULT 0,
+    UNIQUE KEY unique_user_daily (user_id, date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
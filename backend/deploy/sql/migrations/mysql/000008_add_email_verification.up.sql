@@ -0,0 +1,13 @@
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMP NULL DEFAULT NULL COMMENT '邮箱验证时间' AFTER last_login_at;
+
+CREATE TABLE user_tokens (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    purpose VARCHAR(32) NOT NULL COMMENT 'verify_email / reset_password',
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
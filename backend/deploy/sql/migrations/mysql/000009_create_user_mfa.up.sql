@@ -0,0 +1,18 @@
+CREATE TABLE user_mfa (
+    user_id BIGINT PRIMARY KEY,
+    secret VARCHAR(255) NOT NULL COMMENT 'AES-GCM 加密后的 TOTP 密钥',
+    enabled TINYINT(1) DEFAULT 0,
+    enabled_at TIMESTAMP NULL,
+    last_used_step BIGINT DEFAULT 0 COMMENT '最近一次通过校验的时间步，防止验证码重放',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE user_recovery_codes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_recovery_codes_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,12 @@
+CREATE TABLE user_identities (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    subject VARCHAR(255) NOT NULL COMMENT '提供方的用户唯一标识（OIDC sub）',
+    email VARCHAR(100),
+    username VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_identities_provider_subject (provider, subject),
+    UNIQUE KEY uk_user_identities_user_provider (user_id, provider)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+ALTER TABLE user_sessions
+DROP COLUMN last_seen_at,
+DROP COLUMN ip,
+DROP COLUMN user_agent;
@@ -0,0 +1,4 @@
+ALTER TABLE user_sessions
+ADD COLUMN user_agent VARCHAR(255) NULL COMMENT '登录或最近一次刷新时的 User-Agent' AFTER revoked_at,
+ADD COLUMN ip VARCHAR(64) NULL COMMENT '最近一次使用的 IP' AFTER user_agent,
+ADD COLUMN last_seen_at TIMESTAMP NULL COMMENT '最近一次使用时间' AFTER ip;
@@ -0,0 +1,7 @@
+CREATE TABLE roles (
+    name VARCHAR(64) PRIMARY KEY,
+    description VARCHAR(255),
+    builtin TINYINT(1) NOT NULL DEFAULT 0 COMMENT '内置角色不能删除',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,5 @@
+DROP INDEX idx_posts_board_pinned ON posts;
+
+ALTER TABLE posts
+    DROP COLUMN is_pinned,
+    DROP COLUMN is_locked;
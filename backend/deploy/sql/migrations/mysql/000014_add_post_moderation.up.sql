@@ -0,0 +1,5 @@
+ALTER TABLE posts
+    ADD COLUMN is_locked TINYINT(1) NOT NULL DEFAULT 0 COMMENT '锁定后不能再评论' AFTER status,
+    ADD COLUMN is_pinned TINYINT(1) NOT NULL DEFAULT 0 COMMENT '置顶' AFTER is_locked;
+
+CREATE INDEX idx_posts_board_pinned ON posts (board_id, is_pinned, created_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS casbin_policy_version;
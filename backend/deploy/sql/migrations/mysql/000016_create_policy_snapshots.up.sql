@@ -0,0 +1,11 @@
+CREATE TABLE policy_snapshots (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    action VARCHAR(20) NOT NULL COMMENT 'baseline、manual、import 或 rollback',
+    author_id BIGINT DEFAULT NULL COMMENT '操作的用户，命令行操作时为空',
+    author VARCHAR(64) NOT NULL,
+    comment VARCHAR(255),
+    rules MEDIUMTEXT NOT NULL COMMENT 'CSV 格式的策略定义，不含用户的角色分配',
+    rule_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_policy_snapshots_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,18 @@
+CREATE TABLE audit_events (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    actor_id BIGINT DEFAULT NULL COMMENT '操作的用户，命令行操作时为空',
+    actor VARCHAR(64) NOT NULL,
+    action VARCHAR(64) NOT NULL COMMENT '例如 user.role.update、policy.import',
+    target_type VARCHAR(32) NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
+    `before` JSON DEFAULT NULL COMMENT '操作前的状态',
+    `after` JSON DEFAULT NULL COMMENT '操作后的状态',
+    ip VARCHAR(64),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_events_actor (actor_id),
+    INDEX idx_audit_events_action (action),
+    INDEX idx_audit_events_target (target_type, target_id),
+    INDEX idx_audit_events_request (request_id),
+    INDEX idx_audit_events_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
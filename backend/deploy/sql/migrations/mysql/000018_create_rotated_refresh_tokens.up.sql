@@ -0,0 +1,8 @@
+CREATE TABLE rotated_refresh_tokens (
+    token_hash CHAR(64) PRIMARY KEY COMMENT '已轮换掉的 refresh token 摘要',
+    session_id BIGINT NOT NULL,
+    expires_at TIMESTAMP NOT NULL COMMENT '轮换前会话的过期时间，之后即可清理',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_rotated_refresh_tokens_session_id (session_id),
+    INDEX idx_rotated_refresh_tokens_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+CREATE TABLE boards (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    status SMALLINT DEFAULT 1, -- 1: active, 0: inactive
+    sort_order INT DEFAULT 0,
+    creator_id BIGINT
+);
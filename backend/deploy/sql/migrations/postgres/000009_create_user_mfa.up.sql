@@ -0,0 +1,19 @@
+CREATE TABLE user_mfa (
+    user_id BIGINT PRIMARY KEY,
+    secret VARCHAR(255) NOT NULL, -- AES-GCM 加密后的 TOTP 密钥
+    enabled BOOLEAN DEFAULT FALSE,
+    enabled_at TIMESTAMPTZ NULL,
+    last_used_step BIGINT DEFAULT 0, -- 最近一次通过校验的时间步，防止验证码重放
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE user_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
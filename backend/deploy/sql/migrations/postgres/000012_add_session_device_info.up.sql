@@ -0,0 +1,3 @@
+ALTER TABLE user_sessions ADD COLUMN user_agent VARCHAR(255) NULL;
+ALTER TABLE user_sessions ADD COLUMN ip VARCHAR(64) NULL;
+ALTER TABLE user_sessions ADD COLUMN last_seen_at TIMESTAMPTZ NULL;
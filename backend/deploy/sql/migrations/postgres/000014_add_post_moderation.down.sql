@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_posts_board_pinned;
+
+ALTER TABLE posts DROP COLUMN is_pinned;
+ALTER TABLE posts DROP COLUMN is_locked;
@@ -0,0 +1,4 @@
+ALTER TABLE posts ADD COLUMN is_locked BOOLEAN NOT NULL DEFAULT FALSE; -- 锁定后不能再评论
+ALTER TABLE posts ADD COLUMN is_pinned BOOLEAN NOT NULL DEFAULT FALSE; -- 置顶
+
+CREATE INDEX idx_posts_board_pinned ON posts (board_id, is_pinned, created_at);
@@ -0,0 +1,7 @@
+CREATE TABLE casbin_policy_version (
+    id SMALLINT PRIMARY KEY,
+    version BIGINT NOT NULL DEFAULT 0, -- 每次修改策略加一
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO casbin_policy_version (id, version) VALUES (1, 0);
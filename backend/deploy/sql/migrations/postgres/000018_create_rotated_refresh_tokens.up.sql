@@ -0,0 +1,9 @@
+CREATE TABLE rotated_refresh_tokens (
+    token_hash CHAR(64) PRIMARY KEY, -- 已轮换掉的 refresh token 摘要
+    session_id BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL, -- 轮换前会话的过期时间，之后即可清理
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rotated_refresh_tokens_session_id ON rotated_refresh_tokens (session_id);
+CREATE INDEX idx_rotated_refresh_tokens_expires_at ON rotated_refresh_tokens (expires_at);
@@ -0,0 +1,44 @@
+CREATE TABLE posts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    board_id BIGINT,
+    author_id BIGINT,
+    view_count INT DEFAULT 0,
+    like_count INT DEFAULT 0,
+    favorite_count INT DEFAULT 0,
+    comment_count INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status TINYINT DEFAULT 1 -- 1: published, 0: draft, -1: deleted
+);
+
+CREATE INDEX idx_posts_board ON posts (board_id);
+CREATE INDEX idx_posts_author ON posts (author_id);
+
+CREATE TABLE post_images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id BIGINT,
+    user_id BIGINT NOT NULL,
+    image_url VARCHAR(255) NOT NULL,
+    status TINYINT DEFAULT 1,
+    sort_order INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_post_images_post_id ON post_images (post_id);
+CREATE INDEX idx_post_images_user_id ON post_images (user_id);
+
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE post_tags (
+    post_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE INDEX idx_post_tags_tag_id ON post_tags (tag_id);
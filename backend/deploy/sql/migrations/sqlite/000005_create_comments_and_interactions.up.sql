@@ -0,0 +1,54 @@
+CREATE TABLE comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id BIGINT NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    parent_id BIGINT DEFAULT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    root_id BIGINT DEFAULT NULL REFERENCES comments (id) ON DELETE CASCADE,
+    like_count INT DEFAULT 0,
+    reply_count INT DEFAULT 0,
+    score INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status TINYINT DEFAULT 1 -- 1正常 0隐藏 -1删除
+);
+
+CREATE INDEX idx_comments_post_id ON comments (post_id);
+CREATE INDEX idx_comments_user_id ON comments (user_id);
+CREATE INDEX idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX idx_comments_root_id ON comments (root_id);
+CREATE INDEX idx_comments_created_at ON comments (created_at);
+CREATE INDEX idx_comments_score ON comments (score);
+
+CREATE TABLE likes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL,
+    target_id BIGINT NOT NULL,
+    target_type TINYINT NOT NULL, -- 1: post, 2: comment
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX unique_like ON likes (user_id, target_id, target_type);
+CREATE INDEX idx_likes_target ON likes (target_id, target_type);
+
+CREATE TABLE favorites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL,
+    post_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX unique_favorite ON favorites (user_id, post_id);
+CREATE INDEX idx_favorites_post ON favorites (post_id);
+
+CREATE TABLE user_activities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT,
+    date DATE,
+    post_count INT DEFAULT 0,
+    comment_count INT DEFAULT 0,
+    like_count INT DEFAULT 0,
+    view_count INT DEFAULT 0
+);
+
+CREATE UNIQUE INDEX unique_user_daily ON user_activities (user_id, date);
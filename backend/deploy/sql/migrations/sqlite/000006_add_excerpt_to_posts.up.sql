@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN excerpt VARCHAR(255) DEFAULT NULL;
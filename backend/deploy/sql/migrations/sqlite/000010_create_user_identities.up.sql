@@ -0,0 +1,13 @@
+CREATE TABLE user_identities (
+    id INTEGER PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    subject VARCHAR(255) NOT NULL, -- 提供方的用户唯一标识（OIDC sub）
+    email VARCHAR(100),
+    username VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_user_identities_provider_subject ON user_identities (provider, subject);
+CREATE UNIQUE INDEX uk_user_identities_user_provider ON user_identities (user_id, provider);
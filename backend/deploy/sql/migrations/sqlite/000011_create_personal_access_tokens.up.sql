@@ -0,0 +1,15 @@
+CREATE TABLE personal_access_tokens (
+    id INTEGER PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL, -- 令牌前几位，便于用户辨认
+    token_hash CHAR(64) NOT NULL,
+    scopes VARCHAR(500) NOT NULL, -- 空格分隔的 scope 列表
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    last_used_ip VARCHAR(64),
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
@@ -0,0 +1,7 @@
+CREATE TABLE roles (
+    name VARCHAR(64) PRIMARY KEY,
+    description VARCHAR(255),
+    builtin BOOLEAN NOT NULL DEFAULT 0, -- 内置角色不能删除
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+CREATE TABLE policy_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action VARCHAR(20) NOT NULL, -- baseline、manual、import 或 rollback
+    author_id BIGINT DEFAULT NULL, -- 操作的用户，命令行操作时为空
+    author VARCHAR(64) NOT NULL,
+    comment VARCHAR(255),
+    rules TEXT NOT NULL, -- CSV 格式的策略定义，不含用户的角色分配
+    rule_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_policy_snapshots_created_at ON policy_snapshots (created_at);
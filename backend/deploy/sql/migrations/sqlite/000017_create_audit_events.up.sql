@@ -0,0 +1,19 @@
+CREATE TABLE audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id BIGINT DEFAULT NULL, -- 操作的用户，命令行操作时为空
+    actor VARCHAR(64) NOT NULL,
+    action VARCHAR(64) NOT NULL, -- 例如 user.role.update、policy.import
+    target_type VARCHAR(32) NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
+    "before" JSON DEFAULT NULL, -- 操作前的状态
+    "after" JSON DEFAULT NULL, -- 操作后的状态
+    ip VARCHAR(64),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_events_actor ON audit_events (actor_id);
+CREATE INDEX idx_audit_events_action ON audit_events (action);
+CREATE INDEX idx_audit_events_target ON audit_events (target_type, target_id);
+CREATE INDEX idx_audit_events_request ON audit_events (request_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
@@ -3,12 +3,25 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/TalkSphere/backend/controller"
+	"github.com/TalkSphere/backend/deploy/sql/migrations"
+	"github.com/TalkSphere/backend/pkg/audit"
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/jwt"
 	"github.com/TalkSphere/backend/pkg/logger"
+	"github.com/TalkSphere/backend/pkg/loginguard"
+	"github.com/TalkSphere/backend/pkg/mail"
+	"github.com/TalkSphere/backend/pkg/migrate"
 	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/oauth"
 	"github.com/TalkSphere/backend/pkg/oss"
 	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/TalkSphere/backend/pkg/redis"
+	"github.com/TalkSphere/backend/pkg/session"
 	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/repository"
 	"github.com/TalkSphere/backend/router"
+	"github.com/TalkSphere/backend/service"
 	"github.com/TalkSphere/backend/setting"
 	"log"
 	"net/http"
@@ -50,6 +63,18 @@ func main() {
 		return
 	}
 	zap.L().Debug("logger init success\n")
+	// ./backend migrate up|down|status|force 只执行数据库迁移，不启动服务
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if err := encrypt.Init(setting.Conf.EncryptConfig); err != nil {
+		fmt.Printf("init encrypt failed, err:%v\n", err)
+		return
+	}
+	if err := jwt.Init(setting.Conf.AuthConfig); err != nil {
+		fmt.Printf("init jwt keys failed, err:%v\n", err)
+		return
+	}
 	defer func(l *zap.Logger) {
 		err := l.Sync()
 		if err != nil {
@@ -62,29 +87,70 @@ func main() {
 		return
 	}
 	defer mysql.Close()
+	// 修改配置文件后立即调整连接池和 SQL 日志，不需要重启
+	setting.OnChange(func(cfg *setting.Config) { mysql.Reload(cfg.MysqlConfig) })
+	warnPendingMigrations()
 	//4.redis
-	//if err := redis.Init(setting.Conf.RedisConfig); err != nil {
-	//	fmt.Printf("init redis failed, err:%v\n", err)
-	//	return
-	//}
-	//defer redis.Close()
+	// 登录失败计数和策略同步在多实例部署时需要 redis，策略同步在 redis 不可用时可以退回到轮询数据库
+	var guardCfg *setting.LoginGuardConfig
+	if setting.Conf.AuthConfig != nil {
+		guardCfg = setting.Conf.AuthConfig.LoginGuard
+	}
+	guardRedis := guardCfg != nil && guardCfg.Store == "redis"
+	watcherRedis := setting.Conf.RBACConfig != nil && setting.Conf.RBACConfig.Watcher == rbac.WatcherRedis
+	if guardRedis || watcherRedis {
+		if err := redis.Init(setting.Conf.RedisConfig); err != nil {
+			if guardRedis {
+				fmt.Printf("init redis failed, err:%v\n", err)
+				return
+			}
+			zap.L().Warn("初始化 redis 失败", zap.Error(err))
+		} else {
+			defer redis.Close()
+		}
+	}
+	if err := loginguard.Init(guardCfg); err != nil {
+		fmt.Printf("init login guard failed, err:%v\n", err)
+		return
+	}
 	//5.oss
 	if err := oss.Init(setting.Conf.OSSConfig); err != nil {
 		fmt.Printf("init oss failed, err:%v\n", err)
 		return
 	}
+	if err := mail.Init(setting.Conf.MailConfig); err != nil {
+		fmt.Printf("init mail failed, err:%v\n", err)
+		return
+	}
+	if err := oauth.Init(setting.Conf.OAuthConfig); err != nil {
+		fmt.Printf("init oauth failed, err:%v\n", err)
+		return
+	}
 	if err := snowflake.Init(setting.Conf.SnowFlakeConfig.StartTime, setting.Conf.SnowFlakeConfig.MachineID); err != nil {
 		zap.L().Fatal("snowflake.Init() failed ", zap.Error(err))
 		return
 	}
 
 	rbac.InitCasbin()
+	// 仓储的查询按请求走从库；权限检查加载资源时读主库，刚创建的帖子、评论马上就能操作
+	repos := repository.NewGorm(mysql.Routed)
+	controller.RegisterResourceLoaders(repository.NewGorm(mysql.DB))
+	if err := rbac.InitWatcher(setting.Conf.RBACConfig, redis.Client()); err != nil {
+		zap.L().Fatal("初始化策略同步失败", zap.Error(err))
+		return
+	}
+	defer rbac.CloseWatcher()
+
+	// 定期清理过期会话和 token 黑名单
+	session.Init()
+	// 定期清理过期的审计记录
+	audit.Init(setting.Conf.AuditConfig)
 
 	// 初始化超级管理员
 	rbac.InitSuperAdmin()
 
 	// 5. 注册路由
-	r := router.Setup()
+	r := router.Setup(controller.NewControllers(repos, service.New(repos)))
 	// 6. 启动服务（优雅关机）
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", setting.Conf.AppConfig.Port),
@@ -114,3 +180,50 @@ func main() {
 	}
 	zap.L().Info("Server exiting ...")
 }
+
+// runMigrate 执行 migrate 子命令，返回进程退出码
+func runMigrate(args []string) int {
+	defer zap.L().Sync()
+	if err := mysql.Init(setting.Conf.MysqlConfig); err != nil {
+		fmt.Printf("init mysql failed, err:%v\n", err)
+		return 1
+	}
+	defer mysql.Close()
+
+	m, err := newMigrator()
+	if err != nil {
+		fmt.Printf("load migrations failed, err:%v\n", err)
+		return 1
+	}
+	if err := migrate.Command(context.Background(), m, args, os.Stdout); err != nil {
+		fmt.Printf("migrate failed, err:%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newMigrator 使用当前数据库类型对应的迁移脚本
+func newMigrator() (*migrate.Migrator, error) {
+	fsys, err := migrations.For(mysql.Current().Name())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(mysql.DB, fsys)
+}
+
+// warnPendingMigrations 数据库还有未执行的迁移时提醒先执行 ./backend migrate up，不阻止启动
+func warnPendingMigrations() {
+	m, err := newMigrator()
+	if err != nil {
+		zap.L().Warn("加载迁移脚本失败", zap.Error(err))
+		return
+	}
+	pending, err := m.Pending(context.Background())
+	if err != nil {
+		zap.L().Warn("检查迁移状态失败", zap.Error(err))
+		return
+	}
+	if pending > 0 {
+		zap.L().Warn("数据库有未执行的迁移，请先执行 ./backend migrate up", zap.Int("pending", pending))
+	}
+}
@@ -1,15 +1,21 @@
 package middleware
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/TalkSphere/backend/controller"
 	"github.com/TalkSphere/backend/pkg/jwt"
+	"github.com/TalkSphere/backend/pkg/pat"
 	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/TalkSphere/backend/pkg/session"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// JWTAuthMiddleware 要求请求携带 token，访客令牌也可以通过，访客能访问哪些接口由 RBACMiddleware 决定
 func JWTAuthMiddleware() func(c *gin.Context) {
 	return func(c *gin.Context) {
 		// 客户端携带 Token 的三种方式：1. 放在请求头 2. 放在请求体 3. 放在 URI
@@ -21,42 +27,119 @@ func JWTAuthMiddleware() func(c *gin.Context) {
 			c.Abort()
 			return
 		}
-		// 按空格切割
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			controller.ResponseError(c, controller.CodeInvalidToken)
-			c.Abort()
+		if !authenticate(c, authHeader) {
 			return
 		}
-		// parts[1] 是获取的 tokenString
-		token := parts[1]
-		// 如果是 guest_token_anonymous，设置默认的 guest 用户信息
-		if token == "guest_token_anonymous" {
-			c.Set(controller.CtxtUserID, "0")
-			c.Set(controller.CtxUserName, "guest")
-			c.Set(ROLE, "guest")
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware 公开接口使用，没有 token 时按匿名访客处理，
+// 携带了 token 则必须有效，避免过期的登录状态被静默降级为访客
+func OptionalAuthMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		authHeader := c.Request.Header.Get("Authorization")
+		if authHeader == "" {
+			setAnonymous(c, "")
 			c.Next()
 			return
 		}
-		// 否则正常解析 JWT token
-		mc, err := jwt.ParseToken(token)
-		if err != nil {
-			controller.ResponseError(c, controller.CodeInvalidToken)
-			c.Abort()
+		if !authenticate(c, authHeader) {
 			return
 		}
-		c.Set(controller.CtxtUserID, mc.UserID)
-		c.Set(controller.CtxUserName, mc.Username)
+		c.Next()
+	}
+}
 
-		// 获取并设置用户角色
-		role, err := rbac.GetUserRole(mc.UserID)
+// authenticate 解析 Authorization 头并设置当前身份，失败时中止请求
+func authenticate(c *gin.Context, authHeader string) bool {
+	// 按空格切割
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		controller.ResponseError(c, controller.CodeInvalidToken)
+		c.Abort()
+		return false
+	}
+	// parts[1] 是获取的 tokenString
+	token := parts[1]
+	// 个人访问令牌，权限由 RBACMiddleware 按 scope 进一步收窄
+	if pat.IsToken(token) {
+		t, user, err := pat.Authenticate(token, c.ClientIP())
 		if err != nil {
-			controller.ResponseError(c, controller.CodeServerBusy)
+			if errors.Is(err, pat.ErrInvalidToken) || errors.Is(err, pat.ErrTokenExpired) || errors.Is(err, pat.ErrUserDisabled) {
+				controller.ResponseError(c, controller.CodeInvalidToken)
+			} else {
+				zap.L().Error("校验访问令牌失败", zap.Error(err))
+				controller.ResponseError(c, controller.CodeServerBusy)
+			}
 			c.Abort()
-			return
+			return false
 		}
-		c.Set(ROLE, role)
+		userID := strconv.FormatInt(user.ID, 10)
+		c.Set(controller.CtxtUserID, userID)
+		c.Set(controller.CtxUserName, user.Username)
+		c.Set(controller.CtxTokenScopes, t.ScopeList())
+		return setRole(c, userID)
+	}
+	// 否则正常解析 JWT token
+	mc, err := jwt.ParseToken(token)
+	if errors.Is(err, jwt.ErrUnexpectedToken) {
+		// 带 aud 的 token 只接受访客令牌
+		gc, err := jwt.ParseGuestToken(token)
+		if err != nil {
+			controller.ResponseError(c, controller.CodeInvalidToken)
+			c.Abort()
+			return false
+		}
+		setAnonymous(c, gc.GuestID)
+		return true
+	}
+	if err != nil {
+		controller.ResponseError(c, controller.CodeInvalidToken)
+		c.Abort()
+		return false
+	}
+	// 检查 token 是否已随登出、封禁或改密被吊销
+	revoked, err := session.IsRevoked(mc.Id)
+	if err != nil {
+		controller.ResponseError(c, controller.CodeServerBusy)
+		c.Abort()
+		return false
+	}
+	if revoked {
+		controller.ResponseError(c, controller.CodeInvalidToken)
+		c.Abort()
+		return false
+	}
+	c.Set(controller.CtxtUserID, mc.UserID)
+	c.Set(controller.CtxUserName, mc.Username)
+	c.Set(controller.CtxSessionID, mc.SessionID)
+	if sid, err := strconv.ParseInt(mc.SessionID, 10, 64); err == nil {
+		session.Touch(sid, c.ClientIP())
+	}
 
-		c.Next()
+	return setRole(c, mc.UserID)
+}
+
+// setAnonymous 将当前请求标记为未登录访客，guestID 为空表示没有携带访客令牌
+// 访客在 Casbin 中的主体是 rbac.AnonymousSubject，角色同样从 Casbin 获取
+func setAnonymous(c *gin.Context, guestID string) {
+	c.Set(controller.CtxtUserID, rbac.AnonymousSubject)
+	if guestID != "" {
+		c.Set(controller.CtxGuestID, guestID)
+	}
+	roles, _ := rbac.GetUserRoles(rbac.AnonymousSubject)
+	c.Set(controller.CtxRoles, roles)
+}
+
+// setRole 获取并设置用户的全部角色，失败时中止请求
+func setRole(c *gin.Context, userID string) bool {
+	roles, err := rbac.GetUserRoles(userID)
+	if err != nil {
+		controller.ResponseError(c, controller.CodeServerBusy)
+		c.Abort()
+		return false
 	}
+	c.Set(controller.CtxRoles, roles)
+	return true
 }
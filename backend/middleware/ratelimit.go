@@ -1,10 +1,16 @@
 package middleware
 
 import (
-	"github.com/gin-gonic/gin"
-	"github.com/juju/ratelimit"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/TalkSphere/backend/controller"
+	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/juju/ratelimit"
 )
 
 func RateLimitMiddleware(fillInterval time.Duration, cap int64) func(c *gin.Context) {
@@ -20,3 +26,97 @@ func RateLimitMiddleware(fillInterval time.Duration, cap int64) func(c *gin.Cont
 		c.Next()
 	}
 }
+
+// keyedLimiter 按 key 分别限流，长时间未使用的桶会被清理
+type keyedLimiter struct {
+	mu        sync.Mutex
+	perMinute int64
+	buckets   map[string]*keyedBucket
+	lastSweep time.Time
+}
+
+type keyedBucket struct {
+	bucket   *ratelimit.Bucket
+	lastSeen time.Time
+}
+
+// bucketIdle 桶闲置多久后清理，此时桶早已重新填满，清理不影响限流效果
+const bucketIdle = 10 * time.Minute
+
+func newKeyedLimiter(perMinute int64) *keyedLimiter {
+	return &keyedLimiter{
+		perMinute: perMinute,
+		buckets:   make(map[string]*keyedBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// take 取一个令牌，取不到时返回需要等待的时间
+func (l *keyedLimiter) take(key string) time.Duration {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > bucketIdle {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) > bucketIdle {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &keyedBucket{bucket: ratelimit.NewBucketWithRate(float64(l.perMinute)/60, l.perMinute)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	if b.bucket.TakeAvailable(1) > 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) * 60 / float64(l.perMinute))
+}
+
+// keyedRateLimit 按 keyFn 返回的 key 限流，key 为空的请求不限流
+func keyedRateLimit(perMinute int64, keyFn func(c *gin.Context) string) gin.HandlerFunc {
+	if perMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	l := newKeyedLimiter(perMinute)
+	return func(c *gin.Context) {
+		key := keyFn(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if wait := l.take(key); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			controller.ResponseError(c, controller.CodeTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AnonymousRateLimitMiddleware 只对未登录访客限流，持有访客令牌时按访客ID，否则按 IP
+// 需要放在 JWTAuthMiddleware / OptionalAuthMiddleware 之后
+func AnonymousRateLimitMiddleware(perMinute int64) gin.HandlerFunc {
+	return keyedRateLimit(perMinute, func(c *gin.Context) string {
+		if c.GetString(controller.CtxtUserID) != rbac.AnonymousSubject {
+			return ""
+		}
+		if gid := c.GetString(controller.CtxGuestID); gid != "" {
+			return "guest:" + gid
+		}
+		return "ip:" + c.ClientIP()
+	})
+}
+
+// IPRateLimitMiddleware 按客户端 IP 限流
+func IPRateLimitMiddleware(perMinute int64) gin.HandlerFunc {
+	return keyedRateLimit(perMinute, func(c *gin.Context) string {
+		return c.ClientIP()
+	})
+}
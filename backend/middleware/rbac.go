@@ -4,24 +4,42 @@ import (
 	"net/http"
 
 	"github.com/TalkSphere/backend/controller"
+	"github.com/TalkSphere/backend/pkg/pat"
 	"github.com/TalkSphere/backend/pkg/rbac"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-const ROLE = "role"
-
 // RBACMiddleware 统一的权限检查中间件
 func RBACMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 从上下文中获取用户角色
-		role := c.GetString(ROLE)
-		if role == "" {
-			role = "guest"
+		// 从上下文中获取用户的全部角色
+		roles := c.GetStringSlice(controller.CtxRoles)
+
+		// 个人访问令牌只能访问 scope 覆盖的接口，并且仍然要满足令牌所有者自身的权限
+		if scopes, ok := c.Get(controller.CtxTokenScopes); ok {
+			if !pat.Allowed(scopes.([]string), c.Request.URL.Path, c.Request.Method) {
+				zap.L().Warn("令牌的 scope 不包含该接口",
+					zap.String("userID", c.GetString(controller.CtxtUserID)),
+					zap.Strings("scopes", scopes.([]string)),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("method", c.Request.Method))
+				c.JSON(http.StatusForbidden, gin.H{
+					"code": 403,
+					"msg":  "访问令牌的 scope 不足",
+					"data": gin.H{
+						"path":   c.Request.URL.Path,
+						"method": c.Request.Method,
+						"scopes": scopes,
+					},
+				})
+				c.Abort()
+				return
+			}
 		}
 
 		// 超级管理员直接放行
-		if role == "super_admin" {
+		if rbac.HasRole(roles, "super_admin") {
 			c.Next()
 			return
 		}
@@ -66,23 +84,24 @@ func RBACMiddleware() gin.HandlerFunc {
 		if rbac.CheckUserPermission(userIDStr, obj, act) {
 			zap.L().Info("permission granted",
 				zap.String("userID", userIDStr),
-				zap.String("role", role),
+				zap.Strings("roles", roles),
 				zap.String("path", obj),
 				zap.String("method", act))
 			c.Next()
 		} else {
 			zap.L().Warn("permission denied",
 				zap.String("userID", userIDStr),
-				zap.String("role", role),
+				zap.Strings("roles", roles),
 				zap.String("path", obj),
 				zap.String("method", act))
+			rbac.LogDeniedTrace(userIDStr, obj, act)
 			c.JSON(http.StatusForbidden, gin.H{
 				"code": 403,
 				"msg":  "没有访问权限",
 				"data": gin.H{
 					"path":   obj,
 					"method": act,
-					"role":   role,
+					"roles":  roles,
 				},
 			})
 			c.Abort()
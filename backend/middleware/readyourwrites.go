@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWritesMiddleware 配置了从库时决定请求中的查询读哪个库：
+// 修改数据的请求始终读主库，先查后改时不会因为从库延迟读到旧数据；
+// GET 等只读请求读从库，处理过程中写入过数据后改为读主库
+func ReadYourWritesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx = mysql.WithReadYourWrites(ctx)
+		default:
+			ctx = mysql.WithPrimary(ctx)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
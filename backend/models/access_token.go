@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken 用户为脚本和第三方集成创建的访问令牌，只保存摘要
+type PersonalAccessToken struct {
+	ID         int64      `json:"id,string" gorm:"primaryKey"`
+	UserID     int64      `json:"-" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null;comment:'令牌前几位，便于用户辨认'"`
+	TokenHash  string     `json:"-" gorm:"type:char(64);not null"`
+	Scopes     string     `json:"-" gorm:"type:varchar(500);not null;comment:'空格分隔的 scope 列表'"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"type:varchar(64)"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList 令牌的 scope 列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
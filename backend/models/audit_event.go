@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent 管理操作和权限变更的审计记录
+type AuditEvent struct {
+	ID         int64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActorID    *int64          `json:"actor_id" gorm:"index:idx_audit_events_actor"`
+	Actor      string          `json:"actor" gorm:"type:varchar(64);not null"`
+	Action     string          `json:"action" gorm:"type:varchar(64);not null;index:idx_audit_events_action"`
+	TargetType string          `json:"target_type" gorm:"type:varchar(32);not null;index:idx_audit_events_target,priority:1"`
+	TargetID   string          `json:"target_id" gorm:"type:varchar(64);not null;index:idx_audit_events_target,priority:2"`
+	Before     json.RawMessage `json:"before" gorm:"type:json"`
+	After      json.RawMessage `json:"after" gorm:"type:json"`
+	IP         string          `json:"ip" gorm:"type:varchar(64);column:ip"`
+	RequestID  string          `json:"request_id" gorm:"type:varchar(64);index:idx_audit_events_request"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index:idx_audit_events_created_at"`
+}
+
+// TableName 指定表名
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UserIdentity 用户绑定的第三方登录账号
+type UserIdentity struct {
+	ID        int64     `json:"id,string" gorm:"primaryKey"`
+	UserID    int64     `json:"-" gorm:"not null;uniqueIndex:uk_user_identities_user_provider"`
+	Provider  string    `json:"provider" gorm:"type:varchar(32);not null;uniqueIndex:uk_user_identities_provider_subject;uniqueIndex:uk_user_identities_user_provider"`
+	Subject   string    `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:uk_user_identities_provider_subject"`
+	Email     string    `json:"email" gorm:"type:varchar(100)"`
+	Username  string    `json:"username" gorm:"type:varchar(100)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// UserMFA 用户的 TOTP 两步验证配置
+type UserMFA struct {
+	UserID       int64      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Secret       string     `json:"-" gorm:"type:varchar(255);not null;comment:'AES-GCM 加密后的 TOTP 密钥'"`
+	Enabled      bool       `json:"enabled" gorm:"default:false"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-" gorm:"default:0;comment:'最近一次通过校验的时间步，防止验证码重放'"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// UserRecoveryCode 两步验证的一次性恢复码，只保存哈希
+type UserRecoveryCode struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement"`
+	UserID    int64      `gorm:"index;not null"`
+	CodeHash  string     `gorm:"type:char(64);not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time
+}
+
+// TableName 指定表名
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
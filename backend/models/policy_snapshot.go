@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PolicySnapshot 导入或回滚 Casbin 策略时保存的快照，Rules 为 CSV 格式的完整策略定义（不含用户的角色分配）
+type PolicySnapshot struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Action    string    `json:"action" gorm:"type:varchar(20);not null;comment:'baseline、manual、import 或 rollback'"`
+	AuthorID  *int64    `json:"author_id" gorm:"column:author_id"`
+	Author    string    `json:"author" gorm:"type:varchar(64);not null"`
+	Comment   string    `json:"comment" gorm:"type:varchar(255)"`
+	Rules     string    `json:"rules,omitempty" gorm:"type:mediumtext;not null"`
+	RuleCount int       `json:"rule_count" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (PolicySnapshot) TableName() string {
+	return "policy_snapshots"
+}
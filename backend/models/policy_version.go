@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PolicyVersion Casbin 策略的版本号，每次修改策略加一，多实例部署时据此判断是否需要重新加载
+type PolicyVersion struct {
+	ID        int8      `json:"id" gorm:"primaryKey"`
+	Version   int64     `json:"version" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (PolicyVersion) TableName() string {
+	return "casbin_policy_version"
+}
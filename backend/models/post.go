@@ -16,6 +16,8 @@ type Post struct {
 	CreatedAt     time.Time   `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
 	UpdatedAt     time.Time   `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
 	Status        int8        `json:"status" gorm:"type:tinyint;default:1;comment:'1: published, 0: draft, -1: deleted'"`
+	IsLocked      bool        `json:"is_locked" gorm:"column:is_locked;default:false;comment:'锁定后不能再评论'"`
+	IsPinned      bool        `json:"is_pinned" gorm:"column:is_pinned;default:false;comment:'置顶'"`
 	Tags          []Tag       `json:"tags" gorm:"many2many:post_tags;"`
 	Images        []PostImage `json:"images" gorm:"foreignKey:PostID"`
 	Author        *User       `json:"author" gorm:"foreignKey:AuthorID"`
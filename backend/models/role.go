@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Role 角色的描述信息，角色的权限和继承关系保存在 Casbin 策略中
+type Role struct {
+	Name        string    `json:"name" gorm:"primaryKey;type:varchar(64)"`
+	Description string    `json:"description" gorm:"type:varchar(255)"`
+	Builtin     bool      `json:"builtin" gorm:"not null;default:false;comment:'内置角色不能删除'"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Role) TableName() string {
+	return "roles"
+}
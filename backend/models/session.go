@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// UserSession 登录会话，每个会话持有一个可轮换的 refresh token
+type UserSession struct {
+	ID               int64      `json:"id" gorm:"primaryKey"`
+	UserID           int64      `json:"user_id" gorm:"index;not null"`
+	RefreshTokenHash string     `json:"-" gorm:"type:char(64);not null;column:refresh_token_hash"`
+	AccessJTI        string     `json:"-" gorm:"type:varchar(64);column:access_jti"`
+	AccessExpiresAt  time.Time  `json:"-" gorm:"column:access_expires_at"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt        *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+	UserAgent        string     `json:"user_agent" gorm:"type:varchar(255);column:user_agent"`
+	IP               string     `json:"ip" gorm:"type:varchar(64);column:ip"`
+	LastSeenAt       *time.Time `json:"last_seen_at" gorm:"column:last_seen_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// RevokedToken access token 的 jti 黑名单，过期后即可清理
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;type:varchar(64);column:jti"`
+	UserID    int64     `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// TableName 指定表名
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// RotatedRefreshToken 已轮换掉的 refresh token 摘要
+// 只有出示这里的旧令牌才算重复使用，其它不匹配的令牌按无效处理
+type RotatedRefreshToken struct {
+	TokenHash string    `gorm:"primaryKey;type:char(64);column:token_hash"`
+	SessionID int64     `gorm:"index;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time
+}
+
+// TableName 指定表名
+func (RotatedRefreshToken) TableName() string {
+	return "rotated_refresh_tokens"
+}
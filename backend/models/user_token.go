@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// 一次性令牌用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeOAuthLogin    = "oauth_login"
+)
+
+// UserToken 邮件或跳转链接中发出的一次性令牌，只保存签名后的摘要
+type UserToken struct {
+	ID        int64      `gorm:"primaryKey"`
+	UserID    int64      `gorm:"index;not null"`
+	Purpose   string     `gorm:"type:varchar(32);not null"`
+	TokenHash string     `gorm:"type:char(64);not null;column:token_hash"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time
+}
+
+// TableName 指定表名
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
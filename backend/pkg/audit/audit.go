@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/setting"
+
+	"go.uber.org/zap"
+)
+
+// 操作类型，格式为 <对象>.<操作>
+const (
+	ActionUserRoleUpdate       = "user.role.update"
+	ActionUserRoleAdd          = "user.role.add"
+	ActionUserRoleRemove       = "user.role.remove"
+	ActionUserPermissionUpdate = "user.permission.update"
+	ActionUserStatusUpdate     = "user.status.update"
+	ActionUserSessionRevoke    = "user.session.revoke"
+	ActionLockoutClear         = "lockout.clear"
+	ActionRoleCreate           = "role.create"
+	ActionRoleUpdate           = "role.update"
+	ActionRoleClone            = "role.clone"
+	ActionRoleDelete           = "role.delete"
+	ActionPolicyImport         = "policy.import"
+	ActionPolicySnapshot       = "policy.snapshot"
+	ActionPolicyRollback       = "policy.rollback"
+	ActionBoardCreate          = "board.create"
+	ActionBoardUpdate          = "board.update"
+	ActionBoardDelete          = "board.delete"
+	ActionModeratorAdd         = "board.moderator.add"
+	ActionModeratorRemove      = "board.moderator.remove"
+	ActionPostLock             = "post.lock"
+	ActionPostPin              = "post.pin"
+	ActionPostMove             = "post.move"
+	ActionPostDelete           = "post.delete"
+	ActionCommentDelete        = "comment.delete"
+)
+
+// 操作对象类型
+const (
+	TargetUser    = "user"
+	TargetRole    = "role"
+	TargetPolicy  = "policy"
+	TargetBoard   = "board"
+	TargetPost    = "post"
+	TargetComment = "comment"
+	TargetLockout = "lockout"
+)
+
+// Entry 一次需要审计的操作，Before、After 为操作前后的状态，记录时序列化为 JSON
+type Entry struct {
+	ActorID    *int64
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	IP         string
+	RequestID  string
+}
+
+// Filter 查询条件，零值表示不限制
+type Filter struct {
+	ActorID    *int64
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	Start      *time.Time
+	End        *time.Time
+}
+
+var cfg *setting.AuditConfig
+
+// Init 启动过期审计记录的定期清理，cfg 为 nil 或 retention_days 为 0 时永久保留
+func Init(c *setting.AuditConfig) {
+	cfg = c
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			purgeExpired()
+		}
+	}()
+}
+
+// Record 写入一条审计记录。操作本身已经完成，写入失败只记录日志
+func Record(e Entry) {
+	event := &models.AuditEvent{
+		ActorID:    e.ActorID,
+		Actor:      e.Actor,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     marshal(e.Before),
+		After:      marshal(e.After),
+		IP:         e.IP,
+		RequestID:  e.RequestID,
+	}
+	if err := mysql.DB.Create(event).Error; err != nil {
+		zap.L().Error("写入审计记录失败",
+			zap.String("action", e.Action),
+			zap.String("actor", e.Actor),
+			zap.String("target_type", e.TargetType),
+			zap.String("target_id", e.TargetID),
+			zap.String("request_id", e.RequestID),
+			zap.Error(err))
+	}
+}
+
+// List 按时间倒序分页查询审计记录
+func List(f Filter, page, size int) ([]models.AuditEvent, int64, error) {
+	query := mysql.DB.Model(&models.AuditEvent{})
+	if f.ActorID != nil {
+		query = query.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.TargetType != "" {
+		query = query.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		query = query.Where("target_id = ?", f.TargetID)
+	}
+	if f.RequestID != "" {
+		query = query.Where("request_id = ?", f.RequestID)
+	}
+	if f.Start != nil {
+		query = query.Where("created_at >= ?", *f.Start)
+	}
+	if f.End != nil {
+		query = query.Where("created_at < ?", *f.End)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	events := make([]models.AuditEvent, 0, size)
+	err := query.Order("id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&events).Error
+	return events, total, err
+}
+
+// purgeExpired 删除超过保留期限的审计记录
+func purgeExpired() {
+	if cfg == nil || cfg.RetentionDays <= 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -int(cfg.RetentionDays))
+	result := mysql.DB.Where("created_at < ?", cutoff).Delete(&models.AuditEvent{})
+	if result.Error != nil {
+		zap.L().Error("清理过期审计记录失败", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		zap.L().Info("清理过期审计记录", zap.Int64("count", result.RowsAffected), zap.Time("before", cutoff))
+	}
+}
+
+// marshal nil 保存为 NULL，序列化失败时记录错误信息而不是丢弃整条审计记录
+func marshal(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"marshal_error": err.Error()})
+	}
+	return data
+}
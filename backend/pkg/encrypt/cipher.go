@@ -0,0 +1,64 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/TalkSphere/backend/setting"
+)
+
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// Seal 使用 AES-256-GCM 加密需要落库但必须可还原的敏感数据（如 TOTP 密钥）
+// 密钥由 DataKey 派生
+func Seal(plaintext string) (string, error) {
+	aead, err := newAEAD()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 的结果
+func Open(ciphertext string) (string, error) {
+	aead, err := newAEAD()
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil || len(b) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plain), nil
+}
+
+// DataKey 签名令牌、加密落库数据使用的密钥：优先使用 data_key，
+// 没有配置时沿用 secret_key，兼容已经用 secret_key 生成的令牌和密文
+func DataKey() string {
+	if setting.Conf.EncryptConfig.DataKey != "" {
+		return setting.Conf.EncryptConfig.DataKey
+	}
+	return setting.Conf.EncryptConfig.SecretKey
+}
+
+func newAEAD() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("talksphere:seal:" + DataKey()))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
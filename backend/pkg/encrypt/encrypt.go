@@ -2,13 +2,118 @@ package encrypt
 
 import (
 	"crypto/md5"
+	"crypto/subtle"
 	"encoding/hex"
+	"errors"
+	"strings"
 
 	"github.com/TalkSphere/backend/setting"
 )
 
-func EncryptPassword(opassword string) string {
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrInvalidHash      = errors.New("invalid password hash format")
+)
+
+// Hasher 密码哈希算法
+// 生成的哈希串自带算法标识和参数（如 $argon2id$v=19$m=...），
+// 这样更换算法或调整参数后，旧哈希仍能被正确校验
+type Hasher interface {
+	// Hash 生成带版本信息的哈希串
+	Hash(password string) (string, error)
+	// Verify 以常量时间比较密码与哈希串
+	Verify(password, encoded string) (bool, error)
+	// Match 判断哈希串是否由该算法生成
+	Match(encoded string) bool
+	// NeedsRehash 判断哈希串的参数是否落后于当前配置
+	NeedsRehash(encoded string) bool
+}
+
+var (
+	current Hasher = NewArgon2idHasher(DefaultArgon2Params)
+	hashers        = []Hasher{current, NewBcryptHasher(DefaultBcryptCost)}
+)
+
+// Init 根据配置选择新密码使用的哈希算法
+func Init(cfg *setting.EncryptConfig) error {
+	argon := NewArgon2idHasher(Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	})
+	bc := NewBcryptHasher(cfg.BcryptCost)
+
+	switch cfg.Algorithm {
+	case "", AlgorithmArgon2id:
+		current = argon
+	case AlgorithmBcrypt:
+		current = bc
+	default:
+		return ErrUnknownAlgorithm
+	}
+	hashers = []Hasher{argon, bc}
+
+	h, err := current.Hash("talksphere-dummy-password")
+	if err != nil {
+		return err
+	}
+	dummyHash = h
+	return nil
+}
+
+// dummyHash 用当前算法和参数生成的占位哈希，供 DummyVerify 使用
+var dummyHash string
+
+// DummyVerify 用户不存在时也做一次同等开销的密码校验，
+// 使响应耗时与用户存在时一致，避免通过耗时判断用户名是否已注册
+func DummyVerify(password string) {
+	if dummyHash == "" {
+		return
+	}
+	_, _ = current.Verify(password, dummyHash)
+}
+
+// HashPassword 使用当前配置的算法生成密码哈希
+func HashPassword(password string) (string, error) {
+	return current.Hash(password)
+}
+
+// VerifyPassword 校验密码
+// rehash 为 true 表示密码正确，但哈希串是旧版 MD5 或参数已过期，调用方应重新生成并保存
+func VerifyPassword(password, encoded string) (ok bool, rehash bool, err error) {
+	// 未设置密码的账号（例如通过第三方登录注册）不能使用密码登录
+	if encoded == "" {
+		DummyVerify(password)
+		return false, false, nil
+	}
+	if !strings.HasPrefix(encoded, "$") {
+		return verifyLegacy(password, encoded), true, nil
+	}
+	for _, h := range hashers {
+		if !h.Match(encoded) {
+			continue
+		}
+		ok, err = h.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, !current.Match(encoded) || current.NeedsRehash(encoded), nil
+	}
+	return false, false, ErrUnknownAlgorithm
+}
+
+// legacyPassword 旧版的密码摘要算法，仅用于兼容升级前注册的账号
+func legacyPassword(password string) string {
 	h := md5.New()
 	h.Write([]byte(setting.Conf.EncryptConfig.SecretKey))
-	return hex.EncodeToString(h.Sum([]byte(opassword)))
+	return hex.EncodeToString(h.Sum([]byte(password)))
+}
+
+func verifyLegacy(password, encoded string) bool {
+	return subtle.ConstantTimeCompare([]byte(legacyPassword(password)), []byte(encoded)) == 1
 }
@@ -0,0 +1,73 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/TalkSphere/backend/setting"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// guestAudience 访客令牌的 aud，ParseToken 会拒绝带 aud 的 token，访客令牌不能当作 access token 使用
+const guestAudience = "talksphere:guest"
+
+var ErrInvalidGuestToken = errors.New("invalid guest token")
+
+// GuestClaims 未登录访客的令牌，只携带随机生成的访客ID，用于区分访客以便限流
+type GuestClaims struct {
+	GuestID string `json:"gid"`
+	jwt.StandardClaims
+}
+
+// GenGuestToken 为新访客签发令牌
+func GenGuestToken() (string, *GuestClaims, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	jti, err := newJTI()
+	if err != nil {
+		return "", nil, err
+	}
+	c := &GuestClaims{
+		GuestID: hex.EncodeToString(b),
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Audience:  guestAudience,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(GuestTokenExpire()).Unix(),
+			Issuer:    keys.issuer,
+		},
+	}
+	s, err := sign(c)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, c, nil
+}
+
+// ParseGuestToken 解析访客令牌
+func ParseGuestToken(tokenString string) (*GuestClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &GuestClaims{}, keys.keyFunc)
+	if err != nil {
+		return nil, ErrInvalidGuestToken
+	}
+	claims, ok := token.Claims.(*GuestClaims)
+	if !ok || !token.Valid ||
+		!claims.VerifyIssuer(keys.issuer, true) ||
+		!claims.VerifyAudience(guestAudience, true) ||
+		claims.GuestID == "" {
+		return nil, ErrInvalidGuestToken
+	}
+	return claims, nil
+}
+
+// GuestTokenExpire 访客令牌有效期，默认 1 天
+func GuestTokenExpire() time.Duration {
+	if setting.Conf.AuthConfig == nil || setting.Conf.Guest == nil || setting.Conf.Guest.TokenExpire <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(setting.Conf.Guest.TokenExpire) * time.Second
+}
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"strconv"
 	"time"
@@ -11,46 +13,100 @@ import (
 
 //const TokenExpireDuration = time.Hour * 2
 
-var mySecret = []byte("&asd99dBNBAsdq")
+var (
+	ErrInvalidIssuer   = errors.New("invalid token issuer")
+	ErrUnexpectedToken = errors.New("unexpected token audience")
+)
 
 // MyClaims 自定义声明结构体并内嵌 jwt.StandardClaims
 // jwt 包自带的 jwt.StandardClaims 只包含了官方字段
 // 我们这里需要额外记录一个 UserID 字段，所以要自定义结构体
 // 如果想要保存更多信息，都可以添加到这个结构体中
 type MyClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"` // 所属登录会话，用于登出和吊销
 	jwt.StandardClaims
 }
 
-// GenToken 生成 JWT
-func GenToken(userID int64, username string) (string, error) {
+// GenToken 生成短期有效的 access token，Id 字段（jti）用于吊销
+func GenToken(userID int64, username string, sessionID int64) (string, *MyClaims, error) {
+	jti, err := newJTI()
+	if err != nil {
+		return "", nil, err
+	}
 	// 创建一个我们自己的声明的数据
-	c := MyClaims{
-		UserID:   strconv.FormatInt(userID, 10),
-		Username: username, // 自定义字段
+	c := &MyClaims{
+		UserID:    strconv.FormatInt(userID, 10),
+		Username:  username, // 自定义字段
+		SessionID: strconv.FormatInt(sessionID, 10),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Duration(setting.Conf.JwtExpire) * time.Second).Unix(), // 过期时间
-			Issuer:    "Issuer",                                                                   // 签发人
+			Id:        jti,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(AccessTokenExpire()).Unix(), // 过期时间
+			Issuer:    keys.issuer,                                // 签发人
 		},
 	}
-	// 使用指定的签名方法创建签名对象
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
-	// 使用指定的 secret 签名并获得完整的编码后的字符串 token
-	return token.SignedString(mySecret)
+	s, err := sign(c)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, c, nil
 }
 
 // ParseToken 解析JWT
 func ParseToken(tokenString string) (*MyClaims, error) {
 	// 解析 token
-	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return mySecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, keys.keyFunc)
 	if err != nil {
 		return nil, err
 	}
 	if claims, ok := token.Claims.(*MyClaims); ok && token.Valid { // 校验 token
+		if !claims.VerifyIssuer(keys.issuer, true) {
+			return nil, ErrInvalidIssuer
+		}
+		// access token 不带 aud，带 aud 的是两步验证票据等其他用途的 token
+		if claims.Audience != "" {
+			return nil, ErrUnexpectedToken
+		}
 		return claims, nil
 	}
 	return nil, errors.New("invalid token")
 }
+
+// sign 使用当前签名密钥签发 token，并在头部写入 kid 以便轮换后仍能找到校验密钥
+func sign(claims jwt.Claims) (string, error) {
+	k := keys.signing
+	if k == nil {
+		return "", ErrNoSigningKey
+	}
+	// 使用指定的签名方法创建签名对象
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.id
+	// 使用指定的密钥签名并获得完整的编码后的字符串 token
+	return token.SignedString(k.signKey)
+}
+
+// AccessTokenExpire access token 有效期，默认 15 分钟
+func AccessTokenExpire() time.Duration {
+	if setting.Conf.AuthConfig == nil || setting.Conf.AccessTokenExpire <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(setting.Conf.AccessTokenExpire) * time.Second
+}
+
+// RefreshTokenExpire refresh token 有效期，默认 7 天
+func RefreshTokenExpire() time.Duration {
+	if setting.Conf.AuthConfig == nil || setting.Conf.RefreshTokenExpire <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(setting.Conf.RefreshTokenExpire) * time.Second
+}
+
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
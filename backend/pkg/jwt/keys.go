@@ -0,0 +1,226 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/TalkSphere/backend/setting"
+	"github.com/dgrijalva/jwt-go"
+)
+
+const defaultIssuer = "TalkSphere"
+
+var (
+	ErrNoSigningKey = errors.New("jwt signing key not configured")
+	ErrUnknownKeyID = errors.New("unknown jwt key id")
+)
+
+// key 一把签名/校验密钥
+// signKey 为空表示该密钥已经停用，只用来校验轮换前签发、尚未过期的 token
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+type keySet struct {
+	issuer  string
+	signing *key
+	keys    map[string]*key
+}
+
+var keys = &keySet{issuer: defaultIssuer, keys: map[string]*key{}}
+
+// Init 从 auth 配置加载签名密钥
+// signing_key_id 指定签发新 token 使用的密钥，其余密钥仅用于校验，
+// 轮换时先把新密钥加入 keys 并切换 signing_key_id，等旧 token 全部过期后再移除旧密钥
+func Init(cfg *setting.AuthConfig) error {
+	if cfg == nil {
+		return ErrNoSigningKey
+	}
+	ks := &keySet{issuer: cfg.Issuer, keys: make(map[string]*key, len(cfg.Keys))}
+	if ks.issuer == "" {
+		ks.issuer = defaultIssuer
+	}
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return fmt.Errorf("load jwt key %q: %w", kc.ID, err)
+		}
+		if _, ok := ks.keys[k.id]; ok {
+			return fmt.Errorf("duplicate jwt key id %q", k.id)
+		}
+		ks.keys[k.id] = k
+	}
+
+	signing, ok := ks.keys[cfg.SigningKeyID]
+	if !ok || signing.signKey == nil {
+		return ErrNoSigningKey
+	}
+	ks.signing = signing
+	keys = ks
+	return nil
+}
+
+func loadKey(kc setting.JWTKey) (*key, error) {
+	if kc.ID == "" {
+		return nil, errors.New("kid is required")
+	}
+	k := &key{id: kc.ID}
+	switch kc.Algorithm {
+	case "", "HS256":
+		if kc.Secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signKey = []byte(kc.Secret)
+		k.verifyKey = k.signKey
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			b, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(b)
+			if err != nil {
+				return nil, err
+			}
+			k.signKey = priv
+			k.verifyKey = &priv.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			b, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(b)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = pub
+		}
+	case "EdDSA":
+		k.method = SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			block, err := readPEM(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			priv, ok := parsed.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("not an ed25519 private key")
+			}
+			k.signKey = priv
+			k.verifyKey = priv.Public().(ed25519.PublicKey)
+		}
+		if kc.PublicKeyFile != "" {
+			block, err := readPEM(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			pub, ok := parsed.(ed25519.PublicKey)
+			if !ok {
+				return nil, errors.New("not an ed25519 public key")
+			}
+			k.verifyKey = pub
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+	if k.verifyKey == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+	return k, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("invalid PEM file")
+	}
+	return block, nil
+}
+
+// keyFunc 按 token 头部的 kid 选择校验密钥，并要求 alg 与密钥一致，防止算法混淆攻击
+func (ks *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	k := ks.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		if k, ok = ks.keys[kid]; !ok {
+			return nil, ErrUnknownKeyID
+		}
+	}
+	if k == nil {
+		return nil, ErrNoSigningKey
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return k.verifyKey, nil
+}
+
+// JWK RFC 7517 公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet /.well-known/jwks.json 的响应
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回所有非对称密钥的公钥，供其他服务校验 TalkSphere 签发的 token
+// HS256 密钥是共享密钥，不会被公开
+func JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(keys.keys))}
+	for _, k := range keys.keys {
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: k.id,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: k.id,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
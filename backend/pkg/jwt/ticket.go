@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ticketAudience 两步验证票据的 aud，ParseToken 会拒绝带 aud 的 token，防止票据被当作 access token 使用
+const ticketAudience = "talksphere:mfa"
+
+// 票据用途
+const (
+	TicketPurposeVerify = "verify" // 已启用两步验证，等待输入验证码
+	TicketPurposeEnroll = "enroll" // 角色要求两步验证但尚未绑定，等待完成绑定
+)
+
+// TicketExpire 票据有效期
+const TicketExpire = 5 * time.Minute
+
+var ErrInvalidTicket = errors.New("invalid mfa ticket")
+
+// TicketClaims 密码校验通过、两步验证完成之前签发的短期票据
+type TicketClaims struct {
+	UserID  string `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+// GenTicket 生成两步验证票据
+func GenTicket(userID int64, purpose string) (string, error) {
+	jti, err := newJTI()
+	if err != nil {
+		return "", err
+	}
+	c := &TicketClaims{
+		UserID:  strconv.FormatInt(userID, 10),
+		Purpose: purpose,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Audience:  ticketAudience,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(TicketExpire).Unix(),
+			Issuer:    keys.issuer,
+		},
+	}
+	return sign(c)
+}
+
+// ParseTicket 解析两步验证票据，并校验用途
+func ParseTicket(tokenString, purpose string) (*TicketClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TicketClaims{}, keys.keyFunc)
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+	claims, ok := token.Claims.(*TicketClaims)
+	if !ok || !token.Valid ||
+		!claims.VerifyIssuer(keys.issuer, true) ||
+		!claims.VerifyAudience(ticketAudience, true) ||
+		claims.Purpose != purpose {
+		return nil, ErrInvalidTicket
+	}
+	return claims, nil
+}
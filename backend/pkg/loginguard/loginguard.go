@@ -0,0 +1,176 @@
+package loginguard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/pkg/redis"
+	"github.com/TalkSphere/backend/setting"
+)
+
+// 默认策略：同一用户名连续失败 5 次、同一 IP 失败 20 次后开始锁定，
+// 锁定时长从 30 秒起每多失败一次翻倍，最长 15 分钟；15 分钟内没有新的失败则计数清零
+const (
+	defaultMaxUserFailures = 5
+	defaultMaxIPFailures   = 20
+	defaultBaseLockout     = 30 * time.Second
+	defaultMaxLockout      = 15 * time.Minute
+	defaultWindow          = 15 * time.Minute
+)
+
+const (
+	userKeyPrefix = "user:"
+	ipKeyPrefix   = "ip:"
+)
+
+var ErrStoreUnavailable = errors.New("loginguard: redis store requires redis to be initialized")
+
+type policy struct {
+	maxUserFailures int64
+	maxIPFailures   int64
+	baseLockout     time.Duration
+	maxLockout      time.Duration
+	window          time.Duration
+}
+
+var (
+	// memory 进程内唯一的内存存储，未调用 Init 或重新加载配置时都使用它，不会重复创建清理协程
+	memory       = newMemoryStore()
+	store  Store = memory
+	p            = policy{
+		maxUserFailures: defaultMaxUserFailures,
+		maxIPFailures:   defaultMaxIPFailures,
+		baseLockout:     defaultBaseLockout,
+		maxLockout:      defaultMaxLockout,
+		window:          defaultWindow,
+	}
+)
+
+// Init 根据配置选择存储和锁定策略，没有配置时使用内存存储和默认策略
+func Init(cfg *setting.LoginGuardConfig) error {
+	if cfg == nil {
+		cfg = &setting.LoginGuardConfig{}
+	}
+	switch cfg.Store {
+	case "", "memory":
+		store = memory
+	case "redis":
+		client := redis.Client()
+		if client == nil {
+			return ErrStoreUnavailable
+		}
+		store = newRedisStore(client)
+	default:
+		return fmt.Errorf("loginguard: unknown store %q", cfg.Store)
+	}
+	if cfg.MaxUserFailures > 0 {
+		p.maxUserFailures = cfg.MaxUserFailures
+	}
+	if cfg.MaxIPFailures > 0 {
+		p.maxIPFailures = cfg.MaxIPFailures
+	}
+	if cfg.BaseLockout > 0 {
+		p.baseLockout = time.Duration(cfg.BaseLockout) * time.Second
+	}
+	if cfg.MaxLockout > 0 {
+		p.maxLockout = time.Duration(cfg.MaxLockout) * time.Second
+	}
+	if cfg.Window > 0 {
+		p.window = time.Duration(cfg.Window) * time.Second
+	}
+	return nil
+}
+
+// Check 返回用户名或 IP 仍需等待的锁定时长，0 表示可以尝试登录
+func Check(username, ip string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range keys(username, ip) {
+		e, err := store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if e.Locked(now) {
+			if d := e.LockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	return wait, nil
+}
+
+// Fail 记录一次失败（密码错误、用户不存在或两步验证码错误），达到阈值后按指数退避锁定
+func Fail(username, ip string) error {
+	limits := map[string]int64{}
+	if username != "" {
+		limits[UserKey(username)] = p.maxUserFailures
+	}
+	if ip != "" {
+		limits[IPKey(ip)] = p.maxIPFailures
+	}
+	for key, limit := range limits {
+		n, err := store.Incr(key, p.window)
+		if err != nil {
+			return err
+		}
+		if n < limit {
+			continue
+		}
+		lockout := backoff(n - limit)
+		if err := store.Lock(key, time.Now().Add(lockout), lockout+p.window); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed 登录成功后清除该用户名的失败计数
+// IP 计数不清除，否则攻击者可以用自己的账号登录一次来重置 IP 计数
+func Succeed(username string) error {
+	return store.Delete(UserKey(username))
+}
+
+// List 列出所有失败记录，供管理员查看
+func List() ([]Entry, error) {
+	return store.List()
+}
+
+// Clear 管理员解除锁定
+func Clear(key string) error {
+	return store.Delete(key)
+}
+
+// UserKey 用户名对应的计数键，用户名不区分大小写
+func UserKey(username string) string {
+	return userKeyPrefix + strings.ToLower(strings.TrimSpace(username))
+}
+
+// IPKey IP 对应的计数键
+func IPKey(ip string) string {
+	return ipKeyPrefix + ip
+}
+
+func keys(username, ip string) []string {
+	list := make([]string, 0, 2)
+	if username != "" {
+		list = append(list, UserKey(username))
+	}
+	if ip != "" {
+		list = append(list, IPKey(ip))
+	}
+	return list
+}
+
+// backoff 第 n 次超出阈值时的锁定时长
+func backoff(n int64) time.Duration {
+	d := p.baseLockout
+	for i := int64(0); i < n; i++ {
+		d *= 2
+		if d >= p.maxLockout {
+			return p.maxLockout
+		}
+	}
+	return d
+}
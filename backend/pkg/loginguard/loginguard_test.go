@@ -0,0 +1,55 @@
+package loginguard
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/TalkSphere/backend/setting"
+)
+
+// 重新加载配置时沿用同一个内存存储，不会多出清理协程，已有的锁定也不会丢失
+func TestInitReusesMemoryStore(t *testing.T) {
+	if err := Init(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Fail("alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		if err := Init(&setting.LoginGuardConfig{Store: "memory"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines = %d after reloading, %d before", after, before)
+	}
+	if store != memory {
+		t.Fatal("Init replaced the memory store")
+	}
+	e, err := store.Get(UserKey("alice"))
+	if err != nil || e == nil || e.Failures != 1 {
+		t.Fatalf("entry after reload = %+v, %v", e, err)
+	}
+	t.Cleanup(func() { memory.Delete(UserKey("alice")) })
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s := &memoryStore{entries: map[string]*memoryEntry{}}
+	if _, err := s.Incr("user:bob", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	go s.sweep(time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		n := len(s.entries)
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expired entry was not swept")
+}
@@ -0,0 +1,234 @@
+package loginguard
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Entry 一个计数键（用户名或 IP）的失败记录
+type Entry struct {
+	Key         string    `json:"key"`
+	Failures    int64     `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Locked 当前是否处于锁定中
+func (e *Entry) Locked(now time.Time) bool {
+	return now.Before(e.LockedUntil)
+}
+
+// Store 失败计数的存储
+// 单实例部署可以使用内存存储，多实例部署需要使用 redis 共享计数
+type Store interface {
+	// Get 获取记录，不存在时返回 Failures 为 0 的记录
+	Get(key string) (*Entry, error)
+	// Incr 失败次数加一并刷新过期时间，返回新的失败次数
+	Incr(key string, ttl time.Duration) (int64, error)
+	// Lock 锁定到指定时间
+	Lock(key string, until time.Time, ttl time.Duration) error
+	// Delete 清除记录
+	Delete(key string) error
+	// List 列出所有未过期的记录
+	List() ([]Entry, error)
+}
+
+// memoryStore 进程内存储
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+type memoryEntry struct {
+	Entry
+	expiresAt time.Time
+}
+
+// newMemoryStore 创建内存存储并启动定期清理，每个存储只有一个清理协程
+func newMemoryStore() *memoryStore {
+	s := &memoryStore{entries: map[string]*memoryEntry{}}
+	go s.sweep(memorySweepInterval)
+	return s
+}
+
+// memorySweepInterval 定期清理过期记录的间隔。记录只在被访问时才会检查过期，
+// 不清理的话大量不同的用户名或 IP 会让内存一直增长
+const memorySweepInterval = time.Minute
+
+// sweep 定期删除已过期的记录，随进程一直运行
+func (s *memoryStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for key := range s.entries {
+			s.get(key, now)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *memoryStore) Get(key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.get(key, time.Now())
+	if e == nil {
+		return &Entry{Key: key}, nil
+	}
+	entry := e.Entry
+	return &entry, nil
+}
+
+func (s *memoryStore) Incr(key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	e := s.get(key, now)
+	if e == nil {
+		e = &memoryEntry{Entry: Entry{Key: key}}
+		s.entries[key] = e
+	}
+	e.Failures++
+	e.expiresAt = now.Add(ttl)
+	return e.Failures, nil
+}
+
+func (s *memoryStore) Lock(key string, until time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	e := s.get(key, now)
+	if e == nil {
+		e = &memoryEntry{Entry: Entry{Key: key}}
+		s.entries[key] = e
+	}
+	e.LockedUntil = until
+	if exp := now.Add(ttl); exp.After(e.expiresAt) {
+		e.expiresAt = exp
+	}
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) List() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	list := make([]Entry, 0, len(s.entries))
+	for key := range s.entries {
+		if e := s.get(key, now); e != nil {
+			list = append(list, e.Entry)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, nil
+}
+
+// get 获取未过期的记录，顺便清理已过期的记录，调用方需持有锁
+func (s *memoryStore) get(key string, now time.Time) *memoryEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if now.After(e.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// redisStore 使用 redis hash 保存记录，另用一个 set 记录所有键以便管理员查看
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+const (
+	fieldFailures    = "failures"
+	fieldLockedUntil = "locked_until"
+)
+
+func newRedisStore(client *redis.Client) *redisStore {
+	return &redisStore{client: client, prefix: "talksphere:loginguard:"}
+}
+
+func (s *redisStore) Get(key string) (*Entry, error) {
+	m, err := s.client.HGetAll(s.prefix + key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseEntry(key, m), nil
+}
+
+func (s *redisStore) Incr(key string, ttl time.Duration) (int64, error) {
+	pipe := s.client.TxPipeline()
+	incr := pipe.HIncrBy(s.prefix+key, fieldFailures, 1)
+	pipe.Expire(s.prefix+key, ttl)
+	pipe.SAdd(s.indexKey(), key)
+	if _, err := pipe.Exec(); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *redisStore) Lock(key string, until time.Time, ttl time.Duration) error {
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.prefix+key, fieldLockedUntil, until.Unix())
+	pipe.Expire(s.prefix+key, ttl)
+	pipe.SAdd(s.indexKey(), key)
+	_, err := pipe.Exec()
+	return err
+}
+
+func (s *redisStore) Delete(key string) error {
+	pipe := s.client.TxPipeline()
+	pipe.Del(s.prefix + key)
+	pipe.SRem(s.indexKey(), key)
+	_, err := pipe.Exec()
+	return err
+}
+
+func (s *redisStore) List() ([]Entry, error) {
+	keys, err := s.client.SMembers(s.indexKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		m, err := s.client.HGetAll(s.prefix + key).Result()
+		if err != nil {
+			return nil, err
+		}
+		// hash 已过期，从索引中移除
+		if len(m) == 0 {
+			s.client.SRem(s.indexKey(), key)
+			continue
+		}
+		list = append(list, *parseEntry(key, m))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, nil
+}
+
+func (s *redisStore) indexKey() string {
+	return s.prefix + "keys"
+}
+
+func parseEntry(key string, m map[string]string) *Entry {
+	e := &Entry{Key: key}
+	e.Failures, _ = strconv.ParseInt(m[fieldFailures], 10, 64)
+	if ts, err := strconv.ParseInt(m[fieldLockedUntil], 10, 64); err == nil {
+		e.LockedUntil = time.Unix(ts, 0)
+	}
+	return e
+}
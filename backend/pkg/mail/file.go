@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender 把每封邮件写成一个 .eml 文件，便于测试时检查邮件内容
+type FileSender struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if dir == "" {
+		dir = "mail_outbox"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), s.seq.Add(1))
+	return os.WriteFile(filepath.Join(s.dir, name), buildMIME(s.from, msg), 0o644)
+}
+
+// Dir 返回邮件输出目录
+func (s *FileSender) Dir() string {
+	return s.dir
+}
@@ -0,0 +1,22 @@
+package mail
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogSender 只把邮件内容写入日志，用于本地开发
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	zap.L().Info("邮件未发送，仅记录到日志",
+		zap.Strings("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("text", msg.Text))
+	return nil
+}
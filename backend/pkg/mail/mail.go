@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/setting"
+)
+
+// Message 一封邮件
+type Message struct {
+	To      []string
+	Subject string
+	Text    string // 纯文本正文
+	HTML    string // 可选的 HTML 正文
+}
+
+// Sender 邮件发送器
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+var sender Sender = NewLogSender()
+
+// Init 根据配置选择邮件发送器
+func Init(cfg *setting.MailConfig) error {
+	switch cfg.Driver {
+	case "", "log":
+		sender = NewLogSender()
+	case "file":
+		s, err := NewFileSender(cfg.FileDir, cfg.From)
+		if err != nil {
+			return err
+		}
+		sender = s
+	case "smtp":
+		sender = NewSMTPSender(SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+			TLS:      cfg.SMTPTLS,
+		})
+	default:
+		return fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+	return nil
+}
+
+// SetSender 替换默认发送器
+func SetSender(s Sender) {
+	sender = s
+}
+
+// Send 使用默认发送器发送邮件
+func Send(ctx context.Context, msg *Message) error {
+	return sender.Send(ctx, msg)
+}
+
+// buildMIME 组装 RFC 5322 邮件，有 HTML 正文时使用 multipart/alternative
+func buildMIME(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", encodeHeader(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		b.WriteString(msg.Text)
+		return []byte(b.String())
+	}
+	boundary := fmt.Sprintf("talksphere-%d", time.Now().UnixNano())
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", boundary, msg.Text)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, msg.HTML)
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return []byte(b.String())
+}
+
+// encodeHeader 对包含中文的邮件头做 RFC 2047 编码
+func encodeHeader(s string) string {
+	return mime.BEncoding.Encode("UTF-8", s)
+}
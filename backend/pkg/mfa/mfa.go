@@ -0,0 +1,296 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/rbac"
+	"github.com/TalkSphere/backend/pkg/totp"
+	"github.com/TalkSphere/backend/setting"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 恢复码数量和长度
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// 允许前后各一个时间步（30 秒）的时钟偏差
+	skew = 1
+)
+
+var (
+	ErrNotEnrolled     = errors.New("two-factor authentication not enrolled")
+	ErrAlreadyEnabled  = errors.New("two-factor authentication already enabled")
+	ErrInvalidCode     = errors.New("invalid two-factor code")
+	ErrRequiredByRole  = errors.New("two-factor authentication is required for this role")
+	defaultIssuer      = "TalkSphere"
+	defaultRequiredFor = []string{"admin"}
+)
+
+// Enrollment 开始绑定时返回给用户的密钥和二维码 URI
+type Enrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Enabled 用户是否已启用两步验证
+func Enabled(userID int64) (bool, error) {
+	var m models.UserMFA
+	err := mysql.DB.Where("user_id = ?", userID).Limit(1).Find(&m).Error
+	if err != nil {
+		return false, err
+	}
+	return m.Enabled, nil
+}
+
+// Required 用户的角色（包括继承得到的角色）是否被配置为必须启用两步验证
+// 例如 required_roles 为 [admin] 时，继承了 admin 的 super_admin 同样需要
+func Required(userID int64) (bool, error) {
+	roles, err := rbac.Enforcer.GetImplicitRolesForUser(strconv.FormatInt(userID, 10))
+	if err != nil {
+		return false, err
+	}
+	required := requiredRoles()
+	for _, r := range roles {
+		for _, want := range required {
+			if r == want {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Enroll 为用户生成新的 TOTP 密钥，在 Activate 之前不会生效
+// 已启用两步验证的用户需要先关闭才能重新绑定
+func Enroll(user *models.User) (*Enrollment, error) {
+	var m models.UserMFA
+	if err := mysql.DB.Where("user_id = ?", user.ID).Limit(1).Find(&m).Error; err != nil {
+		return nil, err
+	}
+	if m.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := encrypt.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	m = models.UserMFA{UserID: user.ID, Secret: sealed}
+	err = mysql.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled", "enabled_at", "last_used_step", "updated_at"}),
+	}).Create(&m).Error
+	if err != nil {
+		return nil, err
+	}
+	return &Enrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(issuer(), user.Username, secret),
+	}, nil
+}
+
+// Activate 用户输入验证器 App 上的验证码确认绑定，成功后启用两步验证并返回恢复码
+func Activate(userID int64, code string) ([]string, error) {
+	var codes []string
+	err := mysql.DB.Transaction(func(tx *gorm.DB) error {
+		m, err := lockMFA(tx, userID)
+		if err != nil {
+			return err
+		}
+		if m.Enabled {
+			return ErrAlreadyEnabled
+		}
+		if err := checkCode(tx, m, code); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Model(m).Updates(map[string]interface{}{"enabled": true, "enabled_at": &now}).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify 登录时校验 TOTP 验证码，同一个验证码只能使用一次
+func Verify(userID int64, code string) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		m, err := lockMFA(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !m.Enabled {
+			return ErrNotEnrolled
+		}
+		return checkCode(tx, m, code)
+	})
+}
+
+// VerifyRecovery 使用恢复码登录，每个恢复码只能使用一次
+func VerifyRecovery(userID int64, code string) error {
+	h := hashRecoveryCode(code)
+	now := time.Now()
+	res := mysql.DB.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, h).
+		Update("used_at", &now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧的恢复码全部作废
+func RegenerateRecoveryCodes(userID int64) ([]string, error) {
+	var codes []string
+	err := mysql.DB.Transaction(func(tx *gorm.DB) error {
+		m, err := lockMFA(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !m.Enabled {
+			return ErrNotEnrolled
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RemainingRecoveryCodes 剩余可用的恢复码数量
+func RemainingRecoveryCodes(userID int64) (int64, error) {
+	var n int64
+	err := mysql.DB.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
+
+// Disable 关闭两步验证，需要提供当前验证码或恢复码
+// 角色要求两步验证的用户不能关闭
+func Disable(userID int64, code string) error {
+	required, err := Required(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequiredByRole
+	}
+	if err := Verify(userID, code); err != nil {
+		if !errors.Is(err, ErrInvalidCode) {
+			return err
+		}
+		if err := VerifyRecovery(userID, code); err != nil {
+			return err
+		}
+	}
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+func lockMFA(tx *gorm.DB, userID int64) (*models.UserMFA, error) {
+	var m models.UserMFA
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&m).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotEnrolled
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// checkCode 校验验证码并记录时间步，不接受不晚于上次使用的时间步，防止验证码重放
+func checkCode(tx *gorm.DB, m *models.UserMFA, code string) error {
+	secret, err := encrypt.Open(m.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), skew)
+	if !ok || step <= m.LastUsedStep {
+		return ErrInvalidCode
+	}
+	return tx.Model(m).Update("last_used_step", step).Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID int64) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.UserRecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, models.UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode 生成形如 xxxxx-xxxxx 的恢复码，去掉了容易混淆的字符
+func newRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:]), nil
+}
+
+// hashRecoveryCode 忽略大小写、空格和连字符，方便用户输入
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func issuer() string {
+	if cfg := setting.Conf.AuthConfig; cfg != nil && cfg.MFA != nil && cfg.MFA.Issuer != "" {
+		return cfg.MFA.Issuer
+	}
+	return defaultIssuer
+}
+
+func requiredRoles() []string {
+	if cfg := setting.Conf.AuthConfig; cfg != nil && cfg.MFA != nil && cfg.MFA.RequiredRoles != nil {
+		return cfg.MFA.RequiredRoles
+	}
+	return defaultRequiredFor
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const Usage = `用法: ./backend migrate <命令> [参数]
+
+命令:
+  up     [N]       执行未执行的迁移，指定 N 时最多执行 N 个
+  down   [N]       回滚最新的 N 个迁移，默认 1 个
+  status           列出所有迁移的执行状态
+  force  <版本号>  不执行脚本，把数据库标记为已执行到指定版本，用于清除 dirty 状态或为旧数据库建立基线
+`
+
+// Command 执行 migrate 子命令，args 为子命令之后的参数，结果输出到 w
+func Command(ctx context.Context, m *Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n\n%s", Usage)
+	}
+
+	switch args[0] {
+	case "up", "down":
+		n := 0
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+				return fmt.Errorf("invalid count %q", args[1])
+			}
+		}
+		run, verb := m.Up, "applied"
+		if args[0] == "down" {
+			run, verb = m.Down, "reverted"
+		}
+		done, err := run(ctx, n)
+		for _, mig := range done {
+			fmt.Fprintf(w, "%s %06d_%s\n", verb, mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(w, "no change")
+		}
+		return nil
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatus(w, statuses)
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version\n\n%s", Usage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := m.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "forced to version %d\n", version)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], Usage)
+	}
+}
+
+func printStatus(w io.Writer, statuses []Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Dirty:
+			state = "dirty"
+		case s.Missing:
+			state = "applied (no script)"
+		case s.Applied:
+			state = "applied"
+		}
+		appliedAt := "-"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	return tw.Flush()
+}
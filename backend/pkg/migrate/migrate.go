@@ -0,0 +1,396 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockTimeout 迁移锁的最长持有时间，超过后视为持有者已异常退出，其他进程可以抢占
+const lockTimeout = time.Hour
+
+var (
+	ErrLocked = errors.New("migrate: another migration is running")
+	ErrDirty  = errors.New("migrate: database is dirty")
+)
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 迁移的执行状态，Missing 表示数据库中记录的版本在脚本中不存在，通常是程序版本比数据库旧
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// schemaMigration 已执行的迁移，Dirty 表示脚本执行到一半失败，需要人工修复
+type schemaMigration struct {
+	Version   int64      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string     `gorm:"type:varchar(255);not null"`
+	Dirty     bool       `gorm:"not null;default:false"`
+	AppliedAt *time.Time `gorm:"column:applied_at"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// schemaMigrationLock 只有一行，LockedBy 不为空时表示有进程正在执行迁移
+type schemaMigrationLock struct {
+	ID       int8       `gorm:"primaryKey;autoIncrement:false"`
+	LockedBy *string    `gorm:"type:varchar(128)"`
+	LockedAt *time.Time `gorm:"column:locked_at"`
+}
+
+func (schemaMigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+var fileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load 读取目录下的迁移脚本并按版本号排序，每个版本必须同时有 up 和 down 脚本
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: invalid version in %s", e.Name())
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migrate: version %06d_%s needs both up and down scripts", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator 在数据库上执行迁移，schema_migrations 记录已执行的版本，
+// schema_migrations_lock 保证同一时间只有一个进程在执行迁移
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	owner      string
+}
+
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		owner:      fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}, nil
+}
+
+// Status 所有迁移的执行状态，按版本号排序；只读，不会创建 schema_migrations
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			s.Applied, s.Dirty, s.AppliedAt = true, row.Dirty, row.AppliedAt
+		}
+		result = append(result, s)
+	}
+	for version, row := range applied {
+		if !known[version] {
+			result = append(result, Status{
+				Version: version, Name: row.Name, Applied: true,
+				Dirty: row.Dirty, AppliedAt: row.AppliedAt, Missing: true,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Pending 尚未执行的迁移数量
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, s := range statuses {
+		if !s.Applied {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Up 按版本号从小到大执行未执行的迁移，n <= 0 时全部执行，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(applied map[int64]schemaMigration) error {
+		for _, mig := range m.migrations {
+			if n > 0 && len(done) >= n {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 从最新的版本开始回滚 n 个迁移，n <= 0 时回滚一个，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n <= 0 {
+		n = 1
+	}
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(applied map[int64]schemaMigration) error {
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if len(done) >= n {
+				break
+			}
+			mig, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migrate: no script for applied version %06d_%s", version, applied[version].Name)
+			}
+			if err := m.revert(ctx, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Force 不执行脚本，直接把数据库标记为已执行到 version（含），用于清除 dirty 状态，
+// 或者为已有表结构的旧数据库建立基线；version 为 0 时清空所有记录
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("migrate: unknown version %d", version)
+	}
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&schemaMigration{}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			row := schemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: &now}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// withLock 加锁后检查 dirty 状态，再以已执行的版本调用 fn
+func (m *Migrator) withLock(ctx context.Context, fn func(applied map[int64]schemaMigration) error) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for version, row := range applied {
+		if row.Dirty {
+			return fmt.Errorf("%w: version %06d_%s failed halfway, fix the schema by hand and run `migrate force <version>`",
+				ErrDirty, version, row.Name)
+		}
+	}
+	return fn(applied)
+}
+
+// apply 执行 up 脚本。MySQL 的 DDL 不能回滚，所以先写入 dirty 记录，全部语句成功后再清除，
+// 中途失败时保留 dirty 记录，阻止后续迁移在不一致的表结构上继续执行
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	db := m.db.WithContext(ctx)
+	row := schemaMigration{Version: mig.Version, Name: mig.Name, Dirty: true}
+	if err := db.Create(&row).Error; err != nil {
+		return err
+	}
+	if err := m.exec(ctx, mig.Up); err != nil {
+		return fmt.Errorf("migrate: %06d_%s up: %w", mig.Version, mig.Name, err)
+	}
+	now := time.Now()
+	return db.Model(&row).Updates(map[string]interface{}{"dirty": false, "applied_at": now}).Error
+}
+
+// revert 执行 down 脚本，成功后删除记录
+func (m *Migrator) revert(ctx context.Context, mig Migration) error {
+	db := m.db.WithContext(ctx)
+	if err := db.Model(&schemaMigration{Version: mig.Version}).Update("dirty", true).Error; err != nil {
+		return err
+	}
+	if err := m.exec(ctx, mig.Down); err != nil {
+		return fmt.Errorf("migrate: %06d_%s down: %w", mig.Version, mig.Name, err)
+	}
+	return db.Delete(&schemaMigration{Version: mig.Version}).Error
+}
+
+// exec 逐条执行脚本中的语句，驱动默认不允许一次执行多条语句
+func (m *Migrator) exec(ctx context.Context, script string) error {
+	db := m.db.WithContext(ctx)
+	for _, stmt := range splitStatements(script) {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]schemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	result := make(map[int64]schemaMigration)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return result, nil
+	}
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	if err := db.AutoMigrate(&schemaMigration{}, &schemaMigrationLock{}); err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&schemaMigrationLock{ID: 1}).Error
+}
+
+// lock 抢占迁移锁，锁被其他进程持有且未超时时返回 ErrLocked
+func (m *Migrator) lock(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	now := time.Now()
+	res := db.Model(&schemaMigrationLock{}).
+		Where("id = ? AND (locked_by IS NULL OR locked_at < ?)", 1, now.Add(-lockTimeout)).
+		Updates(map[string]interface{}{"locked_by": m.owner, "locked_at": now})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+
+	var holder schemaMigrationLock
+	if err := db.First(&holder, 1).Error; err != nil {
+		return err
+	}
+	if holder.LockedBy == nil || holder.LockedAt == nil {
+		return ErrLocked
+	}
+	return fmt.Errorf("%w: held by %s since %s", ErrLocked, *holder.LockedBy, holder.LockedAt.Format(time.DateTime))
+}
+
+// unlock 释放迁移锁，不使用调用方的 ctx，保证迁移被取消时锁也能释放
+func (m *Migrator) unlock() {
+	m.db.Model(&schemaMigrationLock{}).
+		Where("id = ? AND locked_by = ?", 1, m.owner).
+		Updates(map[string]interface{}{"locked_by": nil, "locked_at": nil})
+}
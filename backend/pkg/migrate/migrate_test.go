@@ -0,0 +1,143 @@
+package migrate_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/TalkSphere/backend/deploy/sql/migrations"
+	"github.com/TalkSphere/backend/pkg/migrate"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/setting"
+)
+
+func load(t *testing.T, driver string) []migrate.Migration {
+	t.Helper()
+	fsys, err := migrations.For(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migs, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatalf("load %s migrations: %v", driver, err)
+	}
+	return migs
+}
+
+// 各数据库的迁移脚本版本号和名称必须一一对应
+func TestMigrationsMatchAcrossDrivers(t *testing.T) {
+	want := load(t, mysql.DriverMySQL)
+	for _, driver := range []string{mysql.DriverPostgres, mysql.DriverSQLite} {
+		got := load(t, driver)
+		if len(got) != len(want) {
+			t.Fatalf("%s has %d migrations, mysql has %d", driver, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Version != want[i].Version || got[i].Name != want[i].Name {
+				t.Errorf("%s migration %d = %06d_%s, mysql has %06d_%s",
+					driver, i, got[i].Version, got[i].Name, want[i].Version, want[i].Name)
+			}
+		}
+	}
+}
+
+// newSQLiteMigrator 在临时目录的 SQLite 数据库上创建迁移器
+func newSQLiteMigrator(t *testing.T) *migrate.Migrator {
+	t.Helper()
+	cfg := &setting.MysqlConfig{Driver: mysql.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")}
+	if err := mysql.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mysql.Close)
+	fsys, err := migrations.For(mysql.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(mysql.DB, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSQLiteUpDown(t *testing.T) {
+	ctx := context.Background()
+	m := newSQLiteMigrator(t)
+	total := len(load(t, mysql.DriverSQLite))
+
+	done, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(done) != total {
+		t.Fatalf("up applied %d migrations, want %d", len(done), total)
+	}
+	if pending, err := m.Pending(ctx); err != nil || pending != 0 {
+		t.Fatalf("pending after up = %d, %v", pending, err)
+	}
+	for _, table := range []string{"users", "posts", "comments", "likes", "casbin_rule", "policy_snapshots", "audit_events"} {
+		if !mysql.DB.Migrator().HasTable(table) {
+			t.Errorf("table %s missing after up", table)
+		}
+	}
+	if done, err := m.Up(ctx, 0); err != nil || len(done) != 0 {
+		t.Fatalf("second up = %d migrations, %v; want none", len(done), err)
+	}
+
+	// 全部回滚后再执行一遍，检查 down 脚本删干净了 up 创建的对象
+	if done, err := m.Down(ctx, total); err != nil || len(done) != total {
+		t.Fatalf("down = %d migrations, %v; want %d", len(done), err, total)
+	}
+	if mysql.DB.Migrator().HasTable("users") {
+		t.Error("table users still exists after down")
+	}
+	if pending, err := m.Pending(ctx); err != nil || pending != total {
+		t.Fatalf("pending after down = %d, %v; want %d", pending, err, total)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+}
+
+func TestSQLiteUpSteps(t *testing.T) {
+	ctx := context.Background()
+	m := newSQLiteMigrator(t)
+
+	if done, err := m.Up(ctx, 2); err != nil || len(done) != 2 {
+		t.Fatalf("up 2 = %d migrations, %v", len(done), err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range statuses {
+		if s.Applied != (i < 2) || s.Dirty {
+			t.Errorf("status %06d_%s: applied = %v, dirty = %v", s.Version, s.Name, s.Applied, s.Dirty)
+		}
+	}
+
+	if done, err := m.Down(ctx, 0); err != nil || len(done) != 1 || done[0].Version != statuses[1].Version {
+		t.Fatalf("down = %v, %v; want version %d", done, err, statuses[1].Version)
+	}
+}
+
+func TestSQLiteForce(t *testing.T) {
+	ctx := context.Background()
+	m := newSQLiteMigrator(t)
+
+	if err := m.Force(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range statuses {
+		if s.Applied != (i < 3) {
+			t.Errorf("status %06d_%s: applied = %v", s.Version, s.Name, s.Applied)
+		}
+	}
+	if err := m.Force(ctx, 999999); err == nil {
+		t.Error("force to unknown version succeeded")
+	}
+}
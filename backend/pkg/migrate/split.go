@@ -0,0 +1,66 @@
+package migrate
+
+import "strings"
+
+// splitStatements 按分号把脚本拆成单条语句，忽略引号和注释中的分号，去掉只有注释的语句
+func splitStatements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+		// hasCode 当前语句中是否有注释以外的内容
+		hasCode bool
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); hasCode && stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// 引号内的内容原样保留，反斜杠转义下一个字符
+			end := i + 1
+			for end < len(script) && script[end] != c {
+				if script[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(script) {
+				end = len(script) - 1
+			}
+			current.WriteString(script[i : end+1])
+			hasCode = true
+			i = end
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#':
+			// 行注释跳到行尾
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasCode = true
+			}
+		}
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,175 @@
+package mysql
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库类型，对应配置中的 mysql.driver
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialect 屏蔽不同数据库的差异：连接方式，以及统计查询中按日、周、月分组的表达式。
+// 时间范围的计算放在 Go 代码里以参数传入，不依赖 DATE_SUB、CURDATE 这类方言函数
+type Dialect interface {
+	Name() string
+	// DSN 根据配置生成连接串，cfg.DSN 不为空时直接使用
+	DSN(cfg *setting.MysqlConfig) string
+	// Dialector 用连接串生成 gorm 的驱动
+	Dialector(dsn string) gorm.Dialector
+	// Wrap 用已经建立的连接池生成 gorm 的驱动，供读写分离复用主库和从库的连接
+	Wrap(conn gorm.ConnPool) gorm.Dialector
+	// DayKey 把时间列格式化为 2006-01-02
+	DayKey(column string) string
+	// WeekKey 把时间列格式化为 ISO 周，例如 2006-W01，与 time.ISOWeek 一致
+	WeekKey(column string) string
+	// MonthKey 把时间列格式化为 2006-01
+	MonthKey(column string) string
+}
+
+var dialects = map[string]Dialect{
+	DriverMySQL:    mysqlDialect{},
+	DriverPostgres: postgresDialect{},
+	DriverSQLite:   sqliteDialect{},
+}
+
+var current Dialect = mysqlDialect{}
+
+// Current 当前连接的数据库方言
+func Current() Dialect {
+	return current
+}
+
+// LookupDialect 按名称查找方言，name 为空时使用 MySQL
+func LookupDialect(name string) (Dialect, error) {
+	if name == "" {
+		name = DriverMySQL
+	}
+	d, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", name)
+	}
+	return d, nil
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return DriverMySQL }
+
+func (mysqlDialect) DSN(cfg *setting.MysqlConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+	// 参考 https://github.com/go-sql-driver/mysql#dsn-data-source-name 获取详情
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.User, cfg.PassWord, cfg.Host, cfg.Port, cfg.DB)
+}
+
+func (mysqlDialect) Dialector(dsn string) gorm.Dialector {
+	return mysql.Open(dsn)
+}
+
+func (mysqlDialect) Wrap(conn gorm.ConnPool) gorm.Dialector {
+	return mysql.New(mysql.Config{Conn: conn})
+}
+
+func (mysqlDialect) DayKey(column string) string {
+	return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d')", column)
+}
+
+func (mysqlDialect) WeekKey(column string) string {
+	// %x 为 ISO 周所属的年份，%v 为周一开始的 ISO 周数
+	return fmt.Sprintf("DATE_FORMAT(%s, '%%x-W%%v')", column)
+}
+
+func (mysqlDialect) MonthKey(column string) string {
+	return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m')", column)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return DriverPostgres }
+
+func (postgresDialect) DSN(cfg *setting.MysqlConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.PassWord),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:     cfg.DB,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+func (postgresDialect) Dialector(dsn string) gorm.Dialector {
+	return postgres.Open(dsn)
+}
+
+func (postgresDialect) Wrap(conn gorm.ConnPool) gorm.Dialector {
+	return postgres.New(postgres.Config{Conn: conn})
+}
+
+func (postgresDialect) DayKey(column string) string {
+	return fmt.Sprintf("to_char(%s, 'YYYY-MM-DD')", column)
+}
+
+func (postgresDialect) WeekKey(column string) string {
+	return fmt.Sprintf(`to_char(%s, 'IYYY-"W"IW')`, column)
+}
+
+func (postgresDialect) MonthKey(column string) string {
+	return fmt.Sprintf("to_char(%s, 'YYYY-MM')", column)
+}
+
+// sqliteDialect 用于本地开发和测试，时间以带时区偏移的文本保存，
+// 分组前先截掉时区，按写入时的本地时间计算日期
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return DriverSQLite }
+
+// DSN 为数据库文件路径，没有配置 dsn 时使用 <db>.db
+func (sqliteDialect) DSN(cfg *setting.MysqlConfig) string {
+	dsn := cfg.DSN
+	if dsn == "" {
+		dsn = cfg.DB + ".db"
+	}
+	// SQLite 默认不检查外键；并发写入时等待锁而不是立即返回 SQLITE_BUSY。DSN 自带参数时不再追加
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	}
+	return dsn
+}
+
+func (sqliteDialect) Dialector(dsn string) gorm.Dialector {
+	return sqlite.Open(dsn)
+}
+
+func (sqliteDialect) Wrap(conn gorm.ConnPool) gorm.Dialector {
+	return &sqlite.Dialector{Conn: conn}
+}
+
+func (sqliteDialect) DayKey(column string) string {
+	return fmt.Sprintf("substr(%s, 1, 10)", column)
+}
+
+func (sqliteDialect) WeekKey(column string) string {
+	return fmt.Sprintf("strftime('%%G-W%%V', substr(%s, 1, 19))", column)
+}
+
+func (sqliteDialect) MonthKey(column string) string {
+	return fmt.Sprintf("substr(%s, 1, 7)", column)
+}
@@ -0,0 +1,136 @@
+package mysql_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TalkSphere/backend/deploy/sql/migrations"
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/migrate"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/setting"
+)
+
+// openSQLite 在临时目录创建 SQLite 数据库并执行全部迁移
+func openSQLite(t *testing.T) {
+	t.Helper()
+	cfg := &setting.MysqlConfig{Driver: mysql.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")}
+	if err := mysql.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mysql.Close)
+	fsys, err := migrations.For(mysql.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(mysql.DB, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+}
+
+// 统计接口按 Dialect 生成的键分组，键必须与 Go 中按写入时的本地时间计算的结果一致
+func TestSQLiteGrowthKeys(t *testing.T) {
+	openSQLite(t)
+	if got := mysql.Current().Name(); got != mysql.DriverSQLite {
+		t.Fatalf("current dialect = %s, want sqlite", got)
+	}
+
+	cst := time.FixedZone("CST", 8*3600)
+	est := time.FixedZone("EST", -5*3600)
+	times := []time.Time{
+		time.Date(2021, 1, 3, 10, 0, 0, 0, time.UTC), // 属于上一年的第 53 周
+		time.Date(2024, 12, 30, 1, 0, 0, 0, cst),     // 属于下一年的第 1 周，UTC 还是前一天
+		time.Date(2025, 1, 6, 0, 30, 0, 0, cst),      // 本地是周一，UTC 还是上一周的周日
+		time.Date(2023, 6, 15, 22, 0, 0, 0, est),     // UTC 已是第二天
+		time.Date(2023, 6, 15, 9, 0, 0, 0, est),
+	}
+	for i, ts := range times {
+		user := models.User{
+			Username:     fmt.Sprintf("user%d", i),
+			Email:        fmt.Sprintf("user%d@example.com", i),
+			PasswordHash: "x",
+			CreatedAt:    ts,
+			UpdatedAt:    ts,
+		}
+		if err := mysql.DB.Create(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := mysql.Current()
+	tests := []struct {
+		name string
+		key  string
+		want func(time.Time) string
+	}{
+		{"day", d.DayKey("created_at"), func(ts time.Time) string { return ts.Format("2006-01-02") }},
+		{"week", d.WeekKey("created_at"), func(ts time.Time) string {
+			year, week := ts.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{"month", d.MonthKey("created_at"), func(ts time.Time) string { return ts.Format("2006-01") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := map[string]int64{}
+			for _, ts := range times {
+				want[tt.want(ts)]++
+			}
+
+			// 与 controller.fillGrowth 的查询相同
+			var rows []struct {
+				Date  string
+				Count int64
+			}
+			err := mysql.DB.Table("users").
+				Select(tt.key+" as date, COUNT(*) as count").
+				Where("created_at >= ?", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)).
+				Group(tt.key).
+				Find(&rows).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]int64{}
+			for _, r := range rows {
+				got[r.Date] = r.Count
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("groups = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLookupDialect(t *testing.T) {
+	for _, name := range []string{"", mysql.DriverMySQL, mysql.DriverPostgres, mysql.DriverSQLite} {
+		if _, err := mysql.LookupDialect(name); err != nil {
+			t.Errorf("LookupDialect(%q): %v", name, err)
+		}
+	}
+	if _, err := mysql.LookupDialect("oracle"); err == nil {
+		t.Error("LookupDialect(oracle) succeeded")
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	d, _ := mysql.LookupDialect(mysql.DriverSQLite)
+	tests := []struct {
+		cfg  setting.MysqlConfig
+		want string
+	}{
+		{setting.MysqlConfig{DB: "talksphere"}, "talksphere.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"},
+		{setting.MysqlConfig{DSN: "file::memory:?cache=shared"}, "file::memory:?cache=shared"},
+	}
+	for _, tt := range tests {
+		if got := d.DSN(&tt.cfg); got != tt.want {
+			t.Errorf("DSN(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/TalkSphere/backend/setting"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	defaultSlowThreshold = 200 * time.Millisecond
+	defaultLogLevel      = logger.Warn
+)
+
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// logSettings SQL 日志配置，配置文件修改后原地更新，已经建立的连接立即生效
+type logSettings struct {
+	level         atomic.Int32
+	slowThreshold atomic.Int64
+	// sampleRate 为 float64 的位表示
+	sampleRate atomic.Uint64
+	// skipped 上一条慢查询日志之后因采样没有记录的慢查询数
+	skipped atomic.Int64
+}
+
+func (s *logSettings) apply(cfg *setting.MysqlConfig) {
+	level, ok := logLevels[strings.ToLower(cfg.LogLevel)]
+	if !ok {
+		if cfg.LogLevel != "" {
+			zap.L().Warn("未知的 SQL 日志级别，使用 warn", zap.String("log_level", cfg.LogLevel))
+		}
+		level = defaultLogLevel
+	}
+	threshold := time.Duration(cfg.SlowThreshold) * time.Millisecond
+	if threshold <= 0 {
+		threshold = defaultSlowThreshold
+	}
+	rate := cfg.SlowLogSampleRate
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+	s.level.Store(int32(level))
+	s.slowThreshold.Store(int64(threshold))
+	s.sampleRate.Store(math.Float64bits(rate))
+}
+
+// gormLogger 把 GORM 的日志写到 zap：出错的 SQL 记为 Error，慢查询按采样比例记为 Warn，
+// info 级别下全部 SQL 记为 Info
+type gormLogger struct {
+	settings *logSettings
+	// level 通过 LogMode 指定时（例如 db.Debug()）覆盖配置中的级别
+	level *logger.LogLevel
+}
+
+func newGormLogger(cfg *setting.MysqlConfig) *gormLogger {
+	l := &gormLogger{settings: &logSettings{}}
+	l.settings.apply(cfg)
+	return l
+}
+
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &gormLogger{settings: l.settings, level: &level}
+}
+
+func (l *gormLogger) logLevel() logger.LogLevel {
+	if l.level != nil {
+		return *l.level
+	}
+	return logger.LogLevel(l.settings.level.Load())
+}
+
+func (l *gormLogger) Info(_ context.Context, msg string, args ...interface{}) {
+	if l.logLevel() >= logger.Info {
+		zap.L().Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Warn(_ context.Context, msg string, args ...interface{}) {
+	if l.logLevel() >= logger.Warn {
+		zap.L().Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Error(_ context.Context, msg string, args ...interface{}) {
+	if l.logLevel() >= logger.Error {
+		zap.L().Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	level := l.logLevel()
+	if level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	threshold := time.Duration(l.settings.slowThreshold.Load())
+	switch {
+	case err != nil && level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		zap.L().Error("SQL 执行失败", zap.String("sql", sql), zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed), zap.Error(err))
+	case elapsed > threshold && level >= logger.Warn:
+		// 数据库变慢时慢查询会大量出现，按比例采样，记录下来的日志带上期间跳过的条数
+		if rate := math.Float64frombits(l.settings.sampleRate.Load()); rate < 1 && rand.Float64() >= rate {
+			l.settings.skipped.Add(1)
+			return
+		}
+		sql, rows := fc()
+		zap.L().Warn("慢查询", zap.String("sql", sql), zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed), zap.Duration("threshold", threshold),
+			zap.Int64("skipped", l.settings.skipped.Swap(0)))
+	case level >= logger.Info:
+		sql, rows := fc()
+		zap.L().Info("执行 SQL", zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed))
+	}
+}
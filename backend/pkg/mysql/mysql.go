@@ -1,51 +1,32 @@
 package mysql
 
 import (
-	"fmt"
+	"database/sql"
 	"github.com/TalkSphere/backend/setting"
 	"time"
 
 	"go.uber.org/zap"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 var DB *gorm.DB
 
-type MyWriter struct {
-	mLog *zap.Logger
-}
-
-func (m *MyWriter) Printf(format string, v ...interface{}) {
-	logStr := fmt.Sprintf(format, v...)
-	//利用zap记录日志
-	m.mLog.Info(logStr)
-}
-
-func NewMyWriter() *MyWriter {
-	logg := zap.L()
-	return &MyWriter{mLog: logg}
-}
+// sqlLogger GORM 的日志，配置修改后由 Reload 更新
+var sqlLogger *gormLogger
 
+// Init 按 cfg.Driver 连接 MySQL、PostgreSQL 或 SQLite
 func Init(cfg *setting.MysqlConfig) (err error) {
-	// 参考 https://github.com/go-sql-driver/mysql#dsn-data-source-name 获取详情
-	//"user:pass@tcp(127.0.0.1:3306)/dbname?charset=utf8mb4&parseTime=True&loc=Local"
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User, cfg.PassWord, cfg.Host, cfg.Port, cfg.DB)
-	fmt.Println("dns:", dsn)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.New(
-			//设置Logger
-			NewMyWriter(),
-			logger.Config{
-				//慢SQL阈值
-				SlowThreshold: time.Millisecond,
-				//设置日志级别，打印sql
-				LogLevel: logger.Info,
-			},
-		),
+	dialect, err := LookupDialect(cfg.Driver)
+	if err != nil {
+		return err
+	}
+	sqlLogger = newGormLogger(cfg)
+	db, err := gorm.Open(dialect.Dialector(dialect.DSN(cfg)), &gorm.Config{
+		Logger: sqlLogger,
+		// 把各驱动的唯一索引冲突等错误转换为 gorm.ErrDuplicatedKey 等统一的错误
+		TranslateError: true,
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true, // 默认不加复数
 		}})
@@ -53,17 +34,77 @@ func Init(cfg *setting.MysqlConfig) (err error) {
 		zap.L().Fatal("Connect DB failed ", zap.Error(err))
 	}
 	sqlDB, _ := db.DB()
+	setPool(sqlDB, cfg)
+	current = dialect
+	if err := initReplicas(db, dialect, cfg); err != nil {
+		return err
+	}
+	DB = db
+	if replicas != nil {
+		// 登录、会话、权限等直接使用 DB 的代码对延迟敏感，始终读写主库
+		DB = db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+	}
+	return nil
+}
+
+// Reload 按修改后的配置更新主库和从库的连接池以及 SQL 日志。
+// 数据库类型、连接串和从库列表需要重启后生效
+func Reload(cfg *setting.MysqlConfig) {
+	if DB == nil || cfg == nil {
+		return
+	}
+	sqlLogger.settings.apply(cfg)
+	if sqlDB, err := DB.DB(); err == nil {
+		setPool(sqlDB, cfg)
+	}
+	if replicas != nil {
+		for _, r := range replicas.replicas {
+			setPool(r.db, cfg)
+		}
+	}
+	zap.L().Info("已重新加载数据库配置",
+		zap.Int("max_open_connection", cfg.MaxOpenConnection),
+		zap.Int("max_idle_connection", cfg.MaxIdleConnection),
+		zap.String("log_level", cfg.LogLevel),
+		zap.Int64("slow_threshold", cfg.SlowThreshold))
+}
+
+const (
+	defaultMaxOpenConns    = 200
+	defaultMaxIdleConns    = 20
+	defaultConnMaxLifetime = time.Hour
+)
+
+func setPool(sqlDB *sql.DB, cfg *setting.MysqlConfig) {
+	maxOpen, maxIdle := cfg.MaxOpenConnection, cfg.MaxIdleConnection
+	if maxOpen <= 0 {
+		maxOpen = defaultMaxOpenConns
+	}
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	// 空闲连接数超过最大连接数没有意义，database/sql 也会把它截断
+	if maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	lifetime := time.Duration(cfg.ConnMaxLifetime) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultConnMaxLifetime
+	}
 	// SetMaxIdleConns 设置空闲连接池中连接的最大数量
-	sqlDB.SetMaxIdleConns(20)
+	sqlDB.SetMaxIdleConns(maxIdle)
 	// SetMaxOpenConns 设置打开数据库连接的最大数量。
-	sqlDB.SetMaxOpenConns(200)
+	sqlDB.SetMaxOpenConns(maxOpen)
 	// SetConnMaxLifetime 设置了连接可复用的最大时间。
-	sqlDB.SetConnMaxLifetime(time.Hour)
-	DB = db
-	return nil
+	sqlDB.SetConnMaxLifetime(lifetime)
+	// SetConnMaxIdleTime 设置了连接空闲多久后关闭，0 表示不限制
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime) * time.Second)
 }
 
 func Close() {
+	if replicas != nil {
+		replicas.close()
+	}
 	db, _ := DB.DB()
 	db.Close()
 	return
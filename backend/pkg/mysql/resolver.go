@@ -0,0 +1,224 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TalkSphere/backend/setting"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// Routed 读写分离的连接：查询走健康的从库，写入和事务走主库，没有配置从库时与 DB 相同。
+// 上下文被 WithPrimary 固定到主库后，查询也走主库
+var Routed *gorm.DB
+
+// Replica 只读查询使用的连接。WITH 开头的原生查询 GORM 无法判断是否只读，默认会发到主库，
+// 统计分析这类查询需要通过这里显式读从库。不能用来写入
+func Replica(ctx context.Context) *gorm.DB {
+	return Routed.WithContext(ctx).Clauses(dbresolver.Read)
+}
+
+const (
+	defaultReplicaCheckInterval = 10 * time.Second
+	replicaPingTimeout          = 3 * time.Second
+)
+
+// replica 一个从库连接池及其健康状态
+type replica struct {
+	index   int
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet 实现 dbresolver.Policy：在健康的从库中随机选择，全部不可用时回退到主库
+type replicaSet struct {
+	primary  *sql.DB
+	replicas []*replica
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+var replicas *replicaSet
+
+func (s *replicaSet) Resolve([]gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]*replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return s.primary
+	}
+	return healthy[rand.Intn(len(healthy))].db
+}
+
+// initReplicas 连接配置的从库并注册读写分离。启动时连不上的从库记录错误后跳过，不影响服务启动
+func initReplicas(db *gorm.DB, dialect Dialect, cfg *setting.MysqlConfig) error {
+	Routed = db
+	if len(cfg.Replicas) == 0 {
+		return nil
+	}
+
+	primary, err := db.DB()
+	if err != nil {
+		return err
+	}
+	set := &replicaSet{primary: primary, stop: make(chan struct{})}
+	// 主库也作为候选传给 dbresolver：只有一个候选时 dbresolver 不调用 Policy，无法回退
+	dialectors := []gorm.Dialector{dialect.Wrap(primary)}
+	for i, dsn := range cfg.Replicas {
+		rdb, err := gorm.Open(dialect.Dialector(dsn), &gorm.Config{Logger: db.Logger})
+		if err != nil {
+			zap.L().Error("连接从库失败", zap.Int("replica", i), zap.Error(err))
+			continue
+		}
+		sqlDB, _ := rdb.DB()
+		setPool(sqlDB, cfg)
+		r := &replica{index: i, db: sqlDB}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+		dialectors = append(dialectors, dialect.Wrap(sqlDB))
+	}
+	if len(set.replicas) == 0 {
+		zap.L().Warn("没有可用的从库，查询全部读主库")
+		return nil
+	}
+
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   set,
+	}))
+	if err != nil {
+		set.close()
+		return err
+	}
+	if err := registerPinCallbacks(db); err != nil {
+		set.close()
+		return err
+	}
+
+	interval := time.Duration(cfg.ReplicaCheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+	set.wg.Add(1)
+	go set.check(interval)
+
+	replicas = set
+	zap.L().Info("已启用读写分离", zap.Int("count", len(set.replicas)))
+	return nil
+}
+
+// check 定期 ping 每个从库，状态变化时记录日志
+func (s *replicaSet) check(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+		for _, r := range s.replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+			err := r.db.PingContext(ctx)
+			cancel()
+			healthy := err == nil
+			if r.healthy.Swap(healthy) == healthy {
+				continue
+			}
+			if healthy {
+				zap.L().Info("从库已恢复", zap.Int("replica", r.index))
+			} else {
+				zap.L().Warn("从库不可用，查询改为读其他从库或主库",
+					zap.Int("replica", r.index), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *replicaSet) close() {
+	close(s.stop)
+	s.wg.Wait()
+	for _, r := range s.replicas {
+		r.db.Close()
+	}
+}
+
+// primaryPin 记录一个上下文中的查询是否需要读主库
+type primaryPin struct {
+	on atomic.Bool
+}
+
+type primaryPinKey struct{}
+
+// WithPrimary 让使用返回的上下文的查询都读主库，用于写入后马上读取自己写入的数据，
+// 避免从库延迟导致读到旧数据。ctx 已经由 WithReadYourWrites 创建时直接打开其中的标记
+func WithPrimary(ctx context.Context) context.Context {
+	if pin, ok := ctx.Value(primaryPinKey{}).(*primaryPin); ok {
+		pin.on.Store(true)
+		return ctx
+	}
+	pin := &primaryPin{}
+	pin.on.Store(true)
+	return context.WithValue(ctx, primaryPinKey{}, pin)
+}
+
+// WithReadYourWrites 返回的上下文第一次写入之后，后续查询都读主库
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(primaryPinKey{}).(*primaryPin); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, primaryPinKey{}, &primaryPin{})
+}
+
+func pinOf(db *gorm.DB) *primaryPin {
+	if db.Statement.Context == nil {
+		return nil
+	}
+	pin, _ := db.Statement.Context.Value(primaryPinKey{}).(*primaryPin)
+	return pin
+}
+
+// registerPinCallbacks 注册检查上下文的回调：写入时打开标记，查询时在 dbresolver 选择连接之前按标记切到主库
+func registerPinCallbacks(db *gorm.DB) error {
+	markWritten := func(db *gorm.DB) {
+		if pin := pinOf(db); pin != nil {
+			pin.on.Store(true)
+		}
+	}
+	usePrimary := func(db *gorm.DB) {
+		if pin := pinOf(db); pin != nil && pin.on.Load() {
+			dbresolver.Write.ModifyStatement(db.Statement)
+		}
+	}
+
+	// 查询的回调要排在 dbresolver 之前。两者都注册为 Before("*") 时，后注册的排在前面
+	cb := db.Callback()
+	const name = "talksphere:primary_pin"
+	if err := cb.Create().Register(name, markWritten); err != nil {
+		return err
+	}
+	if err := cb.Update().Register(name, markWritten); err != nil {
+		return err
+	}
+	if err := cb.Delete().Register(name, markWritten); err != nil {
+		return err
+	}
+	if err := cb.Raw().Register(name, markWritten); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register(name, usePrimary); err != nil {
+		return err
+	}
+	return cb.Row().Before("*").Register(name, usePrimary)
+}
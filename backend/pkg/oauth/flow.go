@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/TalkSphere/backend/pkg/encrypt"
+
+	"golang.org/x/oauth2"
+)
+
+// FlowExpire 从跳转到授权页面到回调的最长时间
+const FlowExpire = 10 * time.Minute
+
+var ErrInvalidState = errors.New("invalid oauth state")
+
+// Flow 一次授权流程的状态，加密后保存在浏览器 cookie 中，回调时与 state 参数比对
+// 这样 state、PKCE verifier 和 nonce 都绑定在发起登录的浏览器上，不需要服务端存储
+type Flow struct {
+	Provider   string `json:"p"`
+	State      string `json:"s"`
+	Verifier   string `json:"v"`
+	Nonce      string `json:"n"`
+	LinkUserID int64  `json:"l,omitempty"` // 非 0 表示为已登录用户绑定第三方账号
+	ExpiresAt  int64  `json:"e"`
+}
+
+// NewFlow 开始新的授权流程
+func NewFlow(provider string, linkUserID int64) (*Flow, error) {
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	return &Flow{
+		Provider:   provider,
+		State:      state,
+		Verifier:   oauth2.GenerateVerifier(),
+		Nonce:      nonce,
+		LinkUserID: linkUserID,
+		ExpiresAt:  time.Now().Add(FlowExpire).Unix(),
+	}, nil
+}
+
+// Seal 加密流程状态
+func (f *Flow) Seal() (string, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return encrypt.Seal(string(b))
+}
+
+// OpenFlow 解密 cookie 中的流程状态，并校验提供方、state 和有效期
+func OpenFlow(sealed, provider, state string) (*Flow, error) {
+	plain, err := encrypt.Open(sealed)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+	var f Flow
+	if err := json.Unmarshal([]byte(plain), &f); err != nil {
+		return nil, ErrInvalidState
+	}
+	if f.Provider != provider || f.State == "" || f.State != state || time.Now().Unix() > f.ExpiresAt {
+		return nil, ErrInvalidState
+	}
+	return &f, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
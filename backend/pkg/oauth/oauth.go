@@ -0,0 +1,284 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+	ErrMissingSubject  = errors.New("provider did not return a subject")
+)
+
+// Identity 第三方账号信息
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+	AvatarURL     string
+}
+
+// ProviderInfo 提供给前端展示的登录方式
+type ProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// Provider 一个 OAuth2 / OIDC 身份提供方
+// 配置了 issuer 的视为 OIDC 提供方，首次使用时通过 discovery 获取端点并校验 id_token；
+// 否则按普通 OAuth2 处理，用 access token 请求 userinfo_url 获取用户信息
+type Provider struct {
+	cfg setting.OAuthProvider
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+var providers = map[string]*Provider{}
+
+// Init 加载第三方登录配置
+func Init(cfg *setting.OAuthConfig) error {
+	ps := make(map[string]*Provider)
+	if cfg == nil {
+		providers = ps
+		return nil
+	}
+	for _, pc := range cfg.Providers {
+		if pc.Name == "" || pc.ClientID == "" || pc.RedirectURL == "" {
+			return fmt.Errorf("oauth provider %q: name, client_id and redirect_url are required", pc.Name)
+		}
+		if pc.Issuer == "" && (pc.AuthURL == "" || pc.TokenURL == "" || pc.UserInfoURL == "") {
+			return fmt.Errorf("oauth provider %q: issuer or auth_url/token_url/userinfo_url is required", pc.Name)
+		}
+		if _, ok := ps[pc.Name]; ok {
+			return fmt.Errorf("duplicate oauth provider %q", pc.Name)
+		}
+		ps[pc.Name] = &Provider{cfg: pc}
+	}
+	providers = ps
+	return nil
+}
+
+// Get 按名称获取提供方
+func Get(name string) (*Provider, error) {
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Providers 所有已配置的提供方
+func Providers() []ProviderInfo {
+	list := make([]ProviderInfo, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, ProviderInfo{Name: p.cfg.Name, DisplayName: p.DisplayName()})
+	}
+	return list
+}
+
+// Name 提供方名称
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// DisplayName 展示名称，未配置时使用提供方名称
+func (p *Provider) DisplayName() string {
+	if p.cfg.DisplayName != "" {
+		return p.cfg.DisplayName
+	}
+	return p.cfg.Name
+}
+
+// TrustEmail 是否信任该提供方返回的已验证邮箱，信任时会自动关联同邮箱的本地账号
+func (p *Provider) TrustEmail() bool {
+	return p.cfg.TrustEmail
+}
+
+// AuthCodeURL 生成跳转到提供方授权页面的地址（授权码模式 + PKCE）
+func (p *Provider) AuthCodeURL(ctx context.Context, f *Flow) (string, error) {
+	conf, err := p.config(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(f.Verifier)}
+	if p.cfg.Issuer != "" {
+		opts = append(opts, oidc.Nonce(f.Nonce))
+	}
+	return conf.AuthCodeURL(f.State, opts...), nil
+}
+
+// Exchange 用授权码换取令牌并获取第三方账号信息
+func (p *Provider) Exchange(ctx context.Context, f *Flow, code string) (*Identity, error) {
+	conf, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(f.Verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	id := &Identity{Provider: p.cfg.Name}
+	if p.verifier != nil {
+		if err := p.fromIDToken(ctx, token, f.Nonce, id); err != nil {
+			return nil, err
+		}
+	}
+	if p.userInfoURL() != "" {
+		if err := p.fromUserInfo(ctx, conf.TokenSource(ctx, token), id); err != nil {
+			return nil, err
+		}
+	}
+	if id.Subject == "" {
+		return nil, ErrMissingSubject
+	}
+	return id, nil
+}
+
+// config 懒加载 OAuth2 配置，OIDC 提供方在第一次使用时才做 discovery，避免启动时依赖外部服务
+func (p *Provider) config(ctx context.Context) (*oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth2 != nil {
+		return p.oauth2, nil
+	}
+
+	conf := &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.cfg.AuthURL,
+			TokenURL: p.cfg.TokenURL,
+		},
+	}
+	if p.cfg.Issuer != "" {
+		op, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("oidc discovery for %q: %w", p.cfg.Name, err)
+		}
+		conf.Endpoint = op.Endpoint()
+		if len(conf.Scopes) == 0 {
+			conf.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+		}
+		if p.cfg.UserInfoURL == "" {
+			var meta struct {
+				UserInfoURL string `json:"userinfo_endpoint"`
+			}
+			if err := op.Claims(&meta); err == nil {
+				p.cfg.UserInfoURL = meta.UserInfoURL
+			}
+		}
+		p.verifier = op.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	}
+	p.oauth2 = conf
+	return conf, nil
+}
+
+func (p *Provider) userInfoURL() string {
+	return p.cfg.UserInfoURL
+}
+
+func (p *Provider) fromIDToken(ctx context.Context, token *oauth2.Token, nonce string, id *Identity) error {
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return ErrInvalidIDToken
+	}
+	idToken, err := p.verifier.Verify(ctx, raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if idToken.Nonce != nonce {
+		return fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return err
+	}
+	p.applyClaims(claims, id)
+	// 以 id_token 的 sub 为准，userinfo 不能覆盖
+	id.Subject = idToken.Subject
+	return nil
+}
+
+func (p *Provider) fromUserInfo(ctx context.Context, ts oauth2.TokenSource, id *Identity) error {
+	client := oauth2.NewClient(ctx, ts)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("userinfo request failed: %s", resp.Status)
+	}
+	claims := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return err
+	}
+
+	subject := id.Subject
+	p.applyClaims(claims, id)
+	if subject != "" {
+		// OIDC 规范要求 userinfo 的 sub 与 id_token 一致
+		if id.Subject != subject {
+			return fmt.Errorf("%w: userinfo subject mismatch", ErrInvalidIDToken)
+		}
+	}
+	return nil
+}
+
+// applyClaims 按字段映射读取用户信息，已有的值不会被空值覆盖
+func (p *Provider) applyClaims(claims map[string]interface{}, id *Identity) {
+	set := func(dst *string, field, fallback string) {
+		if field == "" {
+			field = fallback
+		}
+		if v := claimString(claims, field); v != "" {
+			*dst = v
+		}
+	}
+	set(&id.Subject, p.cfg.SubjectField, "sub")
+	set(&id.Email, p.cfg.EmailField, "email")
+	set(&id.Username, p.cfg.UsernameField, "preferred_username")
+	set(&id.Name, p.cfg.NameField, "name")
+	set(&id.AvatarURL, p.cfg.AvatarField, "picture")
+	if v, ok := claims["email_verified"].(bool); ok {
+		id.EmailVerified = v
+	}
+	id.Email = strings.TrimSpace(id.Email)
+}
+
+func claimString(claims map[string]interface{}, field string) string {
+	switch v := claims[field].(type) {
+	case string:
+		return v
+	case float64:
+		// GitHub 等提供方的用户 ID 是数字
+		return fmt.Sprintf("%.0f", v)
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+package pat
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Prefix 访问令牌的固定前缀，用于和 JWT 区分，也方便密钥扫描工具识别
+const Prefix = "tsp_"
+
+// MaxTokensPerUser 每个用户最多持有的有效令牌数量
+const MaxTokensPerUser = 50
+
+// lastUsedInterval 最近使用时间的更新间隔，避免每个请求都写库
+const lastUsedInterval = time.Minute
+
+var (
+	ErrInvalidToken  = errors.New("invalid personal access token")
+	ErrTokenExpired  = errors.New("personal access token expired")
+	ErrUserDisabled  = errors.New("token owner is disabled")
+	ErrInvalidScope  = errors.New("invalid scope")
+	ErrTooManyTokens = errors.New("too many personal access tokens")
+)
+
+// IsToken 判断 Bearer 凭证是否为访问令牌
+func IsToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// Create 创建访问令牌，明文只在创建时返回一次
+// 令牌格式为 tsp_<id>_<secret>，库中只保存 secret 的摘要
+// ttl 为 0 表示永不过期
+func Create(userID int64, name string, scopes []string, ttl time.Duration) (string, *models.PersonalAccessToken, error) {
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	seen := make(map[string]bool, len(scopes))
+	list := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if !ValidScope(s) {
+			return "", nil, ErrInvalidScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			list = append(list, s)
+		}
+	}
+
+	var count int64
+	if err := mysql.DB.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error; err != nil {
+		return "", nil, err
+	}
+	if count >= MaxTokensPerUser {
+		return "", nil, ErrTooManyTokens
+	}
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	secret := hex.EncodeToString(b)
+	t := &models.PersonalAccessToken{
+		ID:        snowflake.GenID(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    Prefix + secret[:8],
+		TokenHash: hashSecret(secret),
+		Scopes:    strings.Join(list, " "),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		t.ExpiresAt = &expiresAt
+	}
+	if err := mysql.DB.Create(t).Error; err != nil {
+		return "", nil, err
+	}
+	return Prefix + strconv.FormatInt(t.ID, 10) + "_" + secret, t, nil
+}
+
+// Authenticate 校验访问令牌，返回令牌和所有者，并记录最近使用时间和 IP
+func Authenticate(token, ip string) (*models.PersonalAccessToken, *models.User, error) {
+	id, secret, err := parse(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	var t models.PersonalAccessToken
+	if err := mysql.DB.First(&t, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(t.TokenHash)) != 1 || t.RevokedAt != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	now := time.Now()
+	if t.ExpiresAt != nil && now.After(*t.ExpiresAt) {
+		return nil, nil, ErrTokenExpired
+	}
+
+	var user models.User
+	if err := mysql.DB.First(&user, t.UserID).Error; err != nil {
+		return nil, nil, err
+	}
+	if user.Status != 1 {
+		return nil, nil, ErrUserDisabled
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > lastUsedInterval || t.LastUsedIP != ip {
+		if err := mysql.DB.Model(&t).Updates(map[string]interface{}{
+			"last_used_at": &now,
+			"last_used_ip": ip,
+		}).Error; err != nil {
+			zap.L().Error("更新访问令牌使用时间失败", zap.Int64("token_id", t.ID), zap.Error(err))
+		}
+	}
+	return &t, &user, nil
+}
+
+// List 用户的所有未吊销的令牌，包括已过期的
+func List(userID int64) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := mysql.DB.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke 吊销用户的某个令牌
+func Revoke(userID, tokenID int64) error {
+	now := time.Now()
+	res := mysql.DB.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", &now)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func parse(token string) (int64, string, error) {
+	rest := strings.TrimPrefix(token, Prefix)
+	idStr, secret, ok := strings.Cut(rest, "_")
+	if !ok || secret == "" {
+		return 0, "", ErrInvalidToken
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+	return id, secret, nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package pat
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/casbin/casbin/v2/util"
+)
+
+// Scope 访问令牌可申请的权限范围
+type Scope struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// resource 一类接口，read 对应 GET，write 对应其余方法
+type resource struct {
+	name        string
+	description string
+	paths       []string // keyMatch2 格式
+}
+
+// resources 访问令牌能访问的接口，未列出的接口（令牌管理、改密、两步验证、第三方账号绑定等）一律拒绝
+// 令牌只是在所有者自身权限的基础上再做收窄，最终是否放行还要经过 Casbin 检查
+var resources = []resource{
+	{"posts", "帖子", []string{"/api/posts", "/api/posts/*"}},
+	{"comments", "评论", []string{"/api/comments", "/api/comments/*"}},
+	{"boards", "板块", []string{"/api/boards", "/api/boards/*"}},
+	{"likes", "点赞", []string{"/api/likes", "/api/likes/*"}},
+	{"favorites", "收藏", []string{"/api/favorites", "/api/favorites/*"}},
+	{"profile", "个人资料", []string{"/api/profile", "/api/bio", "/api/avatar"}},
+	{"users", "用户管理", []string{"/api/users", "/api/users/*"}},
+	{"analysis", "数据分析", []string{"/api/analysis/*", "/api/admin/stats"}},
+	{"permissions", "权限管理", []string{"/api/permission/*"}},
+}
+
+// Scopes 所有可用的 scope
+func Scopes() []Scope {
+	list := make([]Scope, 0, len(resources)*2)
+	for _, r := range resources {
+		list = append(list,
+			Scope{Name: r.name + ":read", Description: "读取" + r.description},
+			Scope{Name: r.name + ":write", Description: "修改" + r.description},
+		)
+	}
+	return list
+}
+
+// ValidScope scope 是否存在
+func ValidScope(scope string) bool {
+	name, action, ok := strings.Cut(scope, ":")
+	if !ok || (action != "read" && action != "write") {
+		return false
+	}
+	for _, r := range resources {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed 令牌的 scope 是否覆盖本次请求
+func Allowed(scopes []string, path, method string) bool {
+	action := "write"
+	if method == http.MethodGet || method == http.MethodHead {
+		action = "read"
+	}
+	for _, r := range resources {
+		if !r.match(path) {
+			continue
+		}
+		want := r.name + ":" + action
+		for _, s := range scopes {
+			if s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r resource) match(path string) bool {
+	for _, p := range r.paths {
+		if util.KeyMatch2(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// 资源类型
+const (
+	ResourcePost    = "post"
+	ResourceComment = "comment"
+)
+
+// 资源级操作
+const (
+	ActUpdate = "update"
+	ActDelete = "delete"
+	ActLock   = "lock" // 锁定或解锁帖子
+	ActPin    = "pin"  // 置顶或取消置顶
+	ActMove   = "move" // 移动到其他板块
+)
+
+// 资源级检查使用模型中的 r2/p2/e2/m2
+var abacContext = casbin.NewEnforceContext("2")
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrUnknownResource  = errors.New("unknown resource type")
+)
+
+// Subject 资源级检查中的操作者，ID 为用户ID或 AnonymousSubject
+type Subject struct {
+	ID string
+}
+
+// Resource 资源级检查中的资源，p2 规则可以引用其中的属性，例如 r2.obj.OwnerID == r2.sub.ID
+type Resource struct {
+	Type    string
+	ID      string
+	OwnerID string // 作者，没有作者时为空
+	BoardID string // 所属板块，不属于板块时为空
+	// Object 加载出的原始数据，调用方可以直接使用，避免重复查询
+	Object interface{}
+}
+
+// ResourceLoader 按ID加载资源及其属性，资源不存在时返回 ErrResourceNotFound
+type ResourceLoader func(id string) (*Resource, error)
+
+var (
+	loadersMu sync.RWMutex
+	loaders   = map[string]ResourceLoader{}
+)
+
+// RegisterResourceLoader 注册资源类型的加载函数
+func RegisterResourceLoader(rtype string, fn ResourceLoader) {
+	loadersMu.Lock()
+	defer loadersMu.Unlock()
+	loaders[rtype] = fn
+}
+
+// LoadResource 加载资源
+func LoadResource(rtype, id string) (*Resource, error) {
+	loadersMu.RLock()
+	fn, ok := loaders[rtype]
+	loadersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownResource, rtype)
+	}
+	res, err := fn(id)
+	if err != nil {
+		return nil, err
+	}
+	res.Type = rtype
+	res.ID = id
+	return res, nil
+}
+
+// Authorize 按 p2 规则判断 sub 能否对资源执行 act
+func Authorize(sub Subject, res *Resource, act string) (bool, error) {
+	return Enforcer.Enforce(abacContext, sub, *res, act)
+}
@@ -0,0 +1,141 @@
+package rbac
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/casbin/casbin/v2/util"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 授权来源
+const (
+	GrantSuperAdmin = "super_admin" // 超级管理员直接放行
+	GrantUser       = "user"        // 直接分配给用户的策略
+	GrantRole       = "role"        // 角色的策略
+)
+
+// PolicyMatch 与请求匹配的一条策略，以及 obj、act 是按哪种方式匹配上的
+type PolicyMatch struct {
+	Subject  string `json:"subject"`
+	Obj      string `json:"obj"`
+	Act      string `json:"act"`
+	Source   string `json:"source"`    // user 或 role
+	ObjMatch string `json:"obj_match"` // exact、keyMatch2 或 keyMatch2(reverse)
+	ActMatch string `json:"act_match"` // exact、wildcard 或 regexMatch
+}
+
+// Trace 一次路径级权限判断的完整过程
+type Trace struct {
+	UserID      string        `json:"user_id"`
+	Path        string        `json:"path"`
+	Method      string        `json:"method"`
+	DirectRoles []string      `json:"direct_roles"`
+	Roles       []string      `json:"roles"`        // 含继承得到的角色
+	DefaultRole bool          `json:"default_role"` // 没有直接分配角色，按访客角色处理
+	Matches     []PolicyMatch `json:"matches"`
+	Allowed     bool          `json:"allowed"`
+	GrantedBy   string        `json:"granted_by,omitempty"` // super_admin、user 或 role
+	Grant       *PolicyMatch  `json:"grant,omitempty"`      // 最终放行的策略，按 CheckUserPermission 的检查顺序取第一条
+}
+
+// Explain 按 CheckUserPermission 的逻辑解释 userID 能否以 act 访问 obj，
+// 列出解析出的角色和所有匹配的策略
+func Explain(userID, obj, act string) (*Trace, error) {
+	act = strings.ToUpper(act)
+	t := &Trace{UserID: userID, Path: obj, Method: act, Matches: []PolicyMatch{}}
+
+	direct, err := GetDirectRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	t.DirectRoles = append([]string{}, direct...)
+	t.Roles = roles
+	t.DefaultRole = len(direct) == 0 && len(roles) > 0
+
+	// 检查顺序与 CheckUserPermission 一致：用户自身的策略，然后是各个角色的策略
+	subjects := []struct{ sub, source string }{{userID, GrantUser}}
+	for _, role := range roles {
+		subjects = append(subjects, struct{ sub, source string }{role, GrantRole})
+	}
+	for _, s := range subjects {
+		policies, err := Enforcer.GetFilteredPolicy(0, s.sub)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range policies {
+			if len(p) < 3 {
+				continue
+			}
+			m, ok := MatchPolicy(p[1], p[2], obj, act)
+			if !ok {
+				continue
+			}
+			m.Subject, m.Source = s.sub, s.source
+			t.Matches = append(t.Matches, m)
+		}
+	}
+
+	switch {
+	case HasRole(roles, SuperAdminRole):
+		t.Allowed, t.GrantedBy = true, GrantSuperAdmin
+	case len(t.Matches) > 0:
+		t.Allowed, t.GrantedBy = true, t.Matches[0].Source
+		t.Grant = &t.Matches[0]
+	}
+	return t, nil
+}
+
+// MatchPolicy 判断策略 (pObj, pAct) 能否匹配请求 (obj, act)，规则与模型中 m 的 obj、act 部分相同
+func MatchPolicy(pObj, pAct, obj, act string) (PolicyMatch, bool) {
+	m := PolicyMatch{Obj: pObj, Act: pAct}
+	switch {
+	case pObj == obj:
+		m.ObjMatch = "exact"
+	case util.KeyMatch2(obj, pObj):
+		m.ObjMatch = "keyMatch2"
+	case util.KeyMatch2(pObj, obj):
+		m.ObjMatch = "keyMatch2(reverse)"
+	default:
+		return m, false
+	}
+	switch {
+	case pAct == act:
+		m.ActMatch = "exact"
+	case pAct == "*":
+		m.ActMatch = "wildcard"
+	default:
+		re, err := regexp.Compile(pAct)
+		if err != nil || !re.MatchString(act) {
+			return m, false
+		}
+		m.ActMatch = "regexMatch"
+	}
+	return m, true
+}
+
+// LogDeniedTrace 在 debug 日志中记录被拒绝请求的判断过程，未开启 debug 级别时不做任何事
+func LogDeniedTrace(userID, obj, act string) {
+	if !zap.L().Core().Enabled(zapcore.DebugLevel) {
+		return
+	}
+	t, err := Explain(userID, obj, act)
+	if err != nil {
+		zap.L().Debug("生成权限检查过程失败", zap.String("userID", userID), zap.Error(err))
+		return
+	}
+	zap.L().Debug("权限检查未通过的过程",
+		zap.String("userID", userID),
+		zap.String("path", obj),
+		zap.String("method", t.Method),
+		zap.Strings("direct_roles", t.DirectRoles),
+		zap.Strings("roles", t.Roles),
+		zap.Bool("default_role", t.DefaultRole),
+		zap.Any("matches", t.Matches),
+		zap.Bool("allowed", t.Allowed))
+}
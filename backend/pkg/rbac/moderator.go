@@ -0,0 +1,51 @@
+package rbac
+
+import "sort"
+
+// ModeratorRole 版主角色，通过 g2（用户, 角色, 板块ID）按板块分配，
+// p2 中 moderator 的规则只对其所在板块内的帖子和评论生效
+const ModeratorRole = "moderator"
+
+// AddBoardModerator 任命版主，已经是版主时返回 false
+func AddBoardModerator(userID, boardID string) (bool, error) {
+	return Enforcer.AddNamedGroupingPolicy("g2", userID, ModeratorRole, boardID)
+}
+
+// RemoveBoardModerator 移除版主，不是版主时返回 false
+func RemoveBoardModerator(userID, boardID string) (bool, error) {
+	return Enforcer.RemoveNamedGroupingPolicy("g2", userID, ModeratorRole, boardID)
+}
+
+// RemoveBoardModerators 移除板块的全部版主，删除板块时调用
+func RemoveBoardModerators(boardID string) error {
+	_, err := Enforcer.RemoveFilteredNamedGroupingPolicy("g2", 1, ModeratorRole, boardID)
+	return err
+}
+
+// GetBoardModerators 获取板块的版主ID
+func GetBoardModerators(boardID string) ([]string, error) {
+	rules, err := Enforcer.GetFilteredNamedGroupingPolicy("g2", 1, ModeratorRole, boardID)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]string, 0, len(rules))
+	for _, r := range rules {
+		users = append(users, r[0])
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+// GetModeratedBoards 获取用户担任版主的板块ID
+func GetModeratedBoards(userID string) ([]string, error) {
+	rules, err := Enforcer.GetFilteredNamedGroupingPolicy("g2", 0, userID, ModeratorRole)
+	if err != nil {
+		return nil, err
+	}
+	boards := make([]string, 0, len(rules))
+	for _, r := range rules {
+		boards = append(boards, r[2])
+	}
+	sort.Strings(boards)
+	return boards, nil
+}
@@ -0,0 +1,272 @@
+package rbac
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 策略导入导出中的规则统一表示为 [ptype, v0, v1, ...]，例如 ["p", "admin", "/api/users", "GET"]
+
+var ErrInvalidPolicyFile = errors.New("invalid policy file")
+
+// PolicyDiff 当前策略与目标策略的差异
+type PolicyDiff struct {
+	Added   [][]string `json:"added"`
+	Removed [][]string `json:"removed"`
+	Skipped int        `json:"skipped"` // 导入内容中被忽略的用户角色分配
+}
+
+// Empty 是否没有任何差异
+func (d *PolicyDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// IsUserID 策略中的主体是否为用户ID（角色名以字母开头，用户ID为纯数字）
+func IsUserID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUserRule 用户的角色分配、直接权限和版主任命，导入、快照和回滚都不涉及这些规则
+func isUserRule(rule []string) bool {
+	return len(rule) > 1 && IsUserID(rule[1])
+}
+
+// ExportRules 导出当前策略，includeUsers 为 false 时不含用户的角色分配
+func ExportRules(includeUsers bool) ([][]string, error) {
+	m := Enforcer.GetModel()
+	var rules [][]string
+	for _, sec := range []string{"p", "g"} {
+		ptypes := make([]string, 0, len(m[sec]))
+		for ptype := range m[sec] {
+			ptypes = append(ptypes, ptype)
+		}
+		sort.Strings(ptypes)
+		for _, ptype := range ptypes {
+			var (
+				policies [][]string
+				err      error
+			)
+			if sec == "p" {
+				policies, err = Enforcer.GetNamedPolicy(ptype)
+			} else {
+				policies, err = Enforcer.GetNamedGroupingPolicy(ptype)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range policies {
+				rule := append([]string{ptype}, p...)
+				if includeUsers || !isUserRule(rule) {
+					rules = append(rules, rule)
+				}
+			}
+		}
+	}
+	return rules, nil
+}
+
+// FormatCSV 按 rbac_policy.csv 的格式输出，包含逗号或引号的字段加引号
+func FormatCSV(rules [][]string) string {
+	var b strings.Builder
+	prev := ""
+	for _, rule := range rules {
+		if prev != "" && rule[0] != prev {
+			b.WriteString("\n")
+		}
+		prev = rule[0]
+		for i, field := range rule {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if strings.ContainsAny(field, ",\"\n") {
+				field = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+			}
+			b.WriteString(field)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ParseCSV 解析 CSV 格式的策略，忽略空行和 # 开头的注释
+func ParseCSV(data string) ([][]string, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicyFile, err)
+	}
+	rules := make([][]string, 0, len(records))
+	for _, rec := range records {
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+		rules = append(rules, rec)
+	}
+	return rules, validateRules(rules)
+}
+
+// FormatJSON 按 ptype 分组输出
+func FormatJSON(rules [][]string) map[string][][]string {
+	out := make(map[string][][]string)
+	for _, rule := range rules {
+		out[rule[0]] = append(out[rule[0]], rule[1:])
+	}
+	return out
+}
+
+// ParseJSON 解析 FormatJSON 输出的格式：{"p": [["admin", "/api/users", "GET"]], "g": [...]}
+func ParseJSON(data []byte) ([][]string, error) {
+	var grouped map[string][][]string
+	if err := json.Unmarshal(data, &grouped); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicyFile, err)
+	}
+	ptypes := make([]string, 0, len(grouped))
+	for ptype := range grouped {
+		ptypes = append(ptypes, ptype)
+	}
+	sort.Strings(ptypes)
+	var rules [][]string
+	for _, ptype := range ptypes {
+		for _, p := range grouped[ptype] {
+			rules = append(rules, append([]string{ptype}, p...))
+		}
+	}
+	return rules, validateRules(rules)
+}
+
+// validateRules ptype 必须在模型中定义，字段数与定义一致
+func validateRules(rules [][]string) error {
+	m := Enforcer.GetModel()
+	for i, rule := range rules {
+		if len(rule) < 2 {
+			return fmt.Errorf("%w: rule %d is empty", ErrInvalidPolicyFile, i+1)
+		}
+		ast, ok := m["p"][rule[0]]
+		if !ok {
+			ast, ok = m["g"][rule[0]]
+		}
+		if !ok {
+			return fmt.Errorf("%w: unknown ptype %q in rule %d", ErrInvalidPolicyFile, rule[0], i+1)
+		}
+		if len(rule)-1 != len(ast.Tokens) {
+			return fmt.Errorf("%w: %s rule %d needs %d fields, got %d",
+				ErrInvalidPolicyFile, rule[0], i+1, len(ast.Tokens), len(rule)-1)
+		}
+		for _, field := range rule[1:] {
+			if field == "" {
+				return fmt.Errorf("%w: rule %d has an empty field", ErrInvalidPolicyFile, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// DiffRules 计算把当前策略（不含用户的角色分配）改成 target 需要增加和删除的规则，
+// target 中的用户角色分配会被忽略并计入 Skipped
+func DiffRules(target [][]string) (*PolicyDiff, error) {
+	current, err := ExportRules(false)
+	if err != nil {
+		return nil, err
+	}
+	diff := &PolicyDiff{Added: [][]string{}, Removed: [][]string{}}
+	want := make(map[string]bool, len(target))
+	for _, rule := range target {
+		if isUserRule(rule) {
+			diff.Skipped++
+			continue
+		}
+		key := strings.Join(rule, "\x00")
+		if want[key] {
+			continue
+		}
+		want[key] = true
+	}
+	have := make(map[string]bool, len(current))
+	for _, rule := range current {
+		key := strings.Join(rule, "\x00")
+		have[key] = true
+		if !want[key] {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+	seen := make(map[string]bool, len(target))
+	for _, rule := range target {
+		key := strings.Join(rule, "\x00")
+		if isUserRule(rule) || have[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		diff.Added = append(diff.Added, rule)
+	}
+	return diff, nil
+}
+
+// applyDiff 按 ptype 分组增删规则，修改通过适配器保存并由 watcher 通知其他实例
+func applyDiff(diff *PolicyDiff) error {
+	m := Enforcer.GetModel()
+	for _, rules := range groupByPtype(diff.Removed) {
+		ptype := rules[0][0]
+		values := stripPtype(rules)
+		var err error
+		if _, isGroup := m["g"][ptype]; isGroup {
+			_, err = Enforcer.RemoveNamedGroupingPolicies(ptype, values)
+		} else {
+			_, err = Enforcer.RemoveNamedPolicies(ptype, values)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, rules := range groupByPtype(diff.Added) {
+		ptype := rules[0][0]
+		values := stripPtype(rules)
+		var err error
+		if _, isGroup := m["g"][ptype]; isGroup {
+			_, err = Enforcer.AddNamedGroupingPolicies(ptype, values)
+		} else {
+			_, err = Enforcer.AddNamedPolicies(ptype, values)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func groupByPtype(rules [][]string) [][][]string {
+	var groups [][][]string
+	index := make(map[string]int)
+	for _, rule := range rules {
+		i, ok := index[rule[0]]
+		if !ok {
+			i = len(groups)
+			index[rule[0]] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], rule)
+	}
+	return groups
+}
+
+func stripPtype(rules [][]string) [][]string {
+	out := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, rule[1:])
+	}
+	return out
+}
@@ -8,14 +8,20 @@ import (
 	"github.com/TalkSphere/backend/setting"
 
 	"strconv"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
-var Enforcer *casbin.Enforcer
+var Enforcer *casbin.SyncedEnforcer
+
+// AnonymousSubject 未登录访客在 Casbin 中的主体，访客的角色由分组策略 g, anonymous, <role> 决定
+const AnonymousSubject = "anonymous"
+
+// defaultAnonymousRole 策略中没有为 anonymous 分配角色时补上的默认角色，与 rbac_policy.csv 一致
+const defaultAnonymousRole = "guest"
 
 func InitCasbin() {
 	// 使用 GORM 适配器
@@ -28,7 +34,7 @@ func InitCasbin() {
 	policyPath := "conf/rbac_policy.csv"
 
 	// 创建enforcer，使用模型配置文件和数据库适配器
-	enforcer, err := casbin.NewEnforcer(modelPath, adapter)
+	enforcer, err := casbin.NewSyncedEnforcer(modelPath, adapter)
 	if err != nil {
 		zap.L().Fatal("failed to create casbin enforcer", zap.Error(err))
 	}
@@ -73,6 +79,18 @@ func InitCasbin() {
 			zap.L().Info("Added policies to enforcer", zap.Int("count", len(rules)))
 		}
 
+		// 资源级规则
+		abacRules, err := tmpEnforcer.GetNamedPolicy("p2")
+		if err != nil {
+			zap.L().Fatal("读取策略文件中的资源级规则失败", zap.Error(err))
+		}
+		if len(abacRules) > 0 {
+			if _, err = enforcer.AddNamedPolicies("p2", abacRules); err != nil {
+				zap.L().Fatal("导入资源级规则失败", zap.Error(err))
+			}
+			zap.L().Info("已导入资源级规则", zap.Int("count", len(abacRules)))
+		}
+
 		// 添加角色继承规则
 		if len(groupingRules) > 0 {
 			_, err = enforcer.AddGroupingPolicies(groupingRules)
@@ -106,6 +124,75 @@ func InitCasbin() {
 	enforcer.EnableAutoSave(true)
 
 	Enforcer = enforcer
+	ensureAnonymousRole()
+	ensureAbacPolicies(modelPath, policyPath)
+	ensureBuiltinRoles()
+}
+
+// ensureAnonymousRole 早于 anonymous 分组策略初始化的数据库中没有这条规则，启动时补上
+func ensureAnonymousRole() {
+	roles, err := Enforcer.GetRolesForUser(AnonymousSubject)
+	if err != nil {
+		zap.L().Error("获取访客角色失败", zap.Error(err))
+		return
+	}
+	if len(roles) > 0 {
+		return
+	}
+	if _, err := Enforcer.AddGroupingPolicy(AnonymousSubject, defaultAnonymousRole); err != nil {
+		zap.L().Error("设置访客角色失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("已为访客设置默认角色", zap.String("role", defaultAnonymousRole))
+}
+
+// ensureAbacPolicies 早于资源级规则初始化的数据库中没有 p2 规则，启动时从 CSV 补上。
+// 已有 p2 规则，或者管理员导入、回滚过策略（存在快照）时以数据库为准，
+// 通过导入或回滚删除的规则不会在重启后恢复
+func ensureAbacPolicies(modelPath, policyPath string) {
+	existing, err := Enforcer.GetNamedPolicy("p2")
+	if err != nil {
+		zap.L().Error("获取资源级规则失败", zap.Error(err))
+		return
+	}
+	if len(existing) > 0 {
+		return
+	}
+	var snapshots int64
+	if err := mysql.DB.Model(&models.PolicySnapshot{}).Count(&snapshots).Error; err != nil {
+		zap.L().Error("获取策略快照数量失败", zap.Error(err))
+		return
+	}
+	if snapshots > 0 {
+		return
+	}
+	tmpEnforcer, err := casbin.NewEnforcer(modelPath, policyPath)
+	if err != nil {
+		zap.L().Error("加载策略文件失败", zap.Error(err))
+		return
+	}
+	rules, err := tmpEnforcer.GetNamedPolicy("p2")
+	if err != nil {
+		zap.L().Error("获取资源级规则失败", zap.Error(err))
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+	if _, err := Enforcer.AddNamedPolicies("p2", rules); err != nil {
+		zap.L().Error("初始化资源级规则失败", zap.Error(err))
+		return
+	}
+	zap.L().Info("已补充资源级规则", zap.Int("count", len(rules)))
+}
+
+// AnonymousRole 未登录访客的角色
+func AnonymousRole() string {
+	roles, err := Enforcer.GetRolesForUser(AnonymousSubject)
+	if err != nil || len(roles) == 0 {
+		return ""
+	}
+	return roles[0]
 }
 
 // CheckPermission 检查权限
@@ -147,25 +234,73 @@ func RemoveRole(user string, role string) bool {
 	return ok
 }
 
-// GetUserRole 根据用户ID获取用户角色
-func GetUserRole(userID string) (string, error) {
-	var rule struct {
-		V1 string `gorm:"column:v1"`
+// GetUserRoles 获取用户的全部角色，直接分配的角色在前，随后是通过角色继承得到的角色
+// 没有分配任何角色的用户与未登录的访客权限相同，返回访客角色
+func GetUserRoles(userID string) ([]string, error) {
+	direct, err := GetDirectRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(direct) == 0 {
+		if role := AnonymousRole(); role != "" {
+			direct = []string{role}
+		}
 	}
 
-	err := mysql.DB.Table("casbin_rule").
-		Select("v1").
-		Where("ptype = 'g' AND v0 = ?", userID).
-		First(&rule).Error
+	roles := make([]string, 0, len(direct))
+	seen := make(map[string]bool, len(direct))
+	add := func(role string) {
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	for _, role := range direct {
+		add(role)
+	}
+	for _, role := range direct {
+		inherited, err := Enforcer.GetImplicitRolesForUser(role)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range inherited {
+			add(r)
+		}
+	}
+	return roles, nil
+}
 
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return "guest", nil // 未注册或未登录的用户视为游客
+// GetDirectRoles 获取直接分配给用户的角色
+func GetDirectRoles(userID string) ([]string, error) {
+	return Enforcer.GetRolesForUser(userID)
+}
+
+// HasRole 判断角色列表中是否包含任意一个指定角色
+func HasRole(roles []string, want ...string) bool {
+	for _, r := range roles {
+		for _, w := range want {
+			if r == w {
+				return true
+			}
 		}
-		return "", err
 	}
+	return false
+}
 
-	return rule.V1, nil
+// SetUserRoles 用给定的角色集合替换用户直接分配的角色
+func SetUserRoles(userID string, roles []string) error {
+	if _, err := Enforcer.RemoveFilteredGroupingPolicy(0, userID); err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	rules := make([][]string, 0, len(roles))
+	for _, role := range roles {
+		rules = append(rules, []string{userID, role})
+	}
+	_, err := Enforcer.AddGroupingPolicies(rules)
+	return err
 }
 
 // RemoveAllRoles 删除用户的所有角色
@@ -194,14 +329,6 @@ func RemoveAllRoles(userID string) bool {
 		return false
 	}
 
-	// 保存策略到数据库
-	if err := Enforcer.SavePolicy(); err != nil {
-		zap.L().Error("保存策略到数据库失败",
-			zap.String("user_id", userID),
-			zap.Error(err))
-		return false
-	}
-
 	zap.L().Info("删除用户所有角色完成",
 		zap.String("user_id", userID),
 		zap.Bool("success", ok))
@@ -216,33 +343,46 @@ func InitSuperAdmin() {
 
 	if result.RowsAffected == 0 {
 		// 不存在则创建超级管理员用户
+		passwordHash, err := encrypt.HashPassword(setting.Conf.SuperAdmin.Password)
+		if err != nil {
+			zap.L().Fatal("生成超级管理员密码哈希失败", zap.Error(err))
+			return
+		}
 		userID := snowflake.GenID()
+		// 邮箱由配置指定，视为已验证，开启 require_verified_email 时也能登录
+		now := time.Now()
 		user = models.User{
-			ID:           userID,
-			Username:     "super_admin",
-			Email:        setting.Conf.SuperAdmin.Email,
-			PasswordHash: encrypt.EncryptPassword(setting.Conf.SuperAdmin.Password),
-			Bio:          "System Super Administrator",
-			Status:       1,
+			ID:              userID,
+			Username:        "super_admin",
+			Email:           setting.Conf.SuperAdmin.Email,
+			PasswordHash:    passwordHash,
+			Bio:             "System Super Administrator",
+			Status:          1,
+			EmailVerifiedAt: &now,
 		}
 
 		if err := mysql.DB.Create(&user).Error; err != nil {
 			zap.L().Fatal("failed to create super admin", zap.Error(err))
 			return
 		}
+	} else if user.EmailVerifiedAt == nil {
+		// 早于邮箱验证创建的超级管理员
+		if err := mysql.DB.Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+			zap.L().Error("标记超级管理员邮箱已验证失败", zap.Error(err))
+		}
 	}
 
 	// 无论是新建还是已存在，都确保角色正确
 	userIDStr := strconv.FormatInt(user.ID, 10)
 
 	// 检查当前角色
-	currentRole, err := GetUserRole(userIDStr)
+	roles, err := GetDirectRoles(userIDStr)
 	if err != nil {
 		zap.L().Error("failed to get user role", zap.Error(err))
 	}
 
 	// 如果不是超级管理员，则设置角色
-	if currentRole != "super_admin" {
+	if !HasRole(roles, "super_admin") {
 		// 先清除可能存在的其他角色
 		RemoveAllRoles(userIDStr)
 
@@ -285,8 +425,8 @@ func GetUserPermissions(userID string) ([][]string, error) {
 // HasPermission 检查用户是否有特定权限
 func HasPermission(userID string, obj string, act string) bool {
 	// 首先检查用户是否是超级管理员
-	role, err := GetUserRole(userID)
-	if err == nil && role == "super_admin" {
+	roles, err := GetUserRoles(userID)
+	if err == nil && HasRole(roles, "super_admin") {
 		return true
 	}
 
@@ -319,8 +459,8 @@ func BatchUpdatePermissions(userID string, permissions [][]string) error {
 func GetUserAllPermissions(userID string) map[string][]string {
 	permissions := make(map[string][]string)
 
-	// 获取用户的角色
-	role, err := GetUserRole(userID)
+	// 获取用户的全部角色
+	roles, err := GetUserRoles(userID)
 	if err != nil {
 		zap.L().Error("get user role error", zap.Error(err))
 		return permissions
@@ -342,19 +482,21 @@ func GetUserAllPermissions(userID string) map[string][]string {
 		}
 	}
 
-	// 获取角色的权限
-	rolePermissions, err := Enforcer.GetFilteredPolicy(0, role)
-	if err != nil {
-		zap.L().Error("GetFilteredPolicy error", zap.Error(err))
-		return permissions
-	}
-	for _, p := range rolePermissions {
-		if len(p) >= 3 {
-			obj, act := p[1], p[2]
-			if _, exists := permissions[obj]; !exists {
-				permissions[obj] = make([]string, 0)
+	// 获取各个角色的权限
+	for _, role := range roles {
+		rolePermissions, err := Enforcer.GetFilteredPolicy(0, role)
+		if err != nil {
+			zap.L().Error("GetFilteredPolicy error", zap.Error(err))
+			return permissions
+		}
+		for _, p := range rolePermissions {
+			if len(p) >= 3 {
+				obj, act := p[1], p[2]
+				if _, exists := permissions[obj]; !exists {
+					permissions[obj] = make([]string, 0)
+				}
+				permissions[obj] = append(permissions[obj], act)
 			}
-			permissions[obj] = append(permissions[obj], act)
 		}
 	}
 
@@ -398,8 +540,8 @@ func UpdateUserPermissions(userID string, permissions []map[string]interface{})
 // CheckUserPermission 检查用户是否有特定权限（考虑继承关系）
 func CheckUserPermission(userID string, obj string, act string) bool {
 	// 检查是否是超级管理员
-	role, err := GetUserRole(userID)
-	if err == nil && role == "super_admin" {
+	roles, err := GetUserRoles(userID)
+	if err == nil && HasRole(roles, "super_admin") {
 		return true
 	}
 
@@ -408,9 +550,11 @@ func CheckUserPermission(userID string, obj string, act string) bool {
 		return true
 	}
 
-	// 检查角色权限
-	if role != "" {
-		return CheckPermission(role, obj, act)
+	// 检查角色权限，任意一个角色允许即可
+	for _, role := range roles {
+		if CheckPermission(role, obj, act) {
+			return true
+		}
 	}
 
 	return false
@@ -0,0 +1,434 @@
+package rbac
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/mysql"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SuperAdminRole 超级管理员角色，不能修改或删除
+const SuperAdminRole = "super_admin"
+
+var (
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrRoleExists      = errors.New("role already exists")
+	ErrInvalidRoleName = errors.New("invalid role name")
+	ErrInvalidPolicy   = errors.New("invalid policy")
+	ErrProtectedRole   = errors.New("role is protected")
+	ErrRoleInUse       = errors.New("role still has members")
+	ErrRoleCycle       = errors.New("role inheritance cycle")
+)
+
+// builtinRoles rbac_policy.csv 中定义的内置角色
+var builtinRoles = []models.Role{
+	{Name: "guest", Description: "未登录访客", Builtin: true},
+	{Name: "user", Description: "普通用户", Builtin: true},
+	{Name: "admin", Description: "管理员", Builtin: true},
+	{Name: ModeratorRole, Description: "板块版主，只在被任命的板块内生效", Builtin: true},
+	{Name: SuperAdminRole, Description: "超级管理员，拥有全部权限", Builtin: true},
+}
+
+// 角色名以字母开头，不能与用户ID（纯数字）混淆
+var roleNameRe = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,63}$`)
+
+// 策略中允许的 act
+var policyActs = map[string]bool{"GET": true, "POST": true, "PUT": true, "DELETE": true, "PATCH": true, "*": true}
+
+// Policy 角色的一条权限
+type Policy struct {
+	Obj string `json:"obj"`
+	Act string `json:"act"`
+}
+
+// RoleSpec 创建或修改角色时提交的内容
+type RoleSpec struct {
+	Description string   `json:"description"`
+	Parents     []string `json:"parents"`
+	Policies    []Policy `json:"policies"`
+}
+
+// ResourceRule 角色的一条资源级（p2）规则
+type ResourceRule struct {
+	Type string `json:"type"`
+	Act  string `json:"act"`
+	Rule string `json:"rule"`
+}
+
+// RoleDetail 角色及其权限、继承关系和成员数
+type RoleDetail struct {
+	models.Role
+	Parents       []string       `json:"parents"`
+	Policies      []Policy       `json:"policies"`
+	ResourceRules []ResourceRule `json:"resource_rules"`
+	Members       int            `json:"members"`  // 直接分配了该角色的用户数
+	Children      []string       `json:"children"` // 继承该角色的角色
+}
+
+// ensureBuiltinRoles 为内置角色补上描述信息
+func ensureBuiltinRoles() {
+	for _, r := range builtinRoles {
+		role := r
+		if err := mysql.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+			zap.L().Error("初始化内置角色失败", zap.String("role", r.Name), zap.Error(err))
+		}
+	}
+}
+
+// RoleExists 判断角色是否存在
+func RoleExists(name string) (bool, error) {
+	var count int64
+	if err := mysql.DB.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListRoles 列出全部角色
+func ListRoles() ([]RoleDetail, error) {
+	var roles []models.Role
+	if err := mysql.DB.Order("builtin DESC, name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		names[r.Name] = true
+	}
+	details := make([]RoleDetail, 0, len(roles))
+	for _, r := range roles {
+		d, err := describe(r, names)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
+
+// GetRole 获取单个角色
+func GetRole(name string) (*RoleDetail, error) {
+	role, err := findRole(name)
+	if err != nil {
+		return nil, err
+	}
+	names, err := roleNames()
+	if err != nil {
+		return nil, err
+	}
+	d, err := describe(*role, names)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateRole 创建自定义角色
+func CreateRole(name string, spec RoleSpec) (*RoleDetail, error) {
+	if !roleNameRe.MatchString(name) || name == AnonymousSubject {
+		return nil, ErrInvalidRoleName
+	}
+	policies, err := normalizePolicies(spec.Policies)
+	if err != nil {
+		return nil, err
+	}
+	parents, err := checkParents(name, spec.Parents)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := RoleExists(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRoleExists
+	}
+	role := &models.Role{Name: name, Description: spec.Description}
+	if err := mysql.DB.Create(role).Error; err != nil {
+		return nil, err
+	}
+	if err := applyRole(name, policies, parents); err != nil {
+		// 回滚已写入的描述信息和策略
+		_ = clearRole(name)
+		mysql.DB.Delete(&models.Role{}, "name = ?", name)
+		return nil, err
+	}
+	zap.L().Info("创建角色", zap.String("role", name), zap.Strings("parents", parents), zap.Int("policies", len(policies)))
+	return GetRole(name)
+}
+
+// UpdateRole 修改角色的描述、权限和继承关系，权限和继承关系整体替换
+func UpdateRole(name string, spec RoleSpec) (*RoleDetail, error) {
+	if name == SuperAdminRole {
+		return nil, ErrProtectedRole
+	}
+	role, err := findRole(name)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := normalizePolicies(spec.Policies)
+	if err != nil {
+		return nil, err
+	}
+	parents, err := checkParents(name, spec.Parents)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保存原有的描述、权限和继承关系，写入失败时恢复，避免角色只剩一半的权限
+	oldDescription := role.Description
+	oldPolicies, err := Enforcer.GetFilteredPolicy(0, name)
+	if err != nil {
+		return nil, err
+	}
+	oldParents, err := Enforcer.GetFilteredGroupingPolicy(0, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mysql.DB.Model(role).Update("description", spec.Description).Error; err != nil {
+		return nil, err
+	}
+	err = clearRole(name)
+	if err == nil {
+		err = applyRole(name, policies, parents)
+	}
+	if err != nil {
+		if rerr := restoreRole(name, oldPolicies, oldParents); rerr != nil {
+			zap.L().Error("恢复角色权限失败", zap.String("role", name), zap.Error(rerr))
+		}
+		mysql.DB.Model(role).Update("description", oldDescription)
+		return nil, err
+	}
+	zap.L().Info("修改角色", zap.String("role", name), zap.Strings("parents", parents), zap.Int("policies", len(policies)))
+	return GetRole(name)
+}
+
+// CloneRole 以已有角色为模板创建新角色，复制权限、资源级规则和继承关系
+func CloneRole(source, name, description string) (*RoleDetail, error) {
+	src, err := GetRole(source)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = src.Description
+	}
+	if _, err := CreateRole(name, RoleSpec{
+		Description: description,
+		Parents:     src.Parents,
+		Policies:    src.Policies,
+	}); err != nil {
+		return nil, err
+	}
+	if len(src.ResourceRules) > 0 {
+		rules := make([][]string, 0, len(src.ResourceRules))
+		for _, r := range src.ResourceRules {
+			rules = append(rules, []string{name, r.Type, r.Act, r.Rule})
+		}
+		if _, err := Enforcer.AddNamedPolicies("p2", rules); err != nil {
+			return nil, err
+		}
+	}
+	return GetRole(name)
+}
+
+// DeleteRole 删除自定义角色，内置角色和仍有用户或子角色的角色不能删除
+func DeleteRole(name string) error {
+	role, err := findRole(name)
+	if err != nil {
+		return err
+	}
+	if role.Builtin {
+		return ErrProtectedRole
+	}
+	members, err := Enforcer.GetUsersForRole(name)
+	if err != nil {
+		return err
+	}
+	if len(members) > 0 {
+		return ErrRoleInUse
+	}
+	if err := clearRole(name); err != nil {
+		return err
+	}
+	if _, err := Enforcer.RemoveFilteredNamedPolicy("p2", 0, name); err != nil {
+		return err
+	}
+	if err := mysql.DB.Delete(role).Error; err != nil {
+		return err
+	}
+	zap.L().Info("删除角色", zap.String("role", name))
+	return nil
+}
+
+func findRole(name string) (*models.Role, error) {
+	var role models.Role
+	if err := mysql.DB.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func roleNames() (map[string]bool, error) {
+	var names []string
+	if err := mysql.DB.Model(&models.Role{}).Pluck("name", &names).Error; err != nil {
+		return nil, err
+	}
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m, nil
+}
+
+// describe 从 Casbin 读取角色的权限、父角色和成员
+func describe(role models.Role, roleNames map[string]bool) (RoleDetail, error) {
+	d := RoleDetail{Role: role, Parents: []string{}, Policies: []Policy{}, ResourceRules: []ResourceRule{}, Children: []string{}}
+	rules, err := Enforcer.GetFilteredPolicy(0, role.Name)
+	if err != nil {
+		return d, err
+	}
+	for _, p := range rules {
+		if len(p) >= 3 {
+			d.Policies = append(d.Policies, Policy{Obj: p[1], Act: p[2]})
+		}
+	}
+	abacRules, err := Enforcer.GetFilteredNamedPolicy("p2", 0, role.Name)
+	if err != nil {
+		return d, err
+	}
+	for _, p := range abacRules {
+		if len(p) >= 4 {
+			d.ResourceRules = append(d.ResourceRules, ResourceRule{Type: p[1], Act: p[2], Rule: p[3]})
+		}
+	}
+	parents, err := Enforcer.GetRolesForUser(role.Name)
+	if err != nil {
+		return d, err
+	}
+	d.Parents = append(d.Parents, parents...)
+	members, err := Enforcer.GetUsersForRole(role.Name)
+	if err != nil {
+		return d, err
+	}
+	for _, m := range members {
+		if roleNames[m] {
+			d.Children = append(d.Children, m)
+		} else {
+			d.Members++
+		}
+	}
+	sort.Strings(d.Children)
+	return d, nil
+}
+
+// normalizePolicies 校验并去重权限
+func normalizePolicies(in []Policy) ([]Policy, error) {
+	out := make([]Policy, 0, len(in))
+	seen := make(map[Policy]bool, len(in))
+	for _, p := range in {
+		p.Act = strings.ToUpper(strings.TrimSpace(p.Act))
+		p.Obj = strings.TrimSpace(p.Obj)
+		if !strings.HasPrefix(p.Obj, "/api/") || !policyActs[p.Act] {
+			return nil, ErrInvalidPolicy
+		}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// checkParents 父角色必须存在，且不能形成继承环
+func checkParents(name string, parents []string) ([]string, error) {
+	out := make([]string, 0, len(parents))
+	seen := make(map[string]bool, len(parents))
+	for _, p := range parents {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if p == name {
+			return nil, ErrRoleCycle
+		}
+		exists, err := RoleExists(p)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrRoleNotFound
+		}
+		inherited, err := Enforcer.GetImplicitRolesForUser(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range inherited {
+			if r == name {
+				return nil, ErrRoleCycle
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// clearRole 删除角色自身的权限和它对父角色的继承，不影响分配了该角色的用户
+func clearRole(name string) error {
+	if _, err := Enforcer.RemoveFilteredPolicy(0, name); err != nil {
+		return err
+	}
+	_, err := Enforcer.RemoveFilteredGroupingPolicy(0, name)
+	return err
+}
+
+// restoreRole 把角色的权限和继承关系恢复为修改前读取的规则
+func restoreRole(name string, policies, parents [][]string) error {
+	if err := clearRole(name); err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		if _, err := Enforcer.AddPolicies(policies); err != nil {
+			return err
+		}
+	}
+	if len(parents) > 0 {
+		if _, err := Enforcer.AddGroupingPolicies(parents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRole 写入角色的权限和继承关系
+func applyRole(name string, policies []Policy, parents []string) error {
+	if len(policies) > 0 {
+		rules := make([][]string, 0, len(policies))
+		for _, p := range policies {
+			rules = append(rules, []string{name, p.Obj, p.Act})
+		}
+		if _, err := Enforcer.AddPolicies(rules); err != nil {
+			return err
+		}
+	}
+	if len(parents) > 0 {
+		rules := make([][]string, 0, len(parents))
+		for _, p := range parents {
+			rules = append(rules, []string{name, p})
+		}
+		if _, err := Enforcer.AddGroupingPolicies(rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
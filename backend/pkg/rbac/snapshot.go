@@ -0,0 +1,178 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/mysql"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 快照的来源
+const (
+	SnapshotBaseline = "baseline" // 第一次导入或手动保存前的原始策略
+	SnapshotManual   = "manual"
+	SnapshotImport   = "import"
+	SnapshotRollback = "rollback"
+)
+
+var ErrSnapshotNotFound = errors.New("policy snapshot not found")
+
+// SnapshotAuthor 操作者，命令行操作时 ID 为空
+type SnapshotAuthor struct {
+	ID   *int64
+	Name string
+}
+
+// ImportResult 导入或回滚的结果，DryRun 时只计算差异
+type ImportResult struct {
+	Diff     *PolicyDiff            `json:"diff"`
+	DryRun   bool                   `json:"dry_run"`
+	Snapshot *models.PolicySnapshot `json:"snapshot,omitempty"` // 应用后保存的快照，没有变化时为空
+}
+
+// ImportPolicy 用 rules 整体替换当前策略（用户的角色分配除外），应用前后各保存一次快照
+func ImportPolicy(rules [][]string, author SnapshotAuthor, comment string, dryRun bool) (*ImportResult, error) {
+	if err := validateRules(rules); err != nil {
+		return nil, err
+	}
+	return replacePolicy(rules, SnapshotImport, author, comment, dryRun)
+}
+
+// RollbackSnapshot 把策略恢复到快照 id 保存的状态，回滚本身也会保存为一个新快照
+func RollbackSnapshot(id int64, author SnapshotAuthor, comment string, dryRun bool) (*ImportResult, error) {
+	snap, err := GetSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseCSV(snap.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if comment == "" {
+		comment = fmt.Sprintf("回滚到快照 #%d", id)
+	}
+	return replacePolicy(rules, SnapshotRollback, author, comment, dryRun)
+}
+
+// CreateSnapshot 保存当前策略
+func CreateSnapshot(author SnapshotAuthor, comment string) (*models.PolicySnapshot, error) {
+	return saveSnapshot(SnapshotManual, author, comment)
+}
+
+// ListSnapshots 按时间倒序列出快照，不含规则内容
+func ListSnapshots(page, size int) ([]models.PolicySnapshot, int64, error) {
+	var (
+		snaps []models.PolicySnapshot
+		total int64
+	)
+	if err := mysql.DB.Model(&models.PolicySnapshot{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := mysql.DB.Omit("rules").
+		Order("id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&snaps).Error
+	return snaps, total, err
+}
+
+// GetSnapshot 获取快照及其规则
+func GetSnapshot(id int64) (*models.PolicySnapshot, error) {
+	var snap models.PolicySnapshot
+	if err := mysql.DB.Where("id = ?", id).Take(&snap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func replacePolicy(rules [][]string, action string, author SnapshotAuthor, comment string, dryRun bool) (*ImportResult, error) {
+	diff, err := DiffRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{Diff: diff, DryRun: dryRun}
+	if dryRun || diff.Empty() {
+		return result, nil
+	}
+
+	if err := ensureBaselineSnapshot(author); err != nil {
+		return nil, err
+	}
+	if err := applyDiff(diff); err != nil {
+		return nil, err
+	}
+	ensureRoles(diff.Added)
+
+	snap, err := saveSnapshot(action, author, comment)
+	if err != nil {
+		return nil, err
+	}
+	result.Snapshot = snap
+	zap.L().Info("策略已替换",
+		zap.String("action", action),
+		zap.String("author", author.Name),
+		zap.Int("added", len(diff.Added)),
+		zap.Int("removed", len(diff.Removed)),
+		zap.Int64("snapshot", snap.ID))
+	return result, nil
+}
+
+// ensureBaselineSnapshot 第一次修改前保存原始策略，保证总能回滚到修改前的状态
+func ensureBaselineSnapshot(author SnapshotAuthor) error {
+	var count int64
+	if err := mysql.DB.Model(&models.PolicySnapshot{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := saveSnapshot(SnapshotBaseline, author, "首次修改前的策略")
+	return err
+}
+
+func saveSnapshot(action string, author SnapshotAuthor, comment string) (*models.PolicySnapshot, error) {
+	rules, err := ExportRules(false)
+	if err != nil {
+		return nil, err
+	}
+	snap := &models.PolicySnapshot{
+		Action:    action,
+		AuthorID:  author.ID,
+		Author:    author.Name,
+		Comment:   comment,
+		Rules:     FormatCSV(rules),
+		RuleCount: len(rules),
+	}
+	if err := mysql.DB.Create(snap).Error; err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// ensureRoles 导入的规则中出现的新角色补上角色记录，否则角色管理接口中看不到
+func ensureRoles(rules [][]string) {
+	m := Enforcer.GetModel()
+	for _, rule := range rules {
+		names := rule[1:2]
+		if _, isGroup := m["g"][rule[0]]; isGroup {
+			names = rule[1:3]
+		}
+		for _, name := range names {
+			if name == AnonymousSubject || !roleNameRe.MatchString(name) {
+				continue
+			}
+			role := models.Role{Name: name}
+			if err := mysql.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+				zap.L().Error("补充角色记录失败", zap.String("role", name), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package rbac
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 策略同步方式
+const (
+	WatcherNone  = "none"
+	WatcherDB    = "db"
+	WatcherRedis = "redis"
+)
+
+const (
+	policyChannel       = "talksphere:casbin:policy"
+	policyVersionID     = 1
+	defaultPollInterval = 10 * time.Second
+)
+
+var errRedisUnavailable = errors.New("redis is not initialized")
+
+// policyMessage redis 中广播的策略变更通知
+type policyMessage struct {
+	Instance string `json:"instance"`
+	Version  int64  `json:"version"`
+}
+
+// Watcher 实现 Casbin 的 persist.Watcher：本实例修改策略后把数据库中的版本号加一并通知其他实例，
+// 其他实例收到通知或轮询到版本号变化后重新加载策略
+type Watcher struct {
+	backend    string
+	instanceID string
+	redis      *redis.Client
+	pubsub     *redis.PubSub
+
+	mu       sync.Mutex // 串行化重新加载
+	callback func(string)
+	version  int64 // 本实例已加载的策略版本
+	lastSync int64 // 最近一次加载策略的时间（UnixNano）
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatcherStatus 策略同步状态，用于排查多实例之间策略不一致
+type WatcherStatus struct {
+	Backend    string    `json:"backend"`
+	InstanceID string    `json:"instance_id"`
+	Version    int64     `json:"version"`    // 本实例已加载的版本
+	DBVersion  int64     `json:"db_version"` // 数据库中的最新版本
+	LastSync   time.Time `json:"last_sync"`  // 最近一次加载策略的时间
+}
+
+var watcher *Watcher
+
+// InitWatcher 在 InitCasbin 之后调用，client 为 nil 时 redis 方式退回到轮询数据库
+// 不论哪种方式，本实例修改策略时都会推进版本号，none 只是不监听其他实例的修改
+func InitWatcher(cfg *setting.RBACConfig, client *redis.Client) error {
+	backend, interval := WatcherNone, defaultPollInterval
+	if cfg != nil {
+		if cfg.Watcher != "" {
+			backend = cfg.Watcher
+		}
+		if cfg.PollInterval > 0 {
+			interval = time.Duration(cfg.PollInterval) * time.Second
+		}
+	}
+
+	version, err := ensurePolicyVersion()
+	if err != nil {
+		return err
+	}
+	w := &Watcher{
+		backend:    backend,
+		instanceID: newInstanceID(),
+		version:    version,
+		lastSync:   time.Now().UnixNano(),
+		stop:       make(chan struct{}),
+	}
+
+	if backend == WatcherRedis {
+		if err := w.subscribe(client); err != nil {
+			zap.L().Warn("订阅策略变更失败，改为轮询数据库", zap.Error(err))
+			w.backend = WatcherDB
+		}
+	}
+	if w.backend != WatcherNone {
+		go w.poll(interval)
+	}
+
+	if err := Enforcer.SetWatcher(w); err != nil {
+		return err
+	}
+	// Enforcer.SetWatcher 设置的默认回调不经过 SyncedEnforcer 的锁，这里换成加锁的版本
+	if err := w.SetUpdateCallback(func(string) {
+		if err := Enforcer.LoadPolicy(); err != nil {
+			zap.L().Error("重新加载策略失败", zap.Error(err))
+		}
+	}); err != nil {
+		return err
+	}
+	watcher = w
+	zap.L().Info("策略同步已启用",
+		zap.String("backend", w.backend),
+		zap.String("instance", w.instanceID),
+		zap.Int64("version", version))
+	return nil
+}
+
+// GetWatcherStatus 获取策略同步状态
+func GetWatcherStatus() (*WatcherStatus, error) {
+	dbVersion, err := currentPolicyVersion()
+	if err != nil {
+		return nil, err
+	}
+	if watcher == nil {
+		return &WatcherStatus{Backend: WatcherNone, DBVersion: dbVersion}, nil
+	}
+	return &WatcherStatus{
+		Backend:    watcher.backend,
+		InstanceID: watcher.instanceID,
+		Version:    atomic.LoadInt64(&watcher.version),
+		DBVersion:  dbVersion,
+		LastSync:   time.Unix(0, atomic.LoadInt64(&watcher.lastSync)),
+	}, nil
+}
+
+// CloseWatcher 停止监听策略变更
+func CloseWatcher() {
+	if watcher != nil {
+		watcher.Close()
+	}
+}
+
+// SetUpdateCallback 设置其他实例修改策略后的回调
+func (w *Watcher) SetUpdateCallback(fn func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = fn
+	return nil
+}
+
+// Update 本实例修改策略后由 Casbin 调用（此时持有 Enforcer 的锁），推进版本号并通知其他实例
+// 版本号推进失败时只记录日志，策略本身已经保存，不影响本次修改
+func (w *Watcher) Update() error {
+	version, err := bumpPolicyVersion()
+	if err != nil {
+		zap.L().Error("推进策略版本号失败", zap.Error(err))
+		return nil
+	}
+	// 只有本实例已加载的版本紧挨着这次修改时才推进，否则说明其他实例也修改过，交给通知或轮询重新加载
+	atomic.CompareAndSwapInt64(&w.version, version-1, version)
+
+	if w.pubsub != nil {
+		payload, _ := json.Marshal(policyMessage{Instance: w.instanceID, Version: version})
+		if err := w.redis.Publish(policyChannel, payload).Err(); err != nil {
+			zap.L().Warn("广播策略变更失败，其他实例将通过轮询同步", zap.Int64("version", version), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Close 停止监听
+func (w *Watcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		if w.pubsub != nil {
+			_ = w.pubsub.Close()
+		}
+	})
+}
+
+func (w *Watcher) subscribe(client *redis.Client) error {
+	if client == nil {
+		return errRedisUnavailable
+	}
+	pubsub := client.Subscribe(policyChannel)
+	if _, err := pubsub.Receive(); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+	w.redis, w.pubsub = client, pubsub
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			var m policyMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+				zap.L().Warn("无法解析策略变更通知", zap.String("payload", msg.Payload), zap.Error(err))
+				continue
+			}
+			if m.Instance == w.instanceID || m.Version <= atomic.LoadInt64(&w.version) {
+				continue
+			}
+			w.reload(WatcherRedis)
+		}
+	}()
+	return nil
+}
+
+// poll 定期检查数据库中的版本号，redis 方式下用来弥补断线期间丢失的通知
+func (w *Watcher) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.reload(WatcherDB)
+		}
+	}
+}
+
+// reload 数据库中的版本号比本实例新时重新加载策略
+func (w *Watcher) reload(source string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	version, err := currentPolicyVersion()
+	if err != nil {
+		zap.L().Error("查询策略版本号失败", zap.Error(err))
+		return
+	}
+	local := atomic.LoadInt64(&w.version)
+	if version <= local {
+		return
+	}
+	if w.callback != nil {
+		w.callback(source)
+	}
+	// 重新加载期间本实例可能又推进了版本号，只往前推
+	for {
+		old := atomic.LoadInt64(&w.version)
+		if version <= old || atomic.CompareAndSwapInt64(&w.version, old, version) {
+			break
+		}
+	}
+	atomic.StoreInt64(&w.lastSync, time.Now().UnixNano())
+	zap.L().Info("已同步其他实例的策略变更",
+		zap.String("source", source),
+		zap.Int64("from", local),
+		zap.Int64("to", version))
+}
+
+// ensurePolicyVersion 早于版本号表创建的数据库中没有这一行，启动时补上，返回当前版本号
+func ensurePolicyVersion() (int64, error) {
+	row := models.PolicyVersion{ID: policyVersionID}
+	if err := mysql.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return 0, err
+	}
+	return currentPolicyVersion()
+}
+
+func currentPolicyVersion() (int64, error) {
+	var row models.PolicyVersion
+	if err := mysql.DB.Select("version").Where("id = ?", policyVersionID).Take(&row).Error; err != nil {
+		return 0, err
+	}
+	return row.Version, nil
+}
+
+// bumpPolicyVersion 版本号加一并返回新值，在同一事务中读取以免读到其他实例的修改
+func bumpPolicyVersion() (int64, error) {
+	var version int64
+	err := mysql.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PolicyVersion{}).
+			Where("id = ?", policyVersionID).
+			Update("version", gorm.Expr("version + 1")).Error; err != nil {
+			return err
+		}
+		var row models.PolicyVersion
+		if err := tx.Select("version").Where("id = ?", policyVersionID).Take(&row).Error; err != nil {
+			return err
+		}
+		version = row.Version
+		return nil
+	})
+	return version, err
+}
+
+// newInstanceID 主机名加随机后缀，区分同一主机上的多个实例
+func newInstanceID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
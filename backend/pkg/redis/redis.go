@@ -32,3 +32,8 @@ func Close() {
 	}
 	return
 }
+
+// Client 返回 redis 客户端，未调用 Init 时为 nil
+func Client() *redis.Client {
+	return client
+}
@@ -0,0 +1,371 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/jwt"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionExpired      = errors.New("session expired")
+	ErrSessionRevoked      = errors.New("session revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// TokenPair 登录或刷新后返回给客户端的令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	SessionID    int64  `json:"session_id,string"`
+}
+
+// Client 发起登录或刷新的客户端信息
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// touchInterval 最近使用时间的写库间隔
+const touchInterval = time.Minute
+
+// denylist 已吊销 jti 的本地缓存，避免命中的请求反复查库
+var denylist sync.Map // jti -> expiresAt
+
+// touched 会话最近一次写入 last_seen_at 的时间，用于限制写库频率
+var touched sync.Map // sessionID -> time.Time
+
+// Init 启动过期会话和黑名单的定期清理
+func Init() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			purgeExpired()
+		}
+	}()
+}
+
+// Issue 为用户创建新的登录会话并签发令牌
+func Issue(user *models.User, client Client) (*TokenPair, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s := &models.UserSession{
+		ID:               snowflake.GenID(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashSecret(secret),
+		ExpiresAt:        now.Add(jwt.RefreshTokenExpire()),
+		UserAgent:        truncate(client.UserAgent, 255),
+		IP:               client.IP,
+		LastSeenAt:       &now,
+	}
+	token, claims, err := jwt.GenToken(user.ID, user.Username, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.AccessJTI = claims.Id
+	s.AccessExpiresAt = time.Unix(claims.ExpiresAt, 0)
+
+	if err := mysql.DB.Create(s).Error; err != nil {
+		return nil, err
+	}
+	return newTokenPair(s, token, secret), nil
+}
+
+// Refresh 使用 refresh token 换取新的令牌，旧的 refresh token 立即失效
+// 如果一个已经轮换过的 refresh token 被再次使用，说明它可能已泄露，整个会话会被吊销
+// 其它不匹配的令牌只返回 ErrInvalidRefreshToken，避免仅凭会话 ID 就能让别人下线
+func Refresh(refreshToken string, client Client) (*TokenPair, error) {
+	sessionID, secret, err := parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var pair *TokenPair
+	err = mysql.DB.Transaction(func(tx *gorm.DB) error {
+		var s models.UserSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, sessionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if s.RevokedAt != nil {
+			return ErrSessionRevoked
+		}
+		if time.Now().After(s.ExpiresAt) {
+			return ErrSessionExpired
+		}
+		hash := hashSecret(secret)
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(s.RefreshTokenHash)) != 1 {
+			var n int64
+			if err := tx.Model(&models.RotatedRefreshToken{}).
+				Where("token_hash = ? AND session_id = ?", hash, s.ID).
+				Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				return ErrRefreshTokenReused
+			}
+			return ErrInvalidRefreshToken
+		}
+
+		var user models.User
+		if err := tx.First(&user, s.UserID).Error; err != nil {
+			return err
+		}
+		if user.Status != 1 {
+			return ErrSessionRevoked
+		}
+
+		newSecret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		token, claims, err := jwt.GenToken(user.ID, user.Username, s.ID)
+		if err != nil {
+			return err
+		}
+		// 轮换前签发的 access token 一并作废
+		if err := denyJTI(tx, s.UserID, s.AccessJTI, s.AccessExpiresAt); err != nil {
+			return err
+		}
+		// 记下被换掉的令牌，之后再出示它才能判定为重复使用
+		rotated := models.RotatedRefreshToken{TokenHash: s.RefreshTokenHash, SessionID: s.ID, ExpiresAt: s.ExpiresAt}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rotated).Error; err != nil {
+			return err
+		}
+		s.RefreshTokenHash = hashSecret(newSecret)
+		s.AccessJTI = claims.Id
+		s.AccessExpiresAt = time.Unix(claims.ExpiresAt, 0)
+		now := time.Now()
+		s.ExpiresAt = now.Add(jwt.RefreshTokenExpire())
+		s.UserAgent = truncate(client.UserAgent, 255)
+		s.IP = client.IP
+		s.LastSeenAt = &now
+		if err := tx.Save(&s).Error; err != nil {
+			return err
+		}
+		pair = newTokenPair(&s, token, newSecret)
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		zap.L().Warn("检测到刷新令牌被重复使用，吊销该会话",
+			zap.Int64("session_id", sessionID))
+		if rerr := Revoke(sessionID); rerr != nil {
+			zap.L().Error("吊销会话失败", zap.Int64("session_id", sessionID), zap.Error(rerr))
+		}
+	}
+	return pair, err
+}
+
+// Revoke 吊销单个会话，其 access token 立即失效
+func Revoke(sessionID int64) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		var s models.UserSession
+		if err := tx.First(&s, sessionID).Error; err != nil {
+			return err
+		}
+		return revoke(tx, &s)
+	})
+}
+
+// RevokeUser 吊销用户的全部会话，用于封禁、修改或重置密码
+func RevokeUser(userID int64) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		var sessions []models.UserSession
+		if err := tx.Where("user_id = ? AND revoked_at IS NULL", userID).Find(&sessions).Error; err != nil {
+			return err
+		}
+		for i := range sessions {
+			if err := revoke(tx, &sessions[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RevokeOthers 吊销用户除当前会话外的所有会话，即“退出其他设备”
+func RevokeOthers(userID, keepSessionID int64) (int, error) {
+	var n int
+	err := mysql.DB.Transaction(func(tx *gorm.DB) error {
+		var sessions []models.UserSession
+		if err := tx.Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+			Find(&sessions).Error; err != nil {
+			return err
+		}
+		for i := range sessions {
+			if err := revoke(tx, &sessions[i]); err != nil {
+				return err
+			}
+		}
+		n = len(sessions)
+		return nil
+	})
+	return n, err
+}
+
+// RevokeForUser 吊销属于指定用户的会话，会话不属于该用户时返回 gorm.ErrRecordNotFound
+func RevokeForUser(userID, sessionID int64) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		var s models.UserSession
+		if err := tx.Where("id = ? AND user_id = ?", sessionID, userID).First(&s).Error; err != nil {
+			return err
+		}
+		return revoke(tx, &s)
+	})
+}
+
+// List 用户当前有效的会话，最近使用的在前
+func List(userID int64) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := mysql.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch 记录会话的最近使用时间和 IP，同一会话每分钟最多写一次库
+func Touch(sessionID int64, ip string) {
+	now := time.Now()
+	if last, ok := touched.Load(sessionID); ok && now.Sub(last.(time.Time)) < touchInterval {
+		return
+	}
+	touched.Store(sessionID, now)
+	err := mysql.DB.Model(&models.UserSession{}).
+		Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"last_seen_at": &now, "ip": ip}).Error
+	if err != nil {
+		zap.L().Error("更新会话使用时间失败", zap.Int64("session_id", sessionID), zap.Error(err))
+	}
+}
+
+// IsRevoked 判断 access token 是否已被吊销
+func IsRevoked(jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	if _, ok := denylist.Load(jti); ok {
+		return true, nil
+	}
+	var rt models.RevokedToken
+	err := mysql.DB.Where("jti = ?", jti).Limit(1).Find(&rt).Error
+	if err != nil {
+		return false, err
+	}
+	if rt.JTI == "" {
+		return false, nil
+	}
+	denylist.Store(jti, rt.ExpiresAt)
+	return true, nil
+}
+
+func revoke(tx *gorm.DB, s *models.UserSession) error {
+	if s.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	if err := tx.Model(s).Update("revoked_at", &now).Error; err != nil {
+		return err
+	}
+	return denyJTI(tx, s.UserID, s.AccessJTI, s.AccessExpiresAt)
+}
+
+func denyJTI(tx *gorm.DB, userID int64, jti string, expiresAt time.Time) error {
+	if jti == "" || time.Now().After(expiresAt) {
+		return nil
+	}
+	rt := models.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rt).Error; err != nil {
+		return err
+	}
+	denylist.Store(jti, expiresAt)
+	return nil
+}
+
+func purgeExpired() {
+	now := time.Now()
+	if err := mysql.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		zap.L().Error("清理过期的令牌黑名单失败", zap.Error(err))
+	}
+	if err := mysql.DB.Where("expires_at < ?", now).Delete(&models.UserSession{}).Error; err != nil {
+		zap.L().Error("清理过期会话失败", zap.Error(err))
+	}
+	if err := mysql.DB.Where("expires_at < ?", now).Delete(&models.RotatedRefreshToken{}).Error; err != nil {
+		zap.L().Error("清理已轮换的刷新令牌失败", zap.Error(err))
+	}
+	denylist.Range(func(k, v interface{}) bool {
+		if now.After(v.(time.Time)) {
+			denylist.Delete(k)
+		}
+		return true
+	})
+	touched.Range(func(k, v interface{}) bool {
+		if now.Sub(v.(time.Time)) > touchInterval {
+			touched.Delete(k)
+		}
+		return true
+	})
+}
+
+func newTokenPair(s *models.UserSession, token, secret string) *TokenPair {
+	return &TokenPair{
+		AccessToken:  token,
+		RefreshToken: strconv.FormatInt(s.ID, 10) + "." + secret,
+		ExpiresIn:    int64(jwt.AccessTokenExpire().Seconds()),
+		SessionID:    s.ID,
+	}
+}
+
+// parseRefreshToken refresh token 格式为 <session_id>.<secret>，库中只保存 secret 的摘要
+func parseRefreshToken(token string) (int64, string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", ErrInvalidRefreshToken
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidRefreshToken
+	}
+	return id, parts[1], nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,113 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/TalkSphere/backend/deploy/sql/migrations"
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/jwt"
+	"github.com/TalkSphere/backend/pkg/migrate"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/session"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/setting"
+)
+
+func TestMain(m *testing.M) {
+	cfg := &setting.AuthConfig{
+		SigningKeyID: "test",
+		Keys:         []setting.JWTKey{{ID: "test", Algorithm: "HS256", Secret: "test-secret"}},
+	}
+	if err := jwt.Init(cfg); err != nil {
+		panic(err)
+	}
+	if err := snowflake.Init("2024-01-01", 1); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// openSQLite 在临时目录创建 SQLite 数据库并执行全部迁移
+func openSQLite(t *testing.T) {
+	t.Helper()
+	cfg := &setting.MysqlConfig{Driver: mysql.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")}
+	if err := mysql.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mysql.Close)
+	fsys, err := migrations.For(mysql.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(mysql.DB, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+}
+
+func issue(t *testing.T) *session.TokenPair {
+	t.Helper()
+	user := &models.User{ID: snowflake.GenID(), Username: "alice", Email: "alice@example.com", PasswordHash: "x", Status: 1}
+	if err := mysql.DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	pair, err := session.Issue(user, session.Client{UserAgent: "test", IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair
+}
+
+func isRevoked(t *testing.T, sessionID int64) bool {
+	t.Helper()
+	var s models.UserSession
+	if err := mysql.DB.First(&s, sessionID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return s.RevokedAt != nil
+}
+
+// 只知道会话 ID 的人猜错 secret 时不能让会话被吊销
+func TestRefreshWrongSecretKeepsSession(t *testing.T) {
+	openSQLite(t)
+	pair := issue(t)
+
+	forged := strconv.FormatInt(pair.SessionID, 10) + ".garbage"
+	if _, err := session.Refresh(forged, session.Client{}); !errors.Is(err, session.ErrInvalidRefreshToken) {
+		t.Fatalf("refresh with wrong secret = %v, want ErrInvalidRefreshToken", err)
+	}
+	if isRevoked(t, pair.SessionID) {
+		t.Fatal("session revoked after a wrong secret")
+	}
+	if _, err := session.Refresh(pair.RefreshToken, session.Client{}); err != nil {
+		t.Fatalf("refresh with the real token: %v", err)
+	}
+}
+
+// 再次出示已经轮换掉的令牌视为泄露，整个会话被吊销
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	openSQLite(t)
+	pair := issue(t)
+
+	next, err := session.Refresh(pair.RefreshToken, session.Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Refresh(pair.RefreshToken, session.Client{}); !errors.Is(err, session.ErrRefreshTokenReused) {
+		t.Fatalf("refresh with rotated token = %v, want ErrRefreshTokenReused", err)
+	}
+	if !isRevoked(t, pair.SessionID) {
+		t.Fatal("session not revoked after reuse")
+	}
+	if _, err := session.Refresh(next.RefreshToken, session.Client{}); !errors.Is(err, session.ErrSessionRevoked) {
+		t.Fatalf("refresh after revoke = %v, want ErrSessionRevoked", err)
+	}
+}
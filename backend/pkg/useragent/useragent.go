@@ -0,0 +1,111 @@
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device 从 User-Agent 中解析出的设备信息，只用于给用户展示“在哪里登录过”，不追求完全准确
+type Device struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Type    string `json:"type"` // desktop / mobile / tablet / bot / unknown
+}
+
+// 设备类型
+const (
+	TypeDesktop = "desktop"
+	TypeMobile  = "mobile"
+	TypeTablet  = "tablet"
+	TypeBot     = "bot"
+	TypeUnknown = "unknown"
+)
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// 顺序很重要：Edge、Opera 等基于 Chromium 的浏览器 UA 中同样带有 Chrome 和 Safari
+var browsers = []rule{
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"WeChat", regexp.MustCompile(`MicroMessenger/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"curl", regexp.MustCompile(`curl/([\d.]+)`)},
+	{"Postman", regexp.MustCompile(`PostmanRuntime/([\d.]+)`)},
+	{"Go", regexp.MustCompile(`Go-http-client/([\d.]+)`)},
+	{"Python", regexp.MustCompile(`python-requests/([\d.]+)`)},
+}
+
+var oses = []rule{
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).*? OS ([\d_]+)`)},
+	{"Android", regexp.MustCompile(`Android ([\d.]+)`)},
+	{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{"macOS", regexp.MustCompile(`Mac OS X ([\d_.]+)`)},
+	{"Chrome OS", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{"Linux", regexp.MustCompile(`Linux()`)},
+}
+
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+}
+
+// Parse 解析 User-Agent
+func Parse(ua string) Device {
+	d := Device{Browser: "Unknown", OS: "Unknown", Type: TypeUnknown}
+	if ua == "" {
+		return d
+	}
+
+	for _, r := range browsers {
+		if m := r.re.FindStringSubmatch(ua); m != nil {
+			d.Browser = r.name + " " + majorVersion(m[1])
+			break
+		}
+	}
+	for _, r := range oses {
+		m := r.re.FindStringSubmatch(ua)
+		if m == nil {
+			continue
+		}
+		version := strings.ReplaceAll(m[1], "_", ".")
+		if r.name == "Windows" {
+			if v, ok := windowsVersions[version]; ok {
+				version = v
+			}
+		}
+		d.OS = strings.TrimSpace(r.name + " " + version)
+		break
+	}
+
+	lower := strings.ToLower(ua)
+	switch {
+	case strings.Contains(lower, "bot") || strings.Contains(lower, "spider") || strings.Contains(lower, "crawler"):
+		d.Type = TypeBot
+	case strings.Contains(ua, "iPad") || (strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile")):
+		d.Type = TypeTablet
+	case strings.Contains(ua, "Mobile") || strings.Contains(ua, "iPhone"):
+		d.Type = TypeMobile
+	case d.OS != "Unknown":
+		d.Type = TypeDesktop
+	}
+	return d
+}
+
+// String 形如 “Chrome 120 on Windows 10”
+func (d Device) String() string {
+	return d.Browser + " on " + d.OS
+}
+
+func majorVersion(v string) string {
+	if i := strings.IndexByte(v, '.'); i > 0 {
+		return v[:i]
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package usertoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Issue 为用户签发指定用途的一次性令牌，同一用途下之前未使用的令牌会一并作废
+// 令牌格式为 <id>.<secret>，库中只保存 HMAC(secret_key, purpose|user_id|secret)
+func Issue(userID int64, purpose string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(b)
+	t := &models.UserToken{
+		ID:        snowflake.GenID(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: sign(purpose, userID, secret),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	err := mysql.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Create(t).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(t.ID, 10) + "." + secret, nil
+}
+
+// Consume 校验并消费令牌，返回令牌所属的用户ID
+// fn 在同一个事务中执行，失败时令牌不会被标记为已使用
+func Consume(token, purpose string, fn func(tx *gorm.DB, userID int64) error) (int64, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return 0, ErrInvalidToken
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	var userID int64
+	err = mysql.DB.Transaction(func(tx *gorm.DB) error {
+		var t models.UserToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if t.Purpose != purpose || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+			return ErrInvalidToken
+		}
+		if !hmac.Equal([]byte(sign(purpose, t.UserID, parts[1])), []byte(t.TokenHash)) {
+			return ErrInvalidToken
+		}
+		now := time.Now()
+		if err := tx.Model(&t).Update("used_at", &now).Error; err != nil {
+			return err
+		}
+		userID = t.UserID
+		if fn != nil {
+			return fn(tx, t.UserID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func sign(purpose string, userID int64, secret string) string {
+	mac := hmac.New(sha256.New, []byte(encrypt.DataKey()))
+	mac.Write([]byte(purpose + "|" + strconv.FormatInt(userID, 10) + "|" + secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// BoardRepository 板块
+type BoardRepository interface {
+	GetByID(ctx context.Context, id int64) (*models.Board, error)
+	// Exists 板块是否存在，activeOnly 为 true 时只算启用的板块
+	Exists(ctx context.Context, id int64, activeOnly bool) (bool, error)
+	List(ctx context.Context) ([]models.Board, error)
+	// Count 板块总数，包括停用的
+	Count(ctx context.Context) (int64, error)
+	Create(ctx context.Context, board *models.Board) error
+	// Update 按 board.ID 更新非零值字段
+	Update(ctx context.Context, board *models.Board) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type gormBoardRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormBoardRepository) GetByID(ctx context.Context, id int64) (*models.Board, error) {
+	var board models.Board
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Take(&board).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &board, nil
+}
+
+func (r *gormBoardRepository) Exists(ctx context.Context, id int64, activeOnly bool) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&models.Board{}).Where("id = ?", id)
+	if activeOnly {
+		query = query.Where("status = 1")
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormBoardRepository) List(ctx context.Context) ([]models.Board, error) {
+	var boards []models.Board
+	err := r.db.WithContext(ctx).Find(&boards).Error
+	return boards, err
+}
+
+func (r *gormBoardRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Board{}).Count(&count).Error
+	return count, err
+}
+
+func (r *gormBoardRepository) Create(ctx context.Context, board *models.Board) error {
+	return r.db.WithContext(ctx).Create(board).Error
+}
+
+func (r *gormBoardRepository) Update(ctx context.Context, board *models.Board) error {
+	return r.db.WithContext(ctx).Model(board).Updates(board).Error
+}
+
+func (r *gormBoardRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.Board{}, id).Error
+}
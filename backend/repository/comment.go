@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 顶级评论的排序方式
+const (
+	CommentSortHot = "hot"
+	CommentSortNew = "new"
+	CommentSortTop = "top"
+)
+
+// CommentRepository 评论
+type CommentRepository interface {
+	// GetByID 查询评论，包括已删除的
+	GetByID(ctx context.Context, id int64) (*models.Comment, error)
+	// GetActive 查询未删除的评论
+	GetActive(ctx context.Context, id int64) (*models.Comment, error)
+	Create(ctx context.Context, comment *models.Comment) error
+	// IncrCounter 计数字段加上 delta，column 为 Counter* 常量
+	IncrCounter(ctx context.Context, id int64, column string, delta int) error
+	// DeleteThread 软删除评论及以它为根的所有回复
+	DeleteThread(ctx context.Context, id int64) error
+	// CountActive 未删除的评论数，包括回复
+	CountActive(ctx context.Context) (int64, error)
+
+	// ListRoots 帖子下未删除的顶级评论，sort 为 CommentSort* 常量
+	ListRoots(ctx context.Context, postID int64, sort string, page, size int) ([]models.Comment, int64, error)
+	// ListReplies 帖子下所有未删除的回复，按时间正序
+	ListReplies(ctx context.Context, postID int64) ([]models.Comment, error)
+}
+
+type gormCommentRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormCommentRepository) GetByID(ctx context.Context, id int64) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormCommentRepository) GetActive(ctx context.Context, id int64) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Where("id = ? AND status = 1", id).First(&comment).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *gormCommentRepository) IncrCounter(ctx context.Context, id int64, column string, delta int) error {
+	return incr(ctx, r.db, &models.Comment{}, id, column, delta)
+}
+
+func (r *gormCommentRepository) DeleteThread(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("id = ? OR root_id = ?", id, id).
+		Update("status", -1).Error
+}
+
+func (r *gormCommentRepository) CountActive(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).Where("status = ?", 1).Count(&count).Error
+	return count, err
+}
+
+func (r *gormCommentRepository) ListRoots(ctx context.Context, postID int64, sort string, page, size int) ([]models.Comment, int64, error) {
+	base := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("post_id = ? AND status = 1 AND parent_id IS NULL", postID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := base
+	switch sort {
+	case CommentSortHot:
+		query = query.Order("score DESC, created_at DESC")
+	case CommentSortNew:
+		query = query.Order("created_at DESC")
+	case CommentSortTop:
+		query = query.Order("score DESC")
+	}
+	var comments []models.Comment
+	if err := query.Offset(offset(page, size)).Limit(size).Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+func (r *gormCommentRepository) ListReplies(ctx context.Context, postID int64) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).
+		Where("post_id = ? AND status = 1 AND parent_id IS NOT NULL", postID).
+		Order("created_at ASC").
+		Find(&comments).Error
+	return comments, err
+}
@@ -0,0 +1,179 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/TalkSphere/backend/deploy/sql/migrations"
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/migrate"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/setting"
+)
+
+// newSQLite 在临时目录创建 SQLite 数据库，执行全部迁移后返回 GORM 实现
+func newSQLite(t *testing.T) *repository.Repositories {
+	t.Helper()
+	cfg := &setting.MysqlConfig{Driver: mysql.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")}
+	if err := mysql.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mysql.Close)
+	fsys, err := migrations.For(mysql.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(mysql.DB, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	return repository.NewGorm(mysql.DB)
+}
+
+// GORM 实现和内存实现对同一组操作的结果应当一致
+func TestImplementations(t *testing.T) {
+	impls := map[string]func(t *testing.T) *repository.Repositories{
+		"gorm":   newSQLite,
+		"memory": func(*testing.T) *repository.Repositories { return repository.NewMemory() },
+	}
+	for name, open := range impls {
+		t.Run(name, func(t *testing.T) {
+			testUsers(t, open(t))
+			testInteractions(t, open(t))
+			testIdentities(t, open(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, repos *repository.Repositories) {
+	ctx := context.Background()
+	alice := &models.User{ID: 1001, Username: "alice", Email: "alice@example.com", PasswordHash: "x", Status: 1}
+	if err := repos.Users.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	dup := &models.User{ID: 1002, Username: "alice", Email: "other@example.com", PasswordHash: "x"}
+	if err := repos.Users.Create(ctx, dup); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("duplicate username: err = %v, want ErrDuplicate", err)
+	}
+
+	got, err := repos.Users.GetByUsername(ctx, "alice")
+	if err != nil || got.ID != alice.ID {
+		t.Fatalf("GetByUsername = %v, %v", got, err)
+	}
+	if _, err := repos.Users.GetByUsername(ctx, "nobody"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown username: err = %v, want ErrNotFound", err)
+	}
+	if ok, err := repos.Users.ExistsByEmail(ctx, "alice@example.com"); err != nil || !ok {
+		t.Errorf("ExistsByEmail = %v, %v", ok, err)
+	}
+
+	if err := repos.Users.Update(ctx, alice.ID, map[string]interface{}{"status": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repos.Users.CountActive(ctx); err != nil || n != 0 {
+		t.Errorf("CountActive after disabling = %d, %v; want 0", n, err)
+	}
+}
+
+func testInteractions(t *testing.T, repos *repository.Repositories) {
+	ctx := context.Background()
+	user := &models.User{ID: 1001, Username: "alice", Email: "alice@example.com", PasswordHash: "x"}
+	if err := repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	board := &models.Board{Name: "综合"}
+	if err := repos.Boards.Create(ctx, board); err != nil {
+		t.Fatal(err)
+	}
+	post := &models.Post{Title: "标题", Content: "正文", BoardID: &board.ID, AuthorID: &user.ID}
+	if err := repos.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	like := func() error {
+		return repos.Likes.Create(ctx, &models.Like{UserID: user.ID, TargetID: post.ID, TargetType: models.LikeTargetPost})
+	}
+	if err := like(); err != nil {
+		t.Fatal(err)
+	}
+	if err := like(); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("second like: err = %v, want ErrDuplicate", err)
+	}
+	favorite := func() error {
+		return repos.Favorites.Create(ctx, &models.Favorite{UserID: user.ID, PostID: post.ID})
+	}
+	if err := favorite(); err != nil {
+		t.Fatal(err)
+	}
+	if err := favorite(); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("second favorite: err = %v, want ErrDuplicate", err)
+	}
+
+	if err := repos.Posts.IncrCounter(ctx, post.ID, repository.CounterLike, 1); err != nil {
+		t.Fatal(err)
+	}
+	liked, total, err := repos.Posts.ListLikedBy(ctx, user.ID, 1, 10)
+	if err != nil || total != 1 || liked[0].LikeCount != 1 || liked[0].Author == nil {
+		t.Fatalf("ListLikedBy = %+v, %d, %v", liked, total, err)
+	}
+	if n, err := repos.Posts.CountPublished(ctx); err != nil || n != 1 {
+		t.Errorf("CountPublished = %d, %v; want 1", n, err)
+	}
+	if n, err := repos.Boards.Count(ctx); err != nil || n != 1 {
+		t.Errorf("Count boards = %d, %v; want 1", n, err)
+	}
+}
+
+func testIdentities(t *testing.T, repos *repository.Repositories) {
+	ctx := context.Background()
+	alice := &models.User{ID: 1001, Username: "alice", Email: "alice@example.com", PasswordHash: "x"}
+	if err := repos.Users.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repos.Users.GetByEmail(ctx, "alice@example.com"); err != nil || got.ID != alice.ID {
+		t.Fatalf("GetByEmail = %v, %v", got, err)
+	}
+	if _, err := repos.Users.GetByEmail(ctx, "nobody@example.com"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown email: err = %v, want ErrNotFound", err)
+	}
+
+	if err := repos.Identities.Create(ctx, &models.UserIdentity{ID: 1, UserID: alice.ID, Provider: "mock", Subject: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	// 同一个第三方账号只能绑定一次，同一用户每个提供方也只能绑定一个账号
+	for _, dup := range []models.UserIdentity{
+		{ID: 2, UserID: 1002, Provider: "mock", Subject: "s1"},
+		{ID: 3, UserID: alice.ID, Provider: "mock", Subject: "s2"},
+	} {
+		if err := repos.Identities.Create(ctx, &dup); !errors.Is(err, repository.ErrDuplicate) {
+			t.Errorf("duplicate identity %+v: err = %v, want ErrDuplicate", dup, err)
+		}
+	}
+
+	got, err := repos.Identities.GetBySubject(ctx, "mock", "s1")
+	if err != nil || got.UserID != alice.ID {
+		t.Fatalf("GetBySubject = %v, %v", got, err)
+	}
+	if ok, err := repos.Identities.ExistsForProvider(ctx, alice.ID, "mock"); err != nil || !ok {
+		t.Errorf("ExistsForProvider = %v, %v", ok, err)
+	}
+	if n, err := repos.Identities.CountByUser(ctx, alice.ID); err != nil || n != 1 {
+		t.Errorf("CountByUser = %d, %v; want 1", n, err)
+	}
+
+	if err := repos.Identities.Delete(ctx, alice.ID, "mock"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Identities.Delete(ctx, alice.ID, "mock"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second delete: err = %v, want ErrNotFound", err)
+	}
+	if identities, err := repos.Identities.ListByUser(ctx, alice.ID); err != nil || len(identities) != 0 {
+		t.Errorf("ListByUser after delete = %+v, %v", identities, err)
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// IdentityRepository 用户绑定的第三方账号
+type IdentityRepository interface {
+	// GetBySubject 按提供方和提供方的用户标识查询，没有绑定时返回 ErrNotFound
+	GetBySubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	// ListByUser 用户绑定的第三方账号，按绑定时间排序
+	ListByUser(ctx context.Context, userID int64) ([]models.UserIdentity, error)
+	CountByUser(ctx context.Context, userID int64) (int64, error)
+	// ExistsForProvider 用户是否已经绑定了该提供方的账号
+	ExistsForProvider(ctx context.Context, userID int64, provider string) (bool, error)
+	// Create 添加绑定，第三方账号已被绑定或用户已绑定该提供方时返回 ErrDuplicate
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	// Delete 解绑用户的某个提供方，没有绑定时返回 ErrNotFound
+	Delete(ctx context.Context, userID int64, provider string) error
+}
+
+type gormIdentityRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormIdentityRepository) GetBySubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).Take(&identity).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &identity, nil
+}
+
+func (r *gormIdentityRepository) ListByUser(ctx context.Context, userID int64) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+func (r *gormIdentityRepository) CountByUser(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *gormIdentityRepository) ExistsForProvider(ctx context.Context, userID int64, provider string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	return duplicate(r.db.WithContext(ctx).Create(identity).Error)
+}
+
+func (r *gormIdentityRepository) Delete(ctx context.Context, userID int64, provider string) error {
+	res := r.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// LikeRepository 帖子和评论的点赞
+type LikeRepository interface {
+	// Find 查询用户对目标的点赞，没有点赞时返回 ErrNotFound
+	Find(ctx context.Context, userID, targetID int64, targetType int8) (*models.Like, error)
+	Exists(ctx context.Context, userID, targetID int64, targetType int8) (bool, error)
+	// Create 添加点赞，已经点赞过时返回 ErrDuplicate
+	Create(ctx context.Context, like *models.Like) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// FavoriteRepository 帖子收藏
+type FavoriteRepository interface {
+	// Find 查询用户对帖子的收藏，没有收藏时返回 ErrNotFound
+	Find(ctx context.Context, userID, postID int64) (*models.Favorite, error)
+	// Create 添加收藏，已经收藏过时返回 ErrDuplicate
+	Create(ctx context.Context, favorite *models.Favorite) error
+	Delete(ctx context.Context, id int64) error
+	// ListByUser 用户的收藏，带帖子及其标签
+	ListByUser(ctx context.Context, userID int64, page, size int) ([]models.Favorite, int64, error)
+}
+
+type gormLikeRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormLikeRepository) Find(ctx context.Context, userID, targetID int64, targetType int8) (*models.Like, error) {
+	var like models.Like
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND target_id = ? AND target_type = ?", userID, targetID, targetType).
+		First(&like).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &like, nil
+}
+
+func (r *gormLikeRepository) Exists(ctx context.Context, userID, targetID int64, targetType int8) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Like{}).
+		Where("user_id = ? AND target_id = ? AND target_type = ?", userID, targetID, targetType).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormLikeRepository) Create(ctx context.Context, like *models.Like) error {
+	return duplicate(r.db.WithContext(ctx).Create(like).Error)
+}
+
+func (r *gormLikeRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.Like{}, id).Error
+}
+
+type gormFavoriteRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormFavoriteRepository) Find(ctx context.Context, userID, postID int64) (*models.Favorite, error) {
+	var favorite models.Favorite
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND post_id = ?", userID, postID).First(&favorite).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &favorite, nil
+}
+
+func (r *gormFavoriteRepository) Create(ctx context.Context, favorite *models.Favorite) error {
+	return duplicate(r.db.WithContext(ctx).Create(favorite).Error)
+}
+
+func (r *gormFavoriteRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&models.Favorite{}, id).Error
+}
+
+func (r *gormFavoriteRepository) ListByUser(ctx context.Context, userID int64, page, size int) ([]models.Favorite, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Favorite{}).Where("user_id = ?", userID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var favorites []models.Favorite
+	err := query.Preload("Post").Preload("Post.Tags").
+		Offset(offset(page, size)).
+		Limit(size).
+		Find(&favorites).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return favorites, total, nil
+}
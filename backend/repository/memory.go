@@ -0,0 +1,987 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm/schema"
+)
+
+// NewMemory 基于内存的实现，数据保存在进程内，供测试使用
+// 不支持事务，Transaction 直接执行 fn，fn 返回错误时已写入的数据不会回滚
+func NewMemory() *Repositories {
+	s := &memoryStore{
+		users:      map[int64]*models.User{},
+		posts:      map[int64]*models.Post{},
+		comments:   map[int64]*models.Comment{},
+		boards:     map[int64]*models.Board{},
+		likes:      map[int64]*models.Like{},
+		favorites:  map[int64]*models.Favorite{},
+		tags:       map[int64]*models.Tag{},
+		postTags:   map[int64][]int64{},
+		images:     map[int64]*models.PostImage{},
+		identities: map[int64]*models.UserIdentity{},
+	}
+	return &Repositories{
+		Posts:      &memoryPostRepository{s},
+		Comments:   &memoryCommentRepository{s},
+		Users:      &memoryUserRepository{s},
+		Likes:      &memoryLikeRepository{s},
+		Favorites:  &memoryFavoriteRepository{s},
+		Boards:     &memoryBoardRepository{s},
+		Identities: &memoryIdentityRepository{s},
+	}
+}
+
+// memoryStore 各仓储共用的数据，所有读写都持有 mu
+// 返回给调用方的都是副本，调用方修改返回值不会影响保存的数据
+type memoryStore struct {
+	mu     sync.Mutex
+	lastID int64
+
+	users      map[int64]*models.User
+	posts      map[int64]*models.Post
+	comments   map[int64]*models.Comment
+	boards     map[int64]*models.Board
+	likes      map[int64]*models.Like
+	favorites  map[int64]*models.Favorite
+	tags       map[int64]*models.Tag
+	postTags   map[int64][]int64 // 帖子 ID -> 标签 ID，按添加顺序
+	images     map[int64]*models.PostImage
+	identities map[int64]*models.UserIdentity
+}
+
+// nextID 分配自增 ID，调用方已经提供 ID（例如雪花 ID）时沿用
+func (s *memoryStore) nextID(id int64) int64 {
+	if id != 0 {
+		if id > s.lastID {
+			s.lastID = id
+		}
+		return id
+	}
+	s.lastID++
+	return s.lastID
+}
+
+// sortedIDs map 的键按从小到大排列，用于得到稳定的顺序
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// paginate 按 GORM 实现相同的规则截取第 page 页
+func paginate[T any](items []T, page, size int) []T {
+	start := offset(page, size)
+	if start >= len(items) {
+		return []T{}
+	}
+	end := len(items)
+	if size > 0 && start+size < end {
+		end = start + size
+	}
+	return items[start:end]
+}
+
+// contains 模拟 LIKE '%sub%'，与 MySQL 默认排序规则一样不区分大小写
+func contains(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+var naming = schema.NamingStrategy{}
+
+// column 返回 model 中列名为 name 的字段，model 必须是结构体指针
+func column(model interface{}, name string) (reflect.Value, error) {
+	v := reflect.ValueOf(model).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		col := naming.ColumnName("", f.Name)
+		for _, opt := range strings.Split(f.Tag.Get("gorm"), ";") {
+			if strings.HasPrefix(opt, "column:") {
+				col = strings.TrimPrefix(opt, "column:")
+			}
+		}
+		if col == name {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown column %q", name)
+}
+
+// applyFields 按列名把 fields 写入 model，与 GORM 的 Updates(map) 对应
+func applyFields(model interface{}, fields map[string]interface{}) error {
+	for name, value := range fields {
+		f, err := column(model, name)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			f.Set(reflect.Zero(f.Type()))
+			continue
+		}
+		v := reflect.ValueOf(value)
+		switch {
+		case v.Type().AssignableTo(f.Type()):
+			f.Set(v)
+		case f.Kind() == reflect.Ptr && v.Type().ConvertibleTo(f.Type().Elem()):
+			p := reflect.New(f.Type().Elem())
+			p.Elem().Set(v.Convert(f.Type().Elem()))
+			f.Set(p)
+		case v.Type().ConvertibleTo(f.Type()):
+			f.Set(v.Convert(f.Type()))
+		default:
+			return fmt.Errorf("cannot assign %T to column %q", value, name)
+		}
+	}
+	return nil
+}
+
+// incrField 计数字段加上 delta，与 incr 一样只允许 counters 中的字段
+func incrField(model interface{}, name string, delta int) error {
+	if !counters[name] {
+		return fmt.Errorf("unknown counter column %q", name)
+	}
+	f, err := column(model, name)
+	if err != nil {
+		return err
+	}
+	f.SetInt(f.Int() + int64(delta))
+	return nil
+}
+
+// stamp 创建记录时补上 GORM 会自动填充的创建、更新时间
+func stamp(created, updated *time.Time) {
+	now := time.Now()
+	if created != nil && created.IsZero() {
+		*created = now
+	}
+	if updated != nil && updated.IsZero() {
+		*updated = now
+	}
+}
+
+type memoryUserRepository struct {
+	s *memoryStore
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	u := *user
+	return &u, nil
+}
+
+func (r *memoryUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, id := range sortedIDs(r.s.users) {
+		if r.s.users[id].Username == username {
+			u := *r.s.users[id]
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, id := range sortedIDs(r.s.users) {
+		if r.s.users[id].Email == email {
+			u := *r.s.users[id]
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	_, ok := r.s.users[id]
+	return ok, nil
+}
+
+func (r *memoryUserRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	_, err := r.GetByUsername(ctx, username)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, user := range r.s.users {
+		if user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		if u.ID == user.ID || u.Username == user.Username || u.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	user.ID = r.s.nextID(user.ID)
+	stamp(&user.CreatedAt, &user.UpdatedAt)
+	if user.Status == 0 {
+		user.Status = 1
+	}
+	u := *user
+	r.s.users[user.ID] = &u
+	return nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, id int64, fields map[string]interface{}) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	user, ok := r.s.users[id]
+	if !ok || len(fields) == 0 {
+		return nil
+	}
+	u := *user
+	if err := applyFields(&u, fields); err != nil {
+		return err
+	}
+	u.UpdatedAt = time.Now()
+	r.s.users[id] = &u
+	return nil
+}
+
+func (r *memoryUserRepository) ListActive(ctx context.Context, keyword string, page, size int) ([]models.User, int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var users []models.User
+	for _, id := range sortedIDs(r.s.users) {
+		u := r.s.users[id]
+		if u.Status != 1 {
+			continue
+		}
+		if keyword != "" && !contains(u.Username, keyword) && !contains(strconv.FormatInt(u.ID, 10), keyword) &&
+			!contains(u.Email, keyword) && !contains(u.Bio, keyword) {
+			continue
+		}
+		users = append(users, *u)
+	}
+	return paginate(users, page, size), int64(len(users)), nil
+}
+
+func (r *memoryUserRepository) ListBriefs(ctx context.Context, ids []int64) ([]models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	want := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	users := []models.User{}
+	for _, id := range sortedIDs(r.s.users) {
+		if u := r.s.users[id]; want[id] {
+			users = append(users, models.User{ID: u.ID, Username: u.Username, AvatarURL: u.AvatarURL})
+		}
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) CountActive(ctx context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var count int64
+	for _, u := range r.s.users {
+		if u.Status == 1 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type memoryPostRepository struct {
+	s *memoryStore
+}
+
+// load 复制帖子并按需填充作者、标签和图片，activeImages 为 true 时只带有效图片并按顺序排列
+func (r *memoryPostRepository) load(post *models.Post, withTags, activeImages bool) models.Post {
+	p := *post
+	p.Author, p.Tags, p.Images = nil, nil, nil
+	if p.AuthorID != nil {
+		if u, ok := r.s.users[*p.AuthorID]; ok {
+			author := *u
+			p.Author = &author
+		}
+	}
+	if withTags {
+		for _, id := range r.s.postTags[p.ID] {
+			p.Tags = append(p.Tags, *r.s.tags[id])
+		}
+	}
+	for _, id := range sortedIDs(r.s.images) {
+		img := r.s.images[id]
+		if img.PostID != p.ID || (activeImages && img.Status != 1) {
+			continue
+		}
+		p.Images = append(p.Images, *img)
+	}
+	if activeImages {
+		sort.SliceStable(p.Images, func(i, j int) bool { return p.Images[i].SortOrder < p.Images[j].SortOrder })
+	}
+	return p
+}
+
+func (r *memoryPostRepository) GetByID(ctx context.Context, id int64) (*models.Post, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	post, ok := r.s.posts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	p := *post
+	return &p, nil
+}
+
+func (r *memoryPostRepository) GetActive(ctx context.Context, id int64) (*models.Post, error) {
+	post, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status == -1 {
+		return nil, ErrNotFound
+	}
+	return post, nil
+}
+
+func (r *memoryPostRepository) GetDetail(ctx context.Context, id int64) (*models.Post, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	post, ok := r.s.posts[id]
+	if !ok || post.Status == -1 {
+		return nil, ErrNotFound
+	}
+	p := r.load(post, true, true)
+	p.Author = nil
+	return &p, nil
+}
+
+func (r *memoryPostRepository) Create(ctx context.Context, post *models.Post) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	post.ID = r.s.nextID(post.ID)
+	stamp(&post.CreatedAt, &post.UpdatedAt)
+	if post.Status == 0 {
+		post.Status = 1
+	}
+	p := *post
+	p.Author, p.Tags, p.Images = nil, nil, nil
+	r.s.posts[post.ID] = &p
+	return nil
+}
+
+func (r *memoryPostRepository) Update(ctx context.Context, id int64, fields map[string]interface{}) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	post, ok := r.s.posts[id]
+	if !ok || len(fields) == 0 {
+		return nil
+	}
+	p := *post
+	if err := applyFields(&p, fields); err != nil {
+		return err
+	}
+	p.UpdatedAt = time.Now()
+	r.s.posts[id] = &p
+	return nil
+}
+
+func (r *memoryPostRepository) IncrCounter(ctx context.Context, id int64, column string, delta int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	post, ok := r.s.posts[id]
+	if !ok {
+		return nil
+	}
+	return incrField(post, column, delta)
+}
+
+func (r *memoryPostRepository) CountPublished(ctx context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var count int64
+	for _, p := range r.s.posts {
+		if p.Status == 1 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryPostRepository) ListByBoard(ctx context.Context, q PostQuery) ([]models.Post, int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var posts []models.Post
+	for _, id := range sortedIDs(r.s.posts) {
+		p := r.s.posts[id]
+		if p.BoardID == nil || *p.BoardID != q.BoardID || p.Status == -1 {
+			continue
+		}
+		post := r.load(p, false, false)
+		if q.Search != "" {
+			switch q.SearchType {
+			case SearchByUsername:
+				if post.Author == nil || !contains(post.Author.Username, q.Search) {
+					continue
+				}
+			case SearchByContent:
+				if !contains(post.Content, q.Search) {
+					continue
+				}
+			case SearchAll:
+				if !contains(post.Title, q.Search) && !contains(post.Content, q.Search) {
+					continue
+				}
+			}
+		}
+		posts = append(posts, post)
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		if posts[i].IsPinned != posts[j].IsPinned {
+			return posts[i].IsPinned
+		}
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	return paginate(posts, q.Page, q.Size), int64(len(posts)), nil
+}
+
+// listBy 未删除的帖子中 at 返回 true 的，按 at 返回的时间倒序，带作者、标签和图片
+func (r *memoryPostRepository) listBy(page, size int, at func(p *models.Post) (time.Time, bool)) ([]models.Post, int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var (
+		posts []models.Post
+		times = map[int64]time.Time{}
+	)
+	for _, id := range sortedIDs(r.s.posts) {
+		p := r.s.posts[id]
+		t, ok := at(p)
+		if !ok || p.Status == -1 {
+			continue
+		}
+		times[p.ID] = t
+		posts = append(posts, r.load(p, true, false))
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return times[posts[i].ID].After(times[posts[j].ID]) })
+	return paginate(posts, page, size), int64(len(posts)), nil
+}
+
+func (r *memoryPostRepository) ListByAuthor(ctx context.Context, authorID int64, page, size int) ([]models.Post, int64, error) {
+	return r.listBy(page, size, func(p *models.Post) (time.Time, bool) {
+		return p.CreatedAt, p.AuthorID != nil && *p.AuthorID == authorID
+	})
+}
+
+func (r *memoryPostRepository) ListLikedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return r.listBy(page, size, func(p *models.Post) (time.Time, bool) {
+		for _, l := range r.s.likes {
+			if l.UserID == userID && l.TargetID == p.ID && l.TargetType == models.LikeTargetPost {
+				return l.CreatedAt, true
+			}
+		}
+		return time.Time{}, false
+	})
+}
+
+func (r *memoryPostRepository) ListFavoritedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return r.listBy(page, size, func(p *models.Post) (time.Time, bool) {
+		for _, f := range r.s.favorites {
+			if f.UserID == userID && f.PostID == p.ID {
+				return f.CreatedAt, true
+			}
+		}
+		return time.Time{}, false
+	})
+}
+
+func (r *memoryPostRepository) ListCommentedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return r.listBy(page, size, func(p *models.Post) (time.Time, bool) {
+		var (
+			last  time.Time
+			found bool
+		)
+		for _, c := range r.s.comments {
+			if c.UserID == userID && c.PostID == p.ID && c.Status == 1 {
+				if !found || c.CreatedAt.After(last) {
+					last = c.CreatedAt
+				}
+				found = true
+			}
+		}
+		return last, found
+	})
+}
+
+func (r *memoryPostRepository) SetTags(ctx context.Context, postID int64, names []string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ids := []int64{}
+	seen := make(map[int64]bool, len(names))
+	for _, name := range names {
+		var tag *models.Tag
+		for _, t := range r.s.tags {
+			if t.Name == name {
+				tag = t
+				break
+			}
+		}
+		if tag == nil {
+			tag = &models.Tag{ID: r.s.nextID(0), Name: name, CreatedAt: time.Now()}
+			r.s.tags[tag.ID] = tag
+		}
+		if seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+		ids = append(ids, tag.ID)
+	}
+	r.s.postTags[postID] = ids
+	return nil
+}
+
+func (r *memoryPostRepository) CreateImage(ctx context.Context, img *models.PostImage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	img.ID = r.s.nextID(img.ID)
+	stamp(&img.CreatedAt, nil)
+	if img.Status == 0 {
+		img.Status = 1
+	}
+	i := *img
+	r.s.images[img.ID] = &i
+	return nil
+}
+
+func (r *memoryPostRepository) SetImages(ctx context.Context, postID, userID int64, imageIDs []int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// 没有事务可以回滚，先校验再修改
+	valid := map[int64]bool{}
+	for _, id := range imageIDs {
+		img, ok := r.s.images[id]
+		if ok && img.UserID == userID && img.Status == 1 && (img.PostID == 0 || img.PostID == postID) {
+			valid[id] = true
+		}
+	}
+	if len(valid) != len(imageIDs) {
+		return ErrInvalidImages
+	}
+	for id, img := range r.s.images {
+		if img.PostID == postID && !valid[id] {
+			img.PostID = 0
+		}
+	}
+	for i, id := range imageIDs {
+		r.s.images[id].PostID = postID
+		r.s.images[id].SortOrder = i
+	}
+	return nil
+}
+
+type memoryCommentRepository struct {
+	s *memoryStore
+}
+
+func (r *memoryCommentRepository) GetByID(ctx context.Context, id int64) (*models.Comment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comment, ok := r.s.comments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *comment
+	return &c, nil
+}
+
+func (r *memoryCommentRepository) GetActive(ctx context.Context, id int64) (*models.Comment, error) {
+	comment, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Status != 1 {
+		return nil, ErrNotFound
+	}
+	return comment, nil
+}
+
+func (r *memoryCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comment.ID = r.s.nextID(comment.ID)
+	stamp(&comment.CreatedAt, &comment.UpdatedAt)
+	if comment.Status == 0 {
+		comment.Status = 1
+	}
+	c := *comment
+	c.Children, c.User = nil, nil
+	r.s.comments[comment.ID] = &c
+	return nil
+}
+
+func (r *memoryCommentRepository) IncrCounter(ctx context.Context, id int64, column string, delta int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comment, ok := r.s.comments[id]
+	if !ok {
+		return nil
+	}
+	return incrField(comment, column, delta)
+}
+
+func (r *memoryCommentRepository) DeleteThread(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, c := range r.s.comments {
+		if c.ID == id || (c.RootID != nil && *c.RootID == id) {
+			c.Status = -1
+		}
+	}
+	return nil
+}
+
+func (r *memoryCommentRepository) CountActive(ctx context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var count int64
+	for _, c := range r.s.comments {
+		if c.Status == 1 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// list 帖子下未删除的评论，replies 为 true 时只取回复，否则只取顶级评论
+func (r *memoryCommentRepository) list(postID int64, replies bool) []models.Comment {
+	comments := []models.Comment{}
+	for _, id := range sortedIDs(r.s.comments) {
+		c := r.s.comments[id]
+		if c.PostID == postID && c.Status == 1 && (c.ParentID != nil) == replies {
+			comments = append(comments, *c)
+		}
+	}
+	return comments
+}
+
+func (r *memoryCommentRepository) ListRoots(ctx context.Context, postID int64, sortBy string, page, size int) ([]models.Comment, int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comments := r.list(postID, false)
+	newer := func(i, j int) bool { return comments[i].CreatedAt.After(comments[j].CreatedAt) }
+	switch sortBy {
+	case CommentSortHot:
+		sort.SliceStable(comments, func(i, j int) bool {
+			if comments[i].Score != comments[j].Score {
+				return comments[i].Score > comments[j].Score
+			}
+			return newer(i, j)
+		})
+	case CommentSortNew:
+		sort.SliceStable(comments, newer)
+	case CommentSortTop:
+		sort.SliceStable(comments, func(i, j int) bool { return comments[i].Score > comments[j].Score })
+	}
+	return paginate(comments, page, size), int64(len(comments)), nil
+}
+
+func (r *memoryCommentRepository) ListReplies(ctx context.Context, postID int64) ([]models.Comment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comments := r.list(postID, true)
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	return comments, nil
+}
+
+type memoryLikeRepository struct {
+	s *memoryStore
+}
+
+func (r *memoryLikeRepository) Find(ctx context.Context, userID, targetID int64, targetType int8) (*models.Like, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, id := range sortedIDs(r.s.likes) {
+		l := r.s.likes[id]
+		if l.UserID == userID && l.TargetID == targetID && l.TargetType == targetType {
+			like := *l
+			return &like, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryLikeRepository) Exists(ctx context.Context, userID, targetID int64, targetType int8) (bool, error) {
+	_, err := r.Find(ctx, userID, targetID, targetType)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryLikeRepository) Create(ctx context.Context, like *models.Like) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, l := range r.s.likes {
+		if l.UserID == like.UserID && l.TargetID == like.TargetID && l.TargetType == like.TargetType {
+			return ErrDuplicate
+		}
+	}
+	like.ID = r.s.nextID(like.ID)
+	stamp(&like.CreatedAt, nil)
+	l := *like
+	l.User = nil
+	r.s.likes[like.ID] = &l
+	return nil
+}
+
+func (r *memoryLikeRepository) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.likes, id)
+	return nil
+}
+
+type memoryFavoriteRepository struct {
+	s *memoryStore
+}
+
+func (r *memoryFavoriteRepository) Find(ctx context.Context, userID, postID int64) (*models.Favorite, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, id := range sortedIDs(r.s.favorites) {
+		f := r.s.favorites[id]
+		if f.UserID == userID && f.PostID == postID {
+			favorite := *f
+			return &favorite, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryFavoriteRepository) Create(ctx context.Context, favorite *models.Favorite) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, f := range r.s.favorites {
+		if f.UserID == favorite.UserID && f.PostID == favorite.PostID {
+			return ErrDuplicate
+		}
+	}
+	favorite.ID = r.s.nextID(favorite.ID)
+	stamp(&favorite.CreatedAt, nil)
+	f := *favorite
+	f.User, f.Post = nil, nil
+	r.s.favorites[favorite.ID] = &f
+	return nil
+}
+
+func (r *memoryFavoriteRepository) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.favorites, id)
+	return nil
+}
+
+func (r *memoryFavoriteRepository) ListByUser(ctx context.Context, userID int64, page, size int) ([]models.Favorite, int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var favorites []models.Favorite
+	for _, id := range sortedIDs(r.s.favorites) {
+		f := *r.s.favorites[id]
+		if f.UserID != userID {
+			continue
+		}
+		if p, ok := r.s.posts[f.PostID]; ok {
+			post := (&memoryPostRepository{r.s}).load(p, true, false)
+			post.Author, post.Images = nil, nil
+			f.Post = &post
+		}
+		favorites = append(favorites, f)
+	}
+	return paginate(favorites, page, size), int64(len(favorites)), nil
+}
+
+type memoryBoardRepository struct {
+	s *memoryStore
+}
+
+func (r *memoryBoardRepository) GetByID(ctx context.Context, id int64) (*models.Board, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	board, ok := r.s.boards[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	b := *board
+	return &b, nil
+}
+
+func (r *memoryBoardRepository) Exists(ctx context.Context, id int64, activeOnly bool) (bool, error) {
+	board, err := r.GetByID(ctx, id)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !activeOnly || board.Status == 1, nil
+}
+
+func (r *memoryBoardRepository) List(ctx context.Context) ([]models.Board, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	boards := []models.Board{}
+	for _, id := range sortedIDs(r.s.boards) {
+		boards = append(boards, *r.s.boards[id])
+	}
+	return boards, nil
+}
+
+func (r *memoryBoardRepository) Count(ctx context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return int64(len(r.s.boards)), nil
+}
+
+func (r *memoryBoardRepository) Create(ctx context.Context, board *models.Board) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	board.ID = r.s.nextID(board.ID)
+	stamp(&board.CreatedAt, &board.UpdatedAt)
+	if board.Status == 0 {
+		board.Status = 1
+	}
+	b := *board
+	r.s.boards[board.ID] = &b
+	return nil
+}
+
+func (r *memoryBoardRepository) Update(ctx context.Context, board *models.Board) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	b, ok := r.s.boards[board.ID]
+	if !ok {
+		return nil
+	}
+	// 与 GORM 的 Updates(struct) 一样只更新非零值字段
+	if board.Name != "" {
+		b.Name = board.Name
+	}
+	if board.Description != "" {
+		b.Description = board.Description
+	}
+	if board.Status != 0 {
+		b.Status = board.Status
+	}
+	if board.SortOrder != 0 {
+		b.SortOrder = board.SortOrder
+	}
+	if board.CreatorID != 0 {
+		b.CreatorID = board.CreatorID
+	}
+	b.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memoryBoardRepository) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.boards, id)
+	return nil
+}
+
+type memoryIdentityRepository struct {
+	s *memoryStore
+}
+
+func (r *memoryIdentityRepository) GetBySubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, i := range r.s.identities {
+		if i.Provider == provider && i.Subject == subject {
+			identity := *i
+			return &identity, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryIdentityRepository) ListByUser(ctx context.Context, userID int64) ([]models.UserIdentity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var identities []models.UserIdentity
+	for _, id := range sortedIDs(r.s.identities) {
+		if i := r.s.identities[id]; i.UserID == userID {
+			identities = append(identities, *i)
+		}
+	}
+	sort.SliceStable(identities, func(i, j int) bool { return identities[i].CreatedAt.Before(identities[j].CreatedAt) })
+	return identities, nil
+}
+
+func (r *memoryIdentityRepository) CountByUser(ctx context.Context, userID int64) (int64, error) {
+	identities, err := r.ListByUser(ctx, userID)
+	return int64(len(identities)), err
+}
+
+func (r *memoryIdentityRepository) ExistsForProvider(ctx context.Context, userID int64, provider string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, i := range r.s.identities {
+		if i.UserID == userID && i.Provider == provider {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, i := range r.s.identities {
+		if i.Provider == identity.Provider && (i.Subject == identity.Subject || i.UserID == identity.UserID) {
+			return ErrDuplicate
+		}
+	}
+	identity.ID = r.s.nextID(identity.ID)
+	stamp(&identity.CreatedAt, &identity.UpdatedAt)
+	i := *identity
+	r.s.identities[identity.ID] = &i
+	return nil
+}
+
+func (r *memoryIdentityRepository) Delete(ctx context.Context, userID int64, provider string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, i := range r.s.identities {
+		if i.UserID == userID && i.Provider == provider {
+			delete(r.s.identities, id)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
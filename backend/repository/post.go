@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidImages 图片不存在、不属于当前用户或已被其他帖子使用
+var ErrInvalidImages = errors.New("invalid post images")
+
+// 板块帖子的搜索方式
+const (
+	SearchByUsername = "username"
+	SearchByContent  = "content"
+	SearchAll        = "all"
+)
+
+// PostQuery 板块帖子列表的查询条件
+type PostQuery struct {
+	BoardID    int64
+	Search     string
+	SearchType string
+	Page       int
+	Size       int
+}
+
+// PostRepository 帖子及其标签、图片
+type PostRepository interface {
+	// GetByID 查询帖子，包括已删除的
+	GetByID(ctx context.Context, id int64) (*models.Post, error)
+	// GetActive 查询未删除的帖子
+	GetActive(ctx context.Context, id int64) (*models.Post, error)
+	// GetDetail 查询未删除的帖子及其标签和有效图片
+	GetDetail(ctx context.Context, id int64) (*models.Post, error)
+	Create(ctx context.Context, post *models.Post) error
+	// Update 更新帖子的部分字段，fields 的键为列名
+	Update(ctx context.Context, id int64, fields map[string]interface{}) error
+	// IncrCounter 计数字段加上 delta，column 为 Counter* 常量
+	IncrCounter(ctx context.Context, id int64, column string, delta int) error
+	// CountPublished 已发布的帖子数，不含草稿和已删除的
+	CountPublished(ctx context.Context) (int64, error)
+
+	// ListByBoard 板块中未删除的帖子，置顶的在前，带作者和图片
+	ListByBoard(ctx context.Context, q PostQuery) ([]models.Post, int64, error)
+	// ListByAuthor 用户发布的帖子，以下几个列表都带作者、标签和图片
+	ListByAuthor(ctx context.Context, authorID int64, page, size int) ([]models.Post, int64, error)
+	// ListLikedBy 用户点赞过的帖子，按点赞时间倒序
+	ListLikedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error)
+	// ListFavoritedBy 用户收藏的帖子，按收藏时间倒序
+	ListFavoritedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error)
+	// ListCommentedBy 用户评论过的帖子，按最近一次评论时间倒序
+	ListCommentedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error)
+
+	// SetTags 把帖子的标签替换为 names，不存在的标签会被创建
+	SetTags(ctx context.Context, postID int64, names []string) error
+	// CreateImage 保存上传的图片，此时还没有关联帖子
+	CreateImage(ctx context.Context, img *models.PostImage) error
+	// SetImages 把帖子的图片替换为 imageIDs，按顺序排列；图片必须是 userID 上传且未被其他帖子使用的，
+	// 否则返回 ErrInvalidImages。不再使用的图片解除关联，不会删除
+	SetImages(ctx context.Context, postID, userID int64, imageIDs []int64) error
+}
+
+type gormPostRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormPostRepository) GetByID(ctx context.Context, id int64) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) GetActive(ctx context.Context, id int64) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Where("id = ? AND status != -1", id).First(&post).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) GetDetail(ctx context.Context, id int64) (*models.Post, error) {
+	var post models.Post
+	err := r.db.WithContext(ctx).
+		Preload("Tags").
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", 1).Order("sort_order")
+		}).
+		Where("status != ?", -1).
+		First(&post, id).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) Create(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
+}
+
+func (r *gormPostRepository) Update(ctx context.Context, id int64, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *gormPostRepository) IncrCounter(ctx context.Context, id int64, column string, delta int) error {
+	return incr(ctx, r.db, &models.Post{}, id, column, delta)
+}
+
+func (r *gormPostRepository) CountPublished(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Post{}).Where("status = ?", 1).Count(&count).Error
+	return count, err
+}
+
+func (r *gormPostRepository) ListByBoard(ctx context.Context, q PostQuery) ([]models.Post, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("posts.board_id = ? AND posts.status != -1", q.BoardID)
+	if q.Search != "" {
+		like := "%" + q.Search + "%"
+		switch q.SearchType {
+		case SearchByUsername:
+			db = db.Joins("JOIN users ON posts.author_id = users.id").
+				Where("users.username LIKE ?", like)
+		case SearchByContent:
+			db = db.Where("posts.content LIKE ?", like)
+		case SearchAll:
+			db = db.Where("(posts.title LIKE ? OR posts.content LIKE ?)", like, like)
+		}
+	}
+	return r.findPage(db.Session(&gorm.Session{}), q.Page, q.Size, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Author").
+			Preload("Images").
+			Order("posts.is_pinned DESC, posts.created_at DESC")
+	})
+}
+
+func (r *gormPostRepository) ListByAuthor(ctx context.Context, authorID int64, page, size int) ([]models.Post, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("posts.author_id = ? AND posts.status != -1", authorID)
+	return r.findPage(db.Session(&gorm.Session{}), page, size, func(db *gorm.DB) *gorm.DB {
+		return withAssociations(db).Order("posts.created_at DESC")
+	})
+}
+
+func (r *gormPostRepository) ListLikedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.Post{}).
+		Joins("JOIN likes ON posts.id = likes.target_id").
+		Where("likes.user_id = ? AND likes.target_type = ? AND posts.status != -1", userID, models.LikeTargetPost)
+	return r.findPage(db.Session(&gorm.Session{}), page, size, func(db *gorm.DB) *gorm.DB {
+		return withAssociations(db).Order("likes.created_at DESC")
+	})
+}
+
+func (r *gormPostRepository) ListFavoritedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.Post{}).
+		Joins("JOIN favorites ON posts.id = favorites.post_id").
+		Where("favorites.user_id = ? AND posts.status != -1", userID)
+	return r.findPage(db.Session(&gorm.Session{}), page, size, func(db *gorm.DB) *gorm.DB {
+		return withAssociations(db).Order("favorites.created_at DESC")
+	})
+}
+
+func (r *gormPostRepository) ListCommentedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	// 一个用户可能对同一个帖子评论多次，按帖子分组去重
+	db := r.db.WithContext(ctx).Model(&models.Post{}).
+		Joins("JOIN comments ON posts.id = comments.post_id").
+		Where("comments.user_id = ? AND comments.status = 1 AND posts.status != -1", userID).
+		Group("posts.id")
+	return r.findPage(db.Session(&gorm.Session{}), page, size, func(db *gorm.DB) *gorm.DB {
+		return withAssociations(db).Order("MAX(comments.created_at) DESC")
+	})
+}
+
+// findPage 统计 base 的总数并查询第 page 页，decorate 追加排序和预加载
+// 联表查询时只取帖子的列，避免和被联表的同名列混淆
+func (r *gormPostRepository) findPage(base *gorm.DB, page, size int, decorate func(*gorm.DB) *gorm.DB) ([]models.Post, int64, error) {
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var posts []models.Post
+	err := decorate(base.Select("posts.*")).
+		Offset(offset(page, size)).
+		Limit(size).
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+func withAssociations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Author").Preload("Tags").Preload("Images")
+}
+
+func (r *gormPostRepository) SetTags(ctx context.Context, postID int64, names []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostTag{}).Error; err != nil {
+			return err
+		}
+		seen := make(map[int64]bool, len(names))
+		for _, name := range names {
+			var tag models.Tag
+			if err := tx.Where("name = ?", name).FirstOrCreate(&tag, models.Tag{Name: name}).Error; err != nil {
+				return err
+			}
+			if seen[tag.ID] {
+				continue
+			}
+			seen[tag.ID] = true
+			if err := tx.Create(&models.PostTag{PostID: postID, TagID: tag.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormPostRepository) CreateImage(ctx context.Context, img *models.PostImage) error {
+	return r.db.WithContext(ctx).Create(img).Error
+}
+
+func (r *gormPostRepository) SetImages(ctx context.Context, postID, userID int64, imageIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		detach := tx.Model(&models.PostImage{}).Where("post_id = ?", postID)
+		if len(imageIDs) > 0 {
+			detach = detach.Where("id NOT IN ?", imageIDs)
+		}
+		if err := detach.Update("post_id", 0).Error; err != nil {
+			return err
+		}
+		if len(imageIDs) == 0 {
+			return nil
+		}
+
+		var count int64
+		if err := tx.Model(&models.PostImage{}).
+			Where("id IN ? AND user_id = ? AND status = 1 AND (post_id = 0 OR post_id = ?)", imageIDs, userID, postID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(imageIDs)) {
+			return ErrInvalidImages
+		}
+		for i, id := range imageIDs {
+			if err := tx.Model(&models.PostImage{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"post_id":    postID,
+				"sort_order": i,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,109 @@
+// Package repository 封装对数据库的访问，controller 和 service 通过注入的仓储接口读写数据，
+// 不再直接使用全局的 mysql.DB，测试时可以换成 SQLite 上的 GORM 实现或内存实现
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound 记录不存在，各实现都应返回这个错误而不是底层驱动的错误
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate 违反唯一约束，例如并发的重复点赞
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// Repositories 汇总各个仓储
+type Repositories struct {
+	Posts      PostRepository
+	Comments   CommentRepository
+	Users      UserRepository
+	Likes      LikeRepository
+	Favorites  FavoriteRepository
+	Boards     BoardRepository
+	Identities IdentityRepository
+
+	// runTx 在事务中执行 fn，传给 fn 的仓储都绑定到同一个事务；为空时直接执行
+	runTx func(ctx context.Context, fn func(tx *Repositories) error) error
+}
+
+// Transaction 在事务中执行 fn，fn 返回错误时回滚
+// 没有事务能力的实现（例如内存实现）直接用当前仓储执行 fn
+func (r *Repositories) Transaction(ctx context.Context, fn func(tx *Repositories) error) error {
+	if r.runTx == nil {
+		return fn(r)
+	}
+	return r.runTx(ctx, fn)
+}
+
+// NewGorm 基于 GORM 的实现
+func NewGorm(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Posts:      &gormPostRepository{db: db},
+		Comments:   &gormCommentRepository{db: db},
+		Users:      &gormUserRepository{db: db},
+		Likes:      &gormLikeRepository{db: db},
+		Favorites:  &gormFavoriteRepository{db: db},
+		Boards:     &gormBoardRepository{db: db},
+		Identities: &gormIdentityRepository{db: db},
+		runTx: func(ctx context.Context, fn func(tx *Repositories) error) error {
+			return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return fn(NewGorm(tx))
+			})
+		},
+	}
+}
+
+// 计数字段
+const (
+	CounterView     = "view_count"
+	CounterLike     = "like_count"
+	CounterFavorite = "favorite_count"
+	CounterComment  = "comment_count"
+	CounterReply    = "reply_count"
+)
+
+var counters = map[string]bool{
+	CounterView:     true,
+	CounterLike:     true,
+	CounterFavorite: true,
+	CounterComment:  true,
+	CounterReply:    true,
+}
+
+// offset 分页参数转换为偏移量，page 从 1 开始
+func offset(page, size int) int {
+	if page < 1 {
+		page = 1
+	}
+	return (page - 1) * size
+}
+
+// notFound 把 GORM 的记录不存在错误转换为 ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// duplicate 把 GORM 的唯一约束冲突错误转换为 ErrDuplicate，需要开启 gorm.Config.TranslateError
+func duplicate(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// incr 对 model 对应表中 id 行的计数字段加上 delta，column 必须是上面定义的计数字段
+func incr(ctx context.Context, db *gorm.DB, model interface{}, id int64, column string, delta int) error {
+	if !counters[column] {
+		return fmt.Errorf("unknown counter column %q", column)
+	}
+	return db.WithContext(ctx).Model(model).Where("id = ?", id).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TalkSphere/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// UserRepository 用户
+type UserRepository interface {
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+	// GetByUsername 按用户名查询，用户不存在时返回 ErrNotFound
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetByEmail 按邮箱查询，包括已封禁的用户，用户不存在时返回 ErrNotFound
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Exists(ctx context.Context, id int64) (bool, error)
+	// ExistsByUsername 用户名是否已被使用，包括已封禁的用户
+	ExistsByUsername(ctx context.Context, username string) (bool, error)
+	// ExistsByEmail 邮箱是否已被使用，包括已封禁的用户
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	// Create 添加用户，用户名或邮箱已被使用时返回 ErrDuplicate
+	Create(ctx context.Context, user *models.User) error
+	// Update 更新用户的部分字段，fields 的键为列名
+	Update(ctx context.Context, id int64, fields map[string]interface{}) error
+	// ListActive 未封禁的用户，keyword 不为空时按用户名、ID、邮箱和简介模糊匹配
+	ListActive(ctx context.Context, keyword string, page, size int) ([]models.User, int64, error)
+	// ListBriefs 只查询 ID、用户名和头像，按 ID 排序，用于在列表中展示用户
+	ListBriefs(ctx context.Context, ids []int64) ([]models.User, error)
+	// CountActive 未封禁的用户数
+	CountActive(ctx context.Context) (int64, error)
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormUserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).Take(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).Take(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUserRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return duplicate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *gormUserRepository) Update(ctx context.Context, id int64, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *gormUserRepository) ListActive(ctx context.Context, keyword string, page, size int) ([]models.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{}).Where("status = ?", 1)
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("username LIKE ? OR id LIKE ? OR email LIKE ? OR bio LIKE ?", like, like, like, like)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	if err := query.Offset(offset(page, size)).Limit(size).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *gormUserRepository) ListBriefs(ctx context.Context, ids []int64) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).
+		Select("id", "username", "avatar_url").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&users).Error
+	return users, err
+}
+
+func (r *gormUserRepository) CountActive(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("status = ?", 1).Count(&count).Error
+	return count, err
+}
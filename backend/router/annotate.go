@@ -0,0 +1,74 @@
+package router
+
+import (
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteAnnotation 路由的访问标注，用于检查路由与 Casbin 策略是否一致以及生成基线策略
+type RouteAnnotation struct {
+	Method string
+	Path   string   // 完整路径，gin 参数写法，keyMatch2 可以直接匹配
+	Public bool     // 不经过 RBAC 检查
+	Roles  []string // 基线策略中可以访问该路由的角色，继承关系由策略中的 g 规则处理
+}
+
+// access 注册路由时的访问标注
+type access struct {
+	public bool
+	roles  []string
+}
+
+// public 公开路由，不经过 RBAC 检查
+var public = access{public: true}
+
+// allow 需要认证的路由，标注基线策略中可以访问的角色
+func allow(roles ...string) access {
+	return access{roles: roles}
+}
+
+// annotations 最近一次 Setup 注册的路由标注
+var annotations []RouteAnnotation
+
+// Annotations 返回最近一次 Setup 注册的路由标注
+func Annotations() []RouteAnnotation {
+	out := make([]RouteAnnotation, len(annotations))
+	copy(out, annotations)
+	return out
+}
+
+// routes 注册路由的同时记录访问标注
+type routes struct {
+	group *gin.RouterGroup
+}
+
+func annotate(group *gin.RouterGroup) routes {
+	return routes{group: group}
+}
+
+func (r routes) GET(relativePath string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("GET", relativePath, a, handlers)
+}
+
+func (r routes) POST(relativePath string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("POST", relativePath, a, handlers)
+}
+
+func (r routes) PUT(relativePath string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("PUT", relativePath, a, handlers)
+}
+
+func (r routes) DELETE(relativePath string, a access, handlers ...gin.HandlerFunc) {
+	r.handle("DELETE", relativePath, a, handlers)
+}
+
+func (r routes) handle(method, relativePath string, a access, handlers []gin.HandlerFunc) {
+	r.group.Handle(method, relativePath, handlers...)
+	annotations = append(annotations, RouteAnnotation{
+		Method: method,
+		Path:   path.Join(r.group.BasePath(), relativePath),
+		Public: a.public,
+		Roles:  a.roles,
+	})
+}
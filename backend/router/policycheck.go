@@ -0,0 +1,230 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/TalkSphere/backend/pkg/rbac"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyLine 策略中的一条 p 规则
+type PolicyLine struct {
+	Sub string
+	Obj string
+	Act string
+}
+
+func (p PolicyLine) String() string {
+	return fmt.Sprintf("p, %s, %s, %s", p.Sub, p.Obj, p.Act)
+}
+
+// RouteGap 标注允许访问、但策略中没有授权的角色
+type RouteGap struct {
+	Route RouteAnnotation
+	Role  string
+}
+
+// PolicyOverlap 被同一角色或其继承的角色中更宽的规则覆盖的策略
+type PolicyOverlap struct {
+	Line      PolicyLine
+	CoveredBy PolicyLine
+}
+
+// PolicyReport 路由与策略的一致性检查结果
+type PolicyReport struct {
+	Unannotated []gin.RouteInfo   // 注册时没有访问标注的路由
+	Unreachable []RouteAnnotation // 需要认证，但除超级管理员外没有任何角色能访问
+	Ungranted   []RouteGap        // 标注允许访问的角色在策略中访问不了
+	Unused      []PolicyLine      // 不匹配任何路由的策略
+	Overlaps    []PolicyOverlap   // 多余的策略，只作提示
+}
+
+// HasProblems 是否存在需要修正的不一致，多余的策略不算
+func (r *PolicyReport) HasProblems() bool {
+	return len(r.Unannotated) > 0 || len(r.Unreachable) > 0 || len(r.Ungranted) > 0 || len(r.Unused) > 0
+}
+
+// CheckPolicies 对照 Setup 注册的路由检查 enforcer 中的路径级策略
+func CheckPolicies(routes gin.RoutesInfo, enforcer *casbin.Enforcer) (*PolicyReport, error) {
+	report := &PolicyReport{}
+
+	annotated := make(map[string]RouteAnnotation, len(annotations))
+	for _, a := range annotations {
+		annotated[a.Method+" "+a.Path] = a
+	}
+
+	roles, err := policyRoles(enforcer)
+	if err != nil {
+		return nil, err
+	}
+
+	// 路由是否有角色可以访问
+	for _, route := range sortedRoutes(routes) {
+		a, ok := annotated[route.Method+" "+route.Path]
+		if !ok {
+			report.Unannotated = append(report.Unannotated, route)
+			continue
+		}
+		if a.Public {
+			continue
+		}
+		reachable := false
+		for _, role := range roles {
+			ok, err := enforcer.Enforce(role, a.Path, a.Method)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			report.Unreachable = append(report.Unreachable, a)
+		}
+		for _, role := range a.Roles {
+			ok, err := enforcer.Enforce(role, a.Path, a.Method)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				report.Ungranted = append(report.Ungranted, RouteGap{Route: a, Role: role})
+			}
+		}
+	}
+
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	bySub := make(map[string][]PolicyLine)
+	var subs []string
+	for _, p := range policies {
+		if len(p) < 3 {
+			continue
+		}
+		line := PolicyLine{Sub: p[0], Obj: p[1], Act: p[2]}
+		if _, ok := bySub[line.Sub]; !ok {
+			subs = append(subs, line.Sub)
+		}
+		bySub[line.Sub] = append(bySub[line.Sub], line)
+
+		// 策略是否匹配任意一条路由
+		used := false
+		for _, route := range routes {
+			if _, ok := rbac.MatchPolicy(line.Obj, line.Act, route.Path, route.Method); ok {
+				used = true
+				break
+			}
+		}
+		if !used {
+			report.Unused = append(report.Unused, line)
+		}
+	}
+
+	// 同一角色或其继承的角色中是否已有更宽的规则
+	for _, sub := range subs {
+		candidates := append([]PolicyLine{}, bySub[sub]...)
+		inherited, err := enforcer.GetImplicitRolesForUser(sub)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range inherited {
+			candidates = append(candidates, bySub[r]...)
+		}
+		for _, line := range bySub[sub] {
+			for _, wider := range candidates {
+				if wider != line && covers(wider, line) {
+					report.Overlaps = append(report.Overlaps, PolicyOverlap{Line: line, CoveredBy: wider})
+					break
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// covers wider 的 obj 模式和 act 能覆盖 line 的全部请求
+func covers(wider, line PolicyLine) bool {
+	if wider.Act != "*" && wider.Act != line.Act {
+		return false
+	}
+	return util.KeyMatch2(line.Obj, wider.Obj)
+}
+
+// policyRoles 策略中出现的角色，不含超级管理员（不经过路径检查）和用户ID
+func policyRoles(enforcer *casbin.Enforcer) ([]string, error) {
+	subjects, err := enforcer.GetAllSubjects()
+	if err != nil {
+		return nil, err
+	}
+	grouped, err := enforcer.GetAllRoles()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var roles []string
+	for _, r := range append(subjects, grouped...) {
+		if seen[r] || r == rbac.SuperAdminRole || r == rbac.AnonymousSubject || rbac.IsUserID(r) {
+			continue
+		}
+		seen[r] = true
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+func sortedRoutes(routes gin.RoutesInfo) gin.RoutesInfo {
+	out := append(gin.RoutesInfo{}, routes...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// GeneratePolicy 根据路由标注生成基线策略，keep 为原样保留的其他规则（资源级规则、角色继承等）
+func GeneratePolicy(w io.Writer, keep []string) error {
+	byRole := make(map[string][]RouteAnnotation)
+	var roles []string
+	for _, a := range annotations {
+		if a.Public {
+			continue
+		}
+		for _, role := range a.Roles {
+			if _, ok := byRole[role]; !ok {
+				roles = append(roles, role)
+			}
+			byRole[role] = append(byRole[role], a)
+		}
+	}
+	sort.Strings(roles)
+
+	var b strings.Builder
+	b.WriteString("# 由 routecheck -generate 根据 router 中的路由标注生成\n\n")
+	b.WriteString("# 超级管理员权限\n")
+	fmt.Fprintf(&b, "p, %s, /api/*, *\n", rbac.SuperAdminRole)
+	for _, role := range roles {
+		fmt.Fprintf(&b, "\n# %s\n", role)
+		for _, a := range byRole[role] {
+			fmt.Fprintf(&b, "%s\n", PolicyLine{Sub: role, Obj: a.Path, Act: a.Method})
+		}
+	}
+	if len(keep) > 0 {
+		b.WriteString("\n# 以下规则保留自原策略文件\n")
+		for _, line := range keep {
+			b.WriteString(line + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
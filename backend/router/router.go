@@ -14,111 +14,222 @@ import (
 	"github.com/TalkSphere/backend/setting"
 )
 
-func Setup() *gin.Engine {
+// Setup 注册全部路由，ctrl 中的接口通过注入的仓储访问数据库
+func Setup(ctrl *controller.Controllers) *gin.Engine {
 	gin.SetMode(setting.Conf.GinConfig.Mode)
 
 	r := gin.Default()
-	r.Use(logger.GinLogger(), logger.GinRecovery(true))
+	annotations = nil
+	r.Use(middleware.RequestIDMiddleware(), logger.GinLogger(), logger.GinRecovery(true), middleware.ReadYourWritesMiddleware())
 	//r.POST("/auth/check", controller.CheckPermission)
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	root := annotate(&r.RouterGroup)
+	root.GET("/swagger/*any", public, ginSwagger.WrapHandler(swaggerFiles.Handler))
+	root.GET("/.well-known/jwks.json", public, controller.GetJWKS)
 
 	// 添加 CORS 中间件
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Accept", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	guest := guestConfig()
+	guestLimit := middleware.AnonymousRateLimitMiddleware(guest.RatePerMinute)
+
 	// 公开路由组 - 不需要认证
-	publicGroup := r.Group("/api")
+	publicGroup := annotate(r.Group("/api"))
 	{
 		// 认证相关
-		publicGroup.POST("/login", controller.LoginHandler)
-		publicGroup.POST("/register", controller.RegisterHandler)
+		publicGroup.POST("/login", public, ctrl.User.Login)
+		publicGroup.POST("/login/2fa", public, ctrl.User.LoginMFA)
+		publicGroup.POST("/login/2fa/enroll", public, ctrl.User.LoginMFAEnroll)
+		publicGroup.POST("/login/2fa/activate", public, ctrl.User.LoginMFAActivate)
+		publicGroup.POST("/register", public, ctrl.User.Register)
+		publicGroup.POST("/token/refresh", public, controller.RefreshTokenHandler)
+		publicGroup.POST("/email/verify", public, ctrl.User.VerifyEmail)
+		publicGroup.POST("/password/forgot", public, ctrl.User.ForgotPassword)
+		publicGroup.POST("/password/reset", public, ctrl.User.ResetPassword)
+		publicGroup.GET("/oauth/providers", public, ctrl.User.GetOAuthProviders)
+		publicGroup.GET("/oauth/:provider/login", public, ctrl.User.OAuthLogin)
+		publicGroup.GET("/oauth/:provider/callback", public, ctrl.User.OAuthCallback)
+		publicGroup.POST("/oauth/exchange", public, ctrl.User.OAuthExchange)
+		publicGroup.POST("/guest/token", public, middleware.IPRateLimitMiddleware(guest.IssuePerMinute), controller.IssueGuestToken)
+	}
 
+	// 公开内容 - 登录与否都可以访问，未登录访客按访客令牌或 IP 限流
+	contentGroup := r.Group("/api")
+	contentGroup.Use(middleware.OptionalAuthMiddleware(), guestLimit)
+	{
 		// 注册各个模块的公开路由
-		RegisterPublicRoutes(publicGroup)
+		RegisterPublicRoutes(contentGroup, ctrl)
 	}
 
-	// 需要认证和权限验证的路由组
+	// 需要认证和权限验证的路由组，访客令牌可以访问 Casbin 中访客角色允许的接口
 	authGroup := r.Group("/api")
-	authGroup.Use(middleware.JWTAuthMiddleware(), middleware.RBACMiddleware())
+	authGroup.Use(middleware.JWTAuthMiddleware(), guestLimit, middleware.RBACMiddleware())
 	{
 		// 注册各个模块的需认证路由
-		RegisterAuthRoutes(authGroup)
+		RegisterAuthRoutes(authGroup, ctrl)
 	}
 
 	return r
 }
 
+// guestConfig 访客相关配置，未配置的项使用默认值
+func guestConfig() setting.GuestConfig {
+	cfg := setting.GuestConfig{RatePerMinute: 120, IssuePerMinute: 10}
+	if setting.Conf.AuthConfig == nil || setting.Conf.Guest == nil {
+		return cfg
+	}
+	if setting.Conf.Guest.RatePerMinute > 0 {
+		cfg.RatePerMinute = setting.Conf.Guest.RatePerMinute
+	}
+	if setting.Conf.Guest.IssuePerMinute > 0 {
+		cfg.IssuePerMinute = setting.Conf.Guest.IssuePerMinute
+	}
+	return cfg
+}
+
 // RegisterPublicRoutes 注册所有公开路由
-func RegisterPublicRoutes(r *gin.RouterGroup) {
+func RegisterPublicRoutes(group *gin.RouterGroup, ctrl *controller.Controllers) {
+	r := annotate(group)
+
 	// 板块相关
-	r.GET("/boards", controller.GetAllBoards)
+	r.GET("/boards", public, ctrl.Board.GetAllBoards)
+	r.GET("/boards/:id/moderators", public, ctrl.Board.GetBoardModerators)
 
 	// 帖子相关
-	r.GET("/posts/:id", controller.GetPostDetail)
-	r.GET("/posts/board/:board_id", controller.GetBoardPosts)
+	r.GET("/posts/:id", public, ctrl.Post.GetPostDetail)
+	r.GET("/posts/board/:board_id", public, ctrl.Post.GetBoardPosts)
 
 	// 评论相关
-	r.GET("/comments/post/:post_id", controller.GetPostComments)
+	r.GET("/comments/post/:post_id", public, ctrl.Comment.GetPostComments)
 }
 
-// RegisterAuthRoutes 注册所有需要认证的路由
-func RegisterAuthRoutes(r *gin.RouterGroup) {
+// 基线策略中的角色，admin 继承 user，super_admin 不经过路径检查
+const (
+	roleGuest = "guest"
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+// RegisterAuthRoutes 注册所有需要认证的路由，allow 中标注基线策略允许访问的角色
+func RegisterAuthRoutes(group *gin.RouterGroup, ctrl *controller.Controllers) {
+	r := annotate(group)
+
 	// 用户相关
-	r.GET("/profile", controller.GetUserProfile)
-	r.POST("/bio", controller.UpdateUserBio)
-	r.POST("/avatar", controller.UpdateUserAvatar)
-	r.GET("/users", controller.GetUserLists)
+	r.POST("/logout", allow(roleUser), controller.LogoutHandler)
+	r.GET("/sessions", allow(roleUser), controller.GetUserSessions)
+	r.POST("/sessions/revoke-others", allow(roleUser), controller.RevokeOtherSessions)
+	r.DELETE("/sessions/:id", allow(roleUser), controller.RevokeUserSession)
+	r.POST("/password", allow(roleUser), ctrl.User.ChangePassword)
+	r.POST("/email/verify/resend", allow(roleUser), ctrl.User.ResendVerificationEmail)
+	r.GET("/2fa/status", allow(roleUser), ctrl.User.GetMFAStatus)
+	r.POST("/2fa/enroll", allow(roleUser), ctrl.User.EnrollMFA)
+	r.POST("/2fa/activate", allow(roleUser), ctrl.User.ActivateMFA)
+	r.POST("/2fa/disable", allow(roleUser), ctrl.User.DisableMFA)
+	r.POST("/2fa/recovery-codes", allow(roleUser), ctrl.User.RegenerateRecoveryCodes)
+	r.GET("/oauth/identities", allow(roleUser), ctrl.User.GetUserIdentities)
+	r.POST("/oauth/:provider/link", allow(roleUser), ctrl.User.LinkOAuthProvider)
+	r.DELETE("/oauth/identities/:provider", allow(roleUser), ctrl.User.UnlinkOAuthProvider)
+	r.GET("/tokens", allow(roleUser), controller.GetUserTokens)
+	r.POST("/tokens", allow(roleUser), controller.CreateToken)
+	r.GET("/tokens/scopes", allow(roleUser), controller.GetTokenScopes)
+	r.DELETE("/tokens/:id", allow(roleUser), controller.RevokeToken)
+	r.GET("/profile", allow(roleGuest, roleUser), ctrl.User.GetUserProfile)
+	r.POST("/bio", allow(roleUser), ctrl.User.UpdateUserBio)
+	r.POST("/avatar", allow(roleUser), ctrl.User.UpdateUserAvatar)
+	r.GET("/users", allow(roleAdmin), ctrl.User.GetUserLists)
+	r.PUT("/users/:user_id/status", allow(roleAdmin), ctrl.User.UpdateUserStatus)
 
 	// 板块管理
-	r.POST("/boards", controller.CreateBoard)
-	r.PUT("/boards/:id", controller.UpdateBoard)
-	r.DELETE("/boards/:id", controller.DeleteBoard)
-
-	// 帖子相关
-	r.POST("/posts", controller.CreatePost)
-	r.PUT("/posts/:id", controller.UpdatePost)
-	r.DELETE("/posts/:id", controller.DeletePost)
-	r.GET("/posts/user", controller.GetUserPosts)
-	r.GET("/posts/user/likes", controller.GetUserLikedPosts)
-	r.GET("/posts/user/favorites", controller.GetUserFavoritePosts)
-	r.GET("/posts/user/comments", controller.GetUserCommentedPosts)
-	r.POST("/posts/image", controller.UploadPostImage)
+	r.POST("/boards", allow(roleAdmin), ctrl.Board.CreateBoard)
+	r.PUT("/boards/:id", allow(roleAdmin), ctrl.Board.UpdateBoard)
+	r.DELETE("/boards/:id", allow(roleAdmin), ctrl.Board.DeleteBoard)
+	r.POST("/boards/:id/moderators", allow(roleAdmin), ctrl.Board.AddBoardModerator)
+	r.DELETE("/boards/:id/moderators/:user_id", allow(roleAdmin), ctrl.Board.RemoveBoardModerator)
+
+	// 帖子相关，作者、版主等资源级权限由 p2 规则检查
+	r.POST("/posts", allow(roleUser), ctrl.Post.CreatePost)
+	r.PUT("/posts/:id", allow(roleUser), ctrl.Post.UpdatePost)
+	r.DELETE("/posts/:id", allow(roleUser), ctrl.Post.DeletePost)
+	r.PUT("/posts/:id/lock", allow(roleUser), ctrl.Post.LockPost)
+	r.PUT("/posts/:id/pin", allow(roleUser), ctrl.Post.PinPost)
+	r.PUT("/posts/:id/move", allow(roleUser), ctrl.Post.MovePost)
+	r.GET("/posts/user", allow(roleUser), ctrl.Post.GetUserPosts)
+	r.GET("/posts/user/likes", allow(roleUser), ctrl.Post.GetUserLikedPosts)
+	r.GET("/posts/user/favorites", allow(roleUser), ctrl.Post.GetUserFavoritePosts)
+	r.GET("/posts/user/comments", allow(roleUser), ctrl.Post.GetUserCommentedPosts)
+	r.POST("/posts/image", allow(roleUser), ctrl.Post.UploadPostImage)
 
 	// 互动相关
-	r.POST("/comments", controller.CreateComment)
-	r.DELETE("/comments/:id", controller.DeleteComment)
-	r.POST("/likes", controller.CreateLike)
-	r.GET("/likes/status", controller.GetLikeStatus)
-	r.POST("/favorites/post/:post_id", controller.CreateFavorite)
-	r.GET("/favorites", controller.GetUserFavorites)
+	r.POST("/comments", allow(roleUser), ctrl.Comment.CreateComment)
+	r.DELETE("/comments/:id", allow(roleUser), ctrl.Comment.DeleteComment)
+	r.POST("/likes", allow(roleUser), ctrl.Like.CreateLike)
+	r.GET("/likes/status", allow(roleUser), ctrl.Like.GetLikeStatus)
+	r.POST("/favorites/post/:post_id", allow(roleUser), ctrl.Favorite.CreateFavorite)
+	r.GET("/favorites", allow(roleUser), ctrl.Favorite.GetUserFavorites)
 
 	// 分析相关
-	r.GET("/analysis/users/active", controller.GetActiveUsers)
-	r.GET("/analysis/users/growth", controller.GetUsersGrowth)
-	r.GET("/analysis/posts/active", controller.GetActivePosts)
-	r.GET("/analysis/posts/growth", controller.GetPostsGrowth)
-	r.GET("/analysis/posts/wordcloud", controller.GetPostsWordCloud)
+	r.GET("/analysis/users/active", allow(roleAdmin), controller.GetActiveUsers)
+	r.GET("/analysis/users/growth", allow(roleAdmin), controller.GetUsersGrowth)
+	r.GET("/analysis/posts/active", allow(roleAdmin), controller.GetActivePosts)
+	r.GET("/analysis/posts/growth", allow(roleAdmin), controller.GetPostsGrowth)
+	r.GET("/analysis/posts/wordcloud", allow(roleAdmin), controller.GetPostsWordCloud)
 
 	// 统计相关
-	r.GET("/admin/stats", controller.GetSystemStats)
+	r.GET("/admin/stats", allow(roleAdmin), ctrl.Stats.GetSystemStats)
+
+	// 审计记录
+	r.GET("/admin/audit", allow(roleAdmin), controller.GetAuditEvents)
+
+	// 登录锁定管理
+	r.GET("/admin/lockouts", allow(roleAdmin), controller.GetLoginLockouts)
+	r.DELETE("/admin/lockouts", allow(roleAdmin), controller.ClearLoginLockout)
+
+	// 用户会话管理
+	r.GET("/admin/users/:user_id/sessions", allow(roleAdmin), controller.AdminGetUserSessions)
+	r.DELETE("/admin/users/:user_id/sessions", allow(roleAdmin), controller.AdminRevokeAllUserSessions)
+	r.DELETE("/admin/users/:user_id/sessions/:id", allow(roleAdmin), controller.AdminRevokeUserSession)
 
-	// 权限管理相关
+	// 权限管理相关，修改类接口在 controller 中还会检查是否为超级管理员
 	//获取用户的所有权限
-	r.GET("/permission/user/:user_id", controller.GetUserPermissions)
+	r.GET("/permission/user/:user_id", allow(roleAdmin), controller.GetUserPermissions)
 	//修改用户权限
-	r.POST("/permission/user/:user_id", controller.UpdateUserPermissions)
+	r.POST("/permission/user/:user_id", allow(roleAdmin), controller.UpdateUserPermissions)
 	//校验用户权限
-	r.GET("/permission/check/:user_id", controller.CheckPermission)
+	r.GET("/permission/check/:user_id", allow(roleAdmin), controller.CheckPermission)
+	//解释用户访问接口时的权限判断过程
+	r.GET("/permission/explain/:user_id", allow(roleAdmin), controller.ExplainPermission)
+	//策略版本和多实例同步状态
+	r.GET("/permission/version", allow(roleAdmin), controller.GetPolicyVersion)
+	//导出、导入策略及策略快照
+	r.GET("/permission/policy/export", allow(roleAdmin), controller.ExportPolicy)
+	r.POST("/permission/policy/preview", allow(roleAdmin), controller.PreviewPolicyImport)
+	r.POST("/permission/policy/import", allow(roleAdmin), controller.ImportPolicy)
+	r.GET("/permission/policy/snapshots", allow(roleAdmin), controller.GetPolicySnapshots)
+	r.POST("/permission/policy/snapshots", allow(roleAdmin), controller.CreatePolicySnapshot)
+	r.GET("/permission/policy/snapshots/:id", allow(roleAdmin), controller.GetPolicySnapshot)
+	r.POST("/permission/policy/snapshots/:id/rollback", allow(roleAdmin), controller.RollbackPolicySnapshot)
 	//获取角色所拥有的权限
-	r.GET("/permission/role", controller.GetRolePermissions)
+	r.GET("/permission/role", allow(roleAdmin), controller.GetRolePermissions)
 	//获取用户的角色
-	r.GET("/permission/user/role/:user_id", controller.GetUserRole)
-	//更新用户角色
-	r.POST("/permission/user/role/:user_id", controller.UpdateUserRole)
+	r.GET("/permission/user/role/:user_id", allow(roleAdmin), controller.GetUserRole)
+	//更新用户角色（整体替换）
+	r.POST("/permission/user/role/:user_id", allow(roleAdmin), controller.UpdateUserRole)
+	//为用户添加单个角色
+	r.POST("/permission/user/:user_id/roles", allow(roleAdmin), controller.AddUserRole)
+	//移除用户的单个角色
+	r.DELETE("/permission/user/:user_id/roles/:role", allow(roleAdmin), controller.RemoveUserRole)
+
+	// 角色管理
+	r.GET("/permission/roles", allow(roleAdmin), controller.GetRoles)
+	r.POST("/permission/roles", allow(roleAdmin), controller.CreateRole)
+	r.GET("/permission/roles/:name", allow(roleAdmin), controller.GetRoleDetail)
+	r.PUT("/permission/roles/:name", allow(roleAdmin), controller.UpdateRole)
+	r.DELETE("/permission/roles/:name", allow(roleAdmin), controller.DeleteRole)
+	r.POST("/permission/roles/:name/clone", allow(roleAdmin), controller.CloneRole)
 }
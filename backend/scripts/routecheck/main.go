@@ -0,0 +1,148 @@
+// routecheck 检查路由与 Casbin 路径级策略是否一致，也可以根据路由标注生成基线策略
+//
+// 报告内容：没有访问标注的路由、除超级管理员外没有角色能访问的路由、
+// 标注允许但策略未授权的角色、不匹配任何路由的策略，以及被更宽的通配规则覆盖的多余策略。
+// 存在前四类问题时以状态码 1 退出，便于在 CI 中使用
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/TalkSphere/backend/controller"
+	"github.com/TalkSphere/backend/pkg/mysql"
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/router"
+	"github.com/TalkSphere/backend/service"
+	"github.com/TalkSphere/backend/setting"
+
+	"github.com/casbin/casbin/v2"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	modelPath  = flag.String("model", "conf/rbac_model.conf", "Casbin 模型文件")
+	policyPath = flag.String("policy", "conf/rbac_policy.csv", "策略文件")
+	fromDB     = flag.Bool("db", false, "检查数据库中的策略（读取 conf/config.yaml），而不是策略文件")
+	generate   = flag.Bool("generate", false, "根据路由标注生成基线策略，资源级规则和角色继承从 -policy 原样保留")
+	output     = flag.String("o", "", "生成的策略写入的文件，默认输出到标准输出")
+)
+
+func main() {
+	flag.Parse()
+
+	setting.Conf.GinConfig = &setting.GinConfig{Mode: gin.ReleaseMode}
+	// 只检查路由注册，不会执行接口，仓储不需要连接数据库
+	repos := repository.NewGorm(nil)
+	engine := router.Setup(controller.NewControllers(repos, service.New(repos)))
+
+	if *generate {
+		if err := generatePolicy(); err != nil {
+			log.Fatalf("generate policy: %v", err)
+		}
+		return
+	}
+
+	enforcer, err := loadEnforcer()
+	if err != nil {
+		log.Fatalf("load policy: %v", err)
+	}
+	report, err := router.CheckPolicies(engine.Routes(), enforcer)
+	if err != nil {
+		log.Fatalf("check policy: %v", err)
+	}
+	printReport(os.Stdout, report)
+	if report.HasProblems() {
+		os.Exit(1)
+	}
+}
+
+func loadEnforcer() (*casbin.Enforcer, error) {
+	if !*fromDB {
+		return casbin.NewEnforcer(*modelPath, *policyPath)
+	}
+	if err := setting.Init(); err != nil {
+		return nil, err
+	}
+	if err := mysql.Init(setting.Conf.MysqlConfig); err != nil {
+		return nil, err
+	}
+	adapter, err := gormadapter.NewAdapterByDB(mysql.DB)
+	if err != nil {
+		return nil, err
+	}
+	return casbin.NewEnforcer(*modelPath, adapter)
+}
+
+func printReport(w io.Writer, r *router.PolicyReport) {
+	section := func(title string, n int) {
+		fmt.Fprintf(w, "== %s (%d)\n", title, n)
+	}
+	section("没有访问标注的路由", len(r.Unannotated))
+	for _, route := range r.Unannotated {
+		fmt.Fprintf(w, "  %-6s %s -> %s\n", route.Method, route.Path, route.Handler)
+	}
+	section("除超级管理员外没有角色能访问的路由", len(r.Unreachable))
+	for _, a := range r.Unreachable {
+		fmt.Fprintf(w, "  %-6s %s\n", a.Method, a.Path)
+	}
+	section("标注允许但策略未授权", len(r.Ungranted))
+	for _, g := range r.Ungranted {
+		fmt.Fprintf(w, "  %-6s %s (%s)\n", g.Route.Method, g.Route.Path, g.Role)
+	}
+	section("不匹配任何路由的策略", len(r.Unused))
+	for _, p := range r.Unused {
+		fmt.Fprintf(w, "  %s\n", p)
+	}
+	section("被更宽的规则覆盖的策略", len(r.Overlaps))
+	for _, o := range r.Overlaps {
+		fmt.Fprintf(w, "  %s  <=  %s\n", o.Line, o.CoveredBy)
+	}
+}
+
+func generatePolicy() error {
+	keep, err := nonPathRules(*policyPath)
+	if err != nil {
+		return err
+	}
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return router.GeneratePolicy(w, keep)
+}
+
+// nonPathRules 策略文件中除 p 以外的规则
+func nonPathRules(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ptype, _, _ := strings.Cut(line, ",")
+		if strings.TrimSpace(ptype) == "p" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/repository"
+)
+
+// CreateCommentInput 发表评论的参数，ParentID 为空时是顶级评论
+type CreateCommentInput struct {
+	PostID   int64
+	UserID   int64
+	Content  string
+	ParentID *int64
+}
+
+// CommentService 评论
+type CommentService struct {
+	repos *repository.Repositories
+}
+
+func NewCommentService(repos *repository.Repositories) *CommentService {
+	return &CommentService{repos: repos}
+}
+
+// Create 发表评论或回复，帖子已删除时返回 ErrPostNotFound，已锁定时返回 ErrPostLocked
+func (s *CommentService) Create(ctx context.Context, in CreateCommentInput) (*models.Comment, error) {
+	comment := &models.Comment{
+		PostID:   in.PostID,
+		UserID:   in.UserID,
+		Content:  in.Content,
+		ParentID: in.ParentID,
+		Status:   1,
+	}
+
+	err := s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		post, err := tx.Posts.GetActive(ctx, in.PostID)
+		if err != nil {
+			return translate(err, ErrPostNotFound)
+		}
+		if post.IsLocked {
+			return ErrPostLocked
+		}
+
+		if in.ParentID != nil {
+			parent, err := tx.Comments.GetActive(ctx, *in.ParentID)
+			if err != nil {
+				return translate(err, ErrCommentNotFound)
+			}
+			// 回复顶级评论时根评论为父评论，否则继承父评论的根评论
+			if parent.RootID == nil {
+				comment.RootID = in.ParentID
+			} else {
+				comment.RootID = parent.RootID
+			}
+			if err := tx.Comments.IncrCounter(ctx, *in.ParentID, repository.CounterReply, 1); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Comments.Create(ctx, comment); err != nil {
+			return err
+		}
+		return tx.Posts.IncrCounter(ctx, in.PostID, repository.CounterComment, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ListByPost 分页查询帖子的顶级评论，每条顶级评论带完整的回复树和评论者信息
+// total 为顶级评论总数，sort 为 repository.CommentSort* 常量
+func (s *CommentService) ListByPost(ctx context.Context, postID int64, sort string, page, size int) ([]models.Comment, int64, error) {
+	roots, total, err := s.repos.Comments.ListRoots(ctx, postID, sort, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(roots) == 0 {
+		return []models.Comment{}, total, nil
+	}
+	replies, err := s.repos.Comments.ListReplies(ctx, postID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 查询所有相关用户
+	var userIDs []int64
+	seen := make(map[int64]bool)
+	for _, list := range [][]models.Comment{roots, replies} {
+		for _, c := range list {
+			if !seen[c.UserID] {
+				seen[c.UserID] = true
+				userIDs = append(userIDs, c.UserID)
+			}
+		}
+	}
+	users, err := s.repos.Users.ListBriefs(ctx, userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	userMap := make(map[int64]*models.User, len(users))
+	for i := range users {
+		userMap[users[i].ID] = &users[i]
+	}
+
+	// 按父评论组织回复，再递归构建回复树
+	childrenByParent := make(map[int64][]*models.Comment)
+	for i := range replies {
+		reply := &replies[i]
+		reply.User = userMap[reply.UserID]
+		if reply.ParentID != nil {
+			childrenByParent[*reply.ParentID] = append(childrenByParent[*reply.ParentID], reply)
+		}
+	}
+	var buildTree func(parentID int64) []models.Comment
+	buildTree = func(parentID int64) []models.Comment {
+		children := childrenByParent[parentID]
+		result := make([]models.Comment, 0, len(children))
+		for _, child := range children {
+			node := *child
+			node.Children = buildTree(child.ID)
+			result = append(result, node)
+		}
+		return result
+	}
+	for i := range roots {
+		roots[i].User = userMap[roots[i].UserID]
+		roots[i].Children = buildTree(roots[i].ID)
+	}
+	return roots, total, nil
+}
+
+// Delete 软删除评论及其所有回复，并更新帖子评论数和父评论回复数
+func (s *CommentService) Delete(ctx context.Context, comment *models.Comment) error {
+	return s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Comments.DeleteThread(ctx, comment.ID); err != nil {
+			return err
+		}
+		if err := tx.Posts.IncrCounter(ctx, comment.PostID, repository.CounterComment, -1); err != nil {
+			return err
+		}
+		if comment.ParentID != nil {
+			return tx.Comments.IncrCounter(ctx, *comment.ParentID, repository.CounterReply, -1)
+		}
+		return nil
+	})
+}
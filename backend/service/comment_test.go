@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TalkSphere/backend/repository"
+)
+
+func TestCommentThread(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewCommentService(repos)
+	post := newTestPost(t, repos, 1)
+
+	root, err := s.Create(ctx, CreateCommentInput{PostID: post.ID, UserID: 2, Content: "沙发"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := s.Create(ctx, CreateCommentInput{PostID: post.ID, UserID: 3, Content: "回复", ParentID: &root.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, err := s.Create(ctx, CreateCommentInput{PostID: post.ID, UserID: 2, Content: "再回复", ParentID: &reply.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nested.RootID == nil || *nested.RootID != root.ID {
+		t.Errorf("nested reply root = %v, want %d", nested.RootID, root.ID)
+	}
+
+	roots, total, err := s.ListByPost(ctx, post.ID, repository.CommentSortNew, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(roots[0].Children) != 1 || len(roots[0].Children[0].Children) != 1 {
+		t.Fatalf("ListByPost = %+v, total %d; want one root with a two-level reply tree", roots, total)
+	}
+	if got, _ := repos.Posts.GetByID(ctx, post.ID); got.CommentCount != 3 {
+		t.Errorf("comment_count = %d, want 3", got.CommentCount)
+	}
+
+	if err := s.Delete(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Comments.GetActive(ctx, nested.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("reply of deleted thread: err = %v, want ErrNotFound", err)
+	}
+	if roots, total, _ := s.ListByPost(ctx, post.ID, repository.CommentSortNew, 1, 10); total != 0 || len(roots) != 0 {
+		t.Errorf("after delete: %d roots, total %d", len(roots), total)
+	}
+}
+
+func TestCommentOnLockedPost(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	post := newTestPost(t, repos, 1)
+	if err := NewPostService(repos).SetLocked(ctx, post.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewCommentService(repos).Create(ctx, CreateCommentInput{PostID: post.ID, UserID: 2, Content: "x"})
+	if !errors.Is(err, ErrPostLocked) {
+		t.Fatalf("err = %v, want ErrPostLocked", err)
+	}
+	_, err = NewCommentService(repos).Create(ctx, CreateCommentInput{PostID: 404, UserID: 2, Content: "x"})
+	if !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("err = %v, want ErrPostNotFound", err)
+	}
+}
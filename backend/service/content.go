@@ -0,0 +1,67 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxExcerptLength = 100 // 摘要取正文的前多少个字符
+	maxExcerptRunes  = 250 // 摘要字段的长度限制，留一些余量
+	excerptImageMark = " [图片]"
+)
+
+// contentPolicy 帖子正文允许的富文本标签和属性，用于 XSS 清理
+// 创建后不再修改，可以并发使用
+var contentPolicy = newContentPolicy()
+
+func newContentPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowStandardURLs()
+	p.AllowStandardAttributes()
+	p.AllowImages()
+	p.AllowLists()
+	p.AllowTables()
+	p.AllowStyles("text-align", "color", "background-color", "font-size", "margin", "padding")
+	p.AllowAttrs("class").Globally()
+	return p
+}
+
+// sanitizeContent 清理正文中不允许的 HTML
+func sanitizeContent(content string) string {
+	return contentPolicy.Sanitize(content)
+}
+
+// buildExcerpt 从清理后的正文中提取纯文本摘要，正文有图片时加上图片标记
+func buildExcerpt(content string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	text := strings.TrimSpace(b.String())
+	excerpt := text
+	if runes := []rune(text); len(runes) > maxExcerptLength {
+		excerpt = string(runes[:maxExcerptLength]) + "..."
+	}
+
+	if strings.Contains(content, "<img") &&
+		len([]rune(excerpt))+len([]rune(excerptImageMark)) <= maxExcerptRunes {
+		excerpt += excerptImageMark
+	}
+	return excerpt, nil
+}
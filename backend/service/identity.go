@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/oauth"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/repository"
+)
+
+// ListIdentities 用户已绑定的第三方账号
+func (s *UserService) ListIdentities(ctx context.Context, userID int64) ([]models.UserIdentity, error) {
+	return s.repos.Identities.ListByUser(ctx, userID)
+}
+
+// ResolveIdentity 查找第三方账号对应的本地用户，没有绑定时按邮箱关联，都找不到时返回 ErrUserNotFound
+// 只有信任的提供方（trustEmail）返回已验证的邮箱时，才自动关联同邮箱的本地账号，否则可能被用来接管账号，
+// 这种情况返回 ErrEmailExists
+func (s *UserService) ResolveIdentity(ctx context.Context, id *oauth.Identity, trustEmail bool) (*models.User, error) {
+	identity, err := s.repos.Identities.GetBySubject(ctx, id.Provider, id.Subject)
+	if err == nil {
+		return s.Get(ctx, identity.UserID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if id.Email == "" {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repos.Users.GetByEmail(ctx, id.Email)
+	if err != nil {
+		return nil, translate(err, ErrUserNotFound)
+	}
+	if !trustEmail || !id.EmailVerified {
+		return nil, ErrEmailExists
+	}
+	if err := s.LinkIdentity(ctx, user.ID, id); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateFromIdentity 为第三方账号创建本地用户并绑定，avatarURL 为第三方没有头像时使用的默认头像
+// 这类用户没有密码，可以通过找回密码设置
+func (s *UserService) CreateFromIdentity(ctx context.Context, id *oauth.Identity, avatarURL string) (*models.User, error) {
+	username, err := s.uniqueUsername(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	email := id.Email
+	if email == "" {
+		// email 列不能为空且唯一，没有邮箱的第三方账号使用不可投递的占位地址
+		email = fmt.Sprintf("%s-%s@oauth.invalid", id.Provider, id.Subject)
+	}
+	if id.AvatarURL != "" {
+		avatarURL = id.AvatarURL
+	}
+	user := &models.User{
+		ID:        snowflake.GenID(),
+		Username:  username,
+		Email:     email,
+		AvatarURL: avatarURL,
+		Bio:       "no bio",
+		Status:    UserStatusActive,
+	}
+	if id.Email != "" && id.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	err = s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		return tx.Identities.Create(ctx, newUserIdentity(user.ID, id))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// LinkIdentity 为用户绑定第三方账号，已经绑定到该用户时直接返回
+// 第三方账号已绑定到其他用户时返回 ErrIdentityInUse，用户已绑定过该提供方的其他账号时返回 ErrIdentityLinked
+func (s *UserService) LinkIdentity(ctx context.Context, userID int64, id *oauth.Identity) error {
+	return s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		existing, err := tx.Identities.GetBySubject(ctx, id.Provider, id.Subject)
+		if err == nil {
+			if existing.UserID == userID {
+				return nil
+			}
+			return ErrIdentityInUse
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		linked, err := tx.Identities.ExistsForProvider(ctx, userID, id.Provider)
+		if err != nil {
+			return err
+		}
+		if linked {
+			return ErrIdentityLinked
+		}
+		if err := tx.Identities.Create(ctx, newUserIdentity(userID, id)); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				// 并发绑定了同一个第三方账号
+				return ErrIdentityInUse
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// UnlinkIdentity 解绑第三方账号，没有设置密码时不能解绑最后一个第三方账号
+func (s *UserService) UnlinkIdentity(ctx context.Context, userID int64, provider string) error {
+	return s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		user, err := tx.Users.GetByID(ctx, userID)
+		if err != nil {
+			return translate(err, ErrUserNotFound)
+		}
+		count, err := tx.Identities.CountByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.PasswordHash == "" && count <= 1 {
+			return ErrLastLoginMethod
+		}
+		return translate(tx.Identities.Delete(ctx, userID, provider), ErrIdentityNotFound)
+	})
+}
+
+func newUserIdentity(userID int64, id *oauth.Identity) *models.UserIdentity {
+	return &models.UserIdentity{
+		ID:       snowflake.GenID(),
+		UserID:   userID,
+		Provider: id.Provider,
+		Subject:  id.Subject,
+		Email:    id.Email,
+		Username: id.Username,
+	}
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_\-.]+`)
+
+// uniqueUsername 根据第三方账号信息生成一个未被占用的用户名
+func (s *UserService) uniqueUsername(ctx context.Context, id *oauth.Identity) (string, error) {
+	base := id.Username
+	if base == "" {
+		base = id.Name
+	}
+	if base == "" && id.Email != "" {
+		base = strings.SplitN(id.Email, "@", 2)[0]
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "_")
+	if base == "" {
+		base = id.Provider + "_user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		exists, err := s.repos.Users.ExistsByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, rand.Intn(10000))
+	}
+	return fmt.Sprintf("%s_%d", base, snowflake.GenID()), nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TalkSphere/backend/pkg/oauth"
+	"github.com/TalkSphere/backend/repository"
+)
+
+func TestResolveIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	aliceID := registerTestUser(t, s, "alice")
+
+	// 没有绑定也没有同邮箱的用户，由调用方创建
+	stranger := &oauth.Identity{Provider: "mock", Subject: "s1", Email: "new@example.com", EmailVerified: true}
+	if _, err := s.ResolveIdentity(ctx, stranger, true); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown identity: err = %v, want ErrUserNotFound", err)
+	}
+
+	// 同邮箱的本地账号只在提供方可信且邮箱已验证时才自动关联
+	same := &oauth.Identity{Provider: "mock", Subject: "s2", Email: "alice@example.com"}
+	if _, err := s.ResolveIdentity(ctx, same, true); !errors.Is(err, ErrEmailExists) {
+		t.Errorf("unverified email: err = %v, want ErrEmailExists", err)
+	}
+	same.EmailVerified = true
+	if _, err := s.ResolveIdentity(ctx, same, false); !errors.Is(err, ErrEmailExists) {
+		t.Errorf("untrusted provider: err = %v, want ErrEmailExists", err)
+	}
+	user, err := s.ResolveIdentity(ctx, same, true)
+	if err != nil || user.ID != aliceID {
+		t.Fatalf("trusted verified email = %v, %v; want alice", user, err)
+	}
+
+	// 关联后按绑定查找，不再看邮箱
+	same.Email, same.EmailVerified = "", false
+	if user, err := s.ResolveIdentity(ctx, same, false); err != nil || user.ID != aliceID {
+		t.Errorf("linked identity = %v, %v; want alice", user, err)
+	}
+}
+
+func TestCreateFromIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	registerTestUser(t, s, "alice")
+
+	id := &oauth.Identity{Provider: "mock", Subject: "s1", Username: "alice", Email: "a2@example.com", EmailVerified: true}
+	user, err := s.CreateFromIdentity(ctx, id, "default.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username == "alice" || user.AvatarURL != "default.png" || !user.EmailVerified() {
+		t.Errorf("user = %+v, want a new username, the default avatar and a verified email", user)
+	}
+	if got, err := s.ResolveIdentity(ctx, id, false); err != nil || got.ID != user.ID {
+		t.Errorf("resolve created identity = %v, %v", got, err)
+	}
+
+	// 没有邮箱时使用占位地址，不与其他用户冲突
+	for _, subject := range []string{"s2", "s3"} {
+		if _, err := s.CreateFromIdentity(ctx, &oauth.Identity{Provider: "mock", Subject: subject}, ""); err != nil {
+			t.Fatalf("identity %s without email: %v", subject, err)
+		}
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	aliceID := registerTestUser(t, s, "alice")
+	bobID := registerTestUser(t, s, "bob")
+
+	id := &oauth.Identity{Provider: "mock", Subject: "s1"}
+	if err := s.LinkIdentity(ctx, aliceID, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LinkIdentity(ctx, aliceID, id); err != nil {
+		t.Errorf("linking again: %v", err)
+	}
+	if err := s.LinkIdentity(ctx, bobID, id); !errors.Is(err, ErrIdentityInUse) {
+		t.Errorf("identity of another user: err = %v, want ErrIdentityInUse", err)
+	}
+	other := &oauth.Identity{Provider: "mock", Subject: "s2"}
+	if err := s.LinkIdentity(ctx, aliceID, other); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("second identity of the same provider: err = %v, want ErrIdentityLinked", err)
+	}
+
+	identities, err := s.ListIdentities(ctx, aliceID)
+	if err != nil || len(identities) != 1 || identities[0].Subject != "s1" {
+		t.Errorf("ListIdentities = %+v, %v", identities, err)
+	}
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+
+	// 第三方登录创建的用户没有密码，不能解绑唯一的第三方账号
+	user, err := s.CreateFromIdentity(ctx, &oauth.Identity{Provider: "mock", Subject: "s1"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnlinkIdentity(ctx, user.ID, "mock"); !errors.Is(err, ErrLastLoginMethod) {
+		t.Errorf("last login method: err = %v, want ErrLastLoginMethod", err)
+	}
+
+	aliceID := registerTestUser(t, s, "alice")
+	if err := s.UnlinkIdentity(ctx, aliceID, "mock"); !errors.Is(err, ErrIdentityNotFound) {
+		t.Errorf("not linked: err = %v, want ErrIdentityNotFound", err)
+	}
+	if err := s.LinkIdentity(ctx, aliceID, &oauth.Identity{Provider: "mock", Subject: "s2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnlinkIdentity(ctx, aliceID, "mock"); err != nil {
+		t.Errorf("user with a password: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/repository"
+)
+
+// InteractionService 点赞和收藏
+type InteractionService struct {
+	repos *repository.Repositories
+}
+
+func NewInteractionService(repos *repository.Repositories) *InteractionService {
+	return &InteractionService{repos: repos}
+}
+
+// ToggleLike 点赞或取消点赞帖子、评论，返回操作后是否为已点赞
+func (s *InteractionService) ToggleLike(ctx context.Context, userID, targetID int64, targetType int8) (bool, error) {
+	if targetType != models.LikeTargetPost && targetType != models.LikeTargetComment {
+		return false, ErrInvalidTarget
+	}
+
+	liked := true
+	err := s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		delta := 1
+		like, err := tx.Likes.Find(ctx, userID, targetID, targetType)
+		switch {
+		case err == nil:
+			if err := tx.Likes.Delete(ctx, like.ID); err != nil {
+				return err
+			}
+			liked, delta = false, -1
+		case errors.Is(err, repository.ErrNotFound):
+			like = &models.Like{UserID: userID, TargetID: targetID, TargetType: targetType}
+			if err := tx.Likes.Create(ctx, like); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		if targetType == models.LikeTargetPost {
+			return tx.Posts.IncrCounter(ctx, targetID, repository.CounterLike, delta)
+		}
+		return tx.Comments.IncrCounter(ctx, targetID, repository.CounterLike, delta)
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		// 并发的相同请求已经点赞，本次事务已回滚，视为已点赞
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return liked, nil
+}
+
+// IsLiked 用户是否已点赞目标
+func (s *InteractionService) IsLiked(ctx context.Context, userID, targetID int64, targetType int8) (bool, error) {
+	return s.repos.Likes.Exists(ctx, userID, targetID, targetType)
+}
+
+// ToggleFavorite 收藏或取消收藏帖子，返回操作后是否为已收藏；帖子已删除时返回 ErrPostNotFound
+func (s *InteractionService) ToggleFavorite(ctx context.Context, userID, postID int64) (bool, error) {
+	favorited := true
+	err := s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if _, err := tx.Posts.GetActive(ctx, postID); err != nil {
+			return translate(err, ErrPostNotFound)
+		}
+
+		delta := 1
+		favorite, err := tx.Favorites.Find(ctx, userID, postID)
+		switch {
+		case err == nil:
+			if err := tx.Favorites.Delete(ctx, favorite.ID); err != nil {
+				return err
+			}
+			favorited, delta = false, -1
+		case errors.Is(err, repository.ErrNotFound):
+			if err := tx.Favorites.Create(ctx, &models.Favorite{UserID: userID, PostID: postID}); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		return tx.Posts.IncrCounter(ctx, postID, repository.CounterFavorite, delta)
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		// 并发的相同请求已经收藏，本次事务已回滚，视为已收藏
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return favorited, nil
+}
+
+// ListFavorites 用户的收藏，带帖子及其标签
+func (s *InteractionService) ListFavorites(ctx context.Context, userID int64, page, size int) ([]models.Favorite, int64, error) {
+	return s.repos.Favorites.ListByUser(ctx, userID, page, size)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/repository"
+)
+
+func TestToggleLike(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewInteractionService(repos)
+	post := newTestPost(t, repos, 1)
+
+	liked, err := s.ToggleLike(ctx, 2, post.ID, models.LikeTargetPost)
+	if err != nil || !liked {
+		t.Fatalf("first toggle = %v, %v; want true, nil", liked, err)
+	}
+	if got, _ := repos.Posts.GetByID(ctx, post.ID); got.LikeCount != 1 {
+		t.Errorf("like_count = %d, want 1", got.LikeCount)
+	}
+
+	liked, err = s.ToggleLike(ctx, 2, post.ID, models.LikeTargetPost)
+	if err != nil || liked {
+		t.Fatalf("second toggle = %v, %v; want false, nil", liked, err)
+	}
+	if got, _ := repos.Posts.GetByID(ctx, post.ID); got.LikeCount != 0 {
+		t.Errorf("like_count = %d, want 0", got.LikeCount)
+	}
+}
+
+func TestToggleLikeInvalidTarget(t *testing.T) {
+	s := NewInteractionService(repository.NewMemory())
+	if _, err := s.ToggleLike(context.Background(), 1, 1, 9); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("err = %v, want ErrInvalidTarget", err)
+	}
+}
+
+// racingLikes 模拟并发请求：查询时还没有点赞，插入时已被另一个请求抢先
+type racingLikes struct {
+	repository.LikeRepository
+}
+
+func (racingLikes) Find(context.Context, int64, int64, int8) (*models.Like, error) {
+	return nil, repository.ErrNotFound
+}
+
+func TestToggleLikeDuplicate(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	post := newTestPost(t, repos, 1)
+	if err := repos.Likes.Create(ctx, &models.Like{UserID: 2, TargetID: post.ID, TargetType: models.LikeTargetPost}); err != nil {
+		t.Fatal(err)
+	}
+	repos.Likes = racingLikes{repos.Likes}
+
+	liked, err := NewInteractionService(repos).ToggleLike(ctx, 2, post.ID, models.LikeTargetPost)
+	if err != nil || !liked {
+		t.Fatalf("toggle = %v, %v; want true, nil", liked, err)
+	}
+}
+
+type racingFavorites struct {
+	repository.FavoriteRepository
+}
+
+func (racingFavorites) Find(context.Context, int64, int64) (*models.Favorite, error) {
+	return nil, repository.ErrNotFound
+}
+
+func TestToggleFavorite(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewInteractionService(repos)
+	post := newTestPost(t, repos, 1)
+
+	favorited, err := s.ToggleFavorite(ctx, 2, post.ID)
+	if err != nil || !favorited {
+		t.Fatalf("first toggle = %v, %v; want true, nil", favorited, err)
+	}
+	favorites, total, err := s.ListFavorites(ctx, 2, 1, 10)
+	if err != nil || total != 1 || favorites[0].Post == nil || favorites[0].Post.ID != post.ID {
+		t.Fatalf("ListFavorites = %v, %d, %v", favorites, total, err)
+	}
+
+	favorited, err = s.ToggleFavorite(ctx, 2, post.ID)
+	if err != nil || favorited {
+		t.Fatalf("second toggle = %v, %v; want false, nil", favorited, err)
+	}
+	if got, _ := repos.Posts.GetByID(ctx, post.ID); got.FavoriteCount != 0 {
+		t.Errorf("favorite_count = %d, want 0", got.FavoriteCount)
+	}
+}
+
+func TestToggleFavoriteDuplicate(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	post := newTestPost(t, repos, 1)
+	if err := repos.Favorites.Create(ctx, &models.Favorite{UserID: 2, PostID: post.ID}); err != nil {
+		t.Fatal(err)
+	}
+	repos.Favorites = racingFavorites{repos.Favorites}
+
+	favorited, err := NewInteractionService(repos).ToggleFavorite(ctx, 2, post.ID)
+	if err != nil || !favorited {
+		t.Fatalf("toggle = %v, %v; want true, nil", favorited, err)
+	}
+}
+
+func TestToggleFavoriteDeletedPost(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	post := newTestPost(t, repos, 1)
+	if err := NewPostService(repos).Delete(ctx, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewInteractionService(repos).ToggleFavorite(ctx, 2, post.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("err = %v, want ErrPostNotFound", err)
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/repository"
+
+	"go.uber.org/zap"
+)
+
+// CreatePostInput 发布帖子的参数
+type CreatePostInput struct {
+	AuthorID int64
+	BoardID  int64
+	Title    string
+	Content  string
+	Tags     []string
+	ImageIDs []int64
+}
+
+// UpdatePostInput 编辑帖子的参数，零值的字段和为 nil 的列表保持不变
+// Tags、ImageIDs 为空列表时清空帖子的标签、图片
+type UpdatePostInput struct {
+	Title    string
+	Content  string
+	BoardID  int64
+	Tags     []string
+	ImageIDs []int64
+}
+
+// PostService 帖子
+type PostService struct {
+	repos *repository.Repositories
+}
+
+func NewPostService(repos *repository.Repositories) *PostService {
+	return &PostService{repos: repos}
+}
+
+// Create 发布帖子，正文经过 XSS 清理并生成摘要，图片必须是作者上传且未被其他帖子使用的
+func (s *PostService) Create(ctx context.Context, in CreatePostInput) (*models.Post, error) {
+	content := sanitizeContent(in.Content)
+	excerpt, err := buildExcerpt(content)
+	if err != nil {
+		return nil, err
+	}
+	post := &models.Post{
+		Title:    in.Title,
+		Content:  content,
+		Excerpt:  excerpt,
+		BoardID:  &in.BoardID,
+		AuthorID: &in.AuthorID,
+	}
+
+	err = s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Posts.Create(ctx, post); err != nil {
+			return err
+		}
+		if len(in.Tags) > 0 {
+			if err := tx.Posts.SetTags(ctx, post.ID, in.Tags); err != nil {
+				return err
+			}
+		}
+		if len(in.ImageIDs) > 0 {
+			return tx.Posts.SetImages(ctx, post.ID, in.AuthorID, in.ImageIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, imagesError(err)
+	}
+	return post, nil
+}
+
+// Update 编辑帖子，新增的图片必须是帖子作者上传的
+// 版主或管理员编辑别人的帖子时，保留作者原有的图片不受影响
+func (s *PostService) Update(ctx context.Context, postID int64, in UpdatePostInput) error {
+	fields := make(map[string]interface{})
+	if in.Title != "" {
+		fields["title"] = in.Title
+	}
+	if in.Content != "" {
+		content := sanitizeContent(in.Content)
+		excerpt, err := buildExcerpt(content)
+		if err != nil {
+			return err
+		}
+		fields["content"] = content
+		fields["excerpt"] = excerpt
+	}
+	if in.BoardID != 0 {
+		fields["board_id"] = in.BoardID
+	}
+
+	err := s.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Posts.Update(ctx, postID, fields); err != nil {
+			return err
+		}
+		if in.Tags != nil {
+			if err := tx.Posts.SetTags(ctx, postID, in.Tags); err != nil {
+				return err
+			}
+		}
+		// 不再使用的图片解除关联
+		if in.ImageIDs != nil {
+			post, err := tx.Posts.GetByID(ctx, postID)
+			if err != nil {
+				return translate(err, ErrPostNotFound)
+			}
+			var authorID int64
+			if post.AuthorID != nil {
+				authorID = *post.AuthorID
+			}
+			return tx.Posts.SetImages(ctx, postID, authorID, in.ImageIDs)
+		}
+		return nil
+	})
+	return imagesError(err)
+}
+
+// Get 查询未删除的帖子及其标签、图片，并在后台增加浏览量
+func (s *PostService) Get(ctx context.Context, id int64) (*models.Post, error) {
+	post, err := s.repos.Posts.GetDetail(ctx, id)
+	if err != nil {
+		return nil, translate(err, ErrPostNotFound)
+	}
+	// 调用方返回后 ctx 可能被取消，浏览量使用独立的 context 更新
+	go func() {
+		if err := s.repos.Posts.IncrCounter(context.Background(), id, repository.CounterView, 1); err != nil {
+			zap.L().Error("update view count failed", zap.Int64("post_id", id), zap.Error(err))
+		}
+	}()
+	return post, nil
+}
+
+// Delete 软删除帖子
+func (s *PostService) Delete(ctx context.Context, id int64) error {
+	return s.repos.Posts.Update(ctx, id, map[string]interface{}{"status": -1})
+}
+
+// SetLocked 锁定或解锁帖子，锁定后不能再评论
+func (s *PostService) SetLocked(ctx context.Context, id int64, locked bool) error {
+	return s.repos.Posts.Update(ctx, id, map[string]interface{}{"is_locked": locked})
+}
+
+// SetPinned 置顶或取消置顶帖子
+func (s *PostService) SetPinned(ctx context.Context, id int64, pinned bool) error {
+	return s.repos.Posts.Update(ctx, id, map[string]interface{}{"is_pinned": pinned})
+}
+
+// Move 把帖子移动到其他板块
+func (s *PostService) Move(ctx context.Context, id, boardID int64) error {
+	return s.repos.Posts.Update(ctx, id, map[string]interface{}{"board_id": boardID})
+}
+
+// SaveImage 保存已上传到对象存储的图片，发布帖子时再关联
+func (s *PostService) SaveImage(ctx context.Context, userID int64, url string) (*models.PostImage, error) {
+	img := &models.PostImage{
+		UserID:    userID,
+		ImageURL:  url,
+		Status:    1,
+		SortOrder: 0,
+	}
+	if err := s.repos.Posts.CreateImage(ctx, img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// ListByBoard 板块中的帖子，置顶的在前
+func (s *PostService) ListByBoard(ctx context.Context, q repository.PostQuery) ([]models.Post, int64, error) {
+	return s.repos.Posts.ListByBoard(ctx, q)
+}
+
+// ListByAuthor 用户发布的帖子
+func (s *PostService) ListByAuthor(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return s.repos.Posts.ListByAuthor(ctx, userID, page, size)
+}
+
+// ListLikedBy 用户点赞过的帖子
+func (s *PostService) ListLikedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return s.repos.Posts.ListLikedBy(ctx, userID, page, size)
+}
+
+// ListFavoritedBy 用户收藏的帖子
+func (s *PostService) ListFavoritedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return s.repos.Posts.ListFavoritedBy(ctx, userID, page, size)
+}
+
+// ListCommentedBy 用户评论过的帖子
+func (s *PostService) ListCommentedBy(ctx context.Context, userID int64, page, size int) ([]models.Post, int64, error) {
+	return s.repos.Posts.ListCommentedBy(ctx, userID, page, size)
+}
+
+func imagesError(err error) error {
+	if errors.Is(err, repository.ErrInvalidImages) {
+		return ErrInvalidImages
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TalkSphere/backend/repository"
+)
+
+func TestCreatePost(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewPostService(repos)
+	img, err := s.SaveImage(ctx, 1, "https://oss.example.com/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post, err := s.Create(ctx, CreatePostInput{
+		AuthorID: 1,
+		BoardID:  1,
+		Title:    "标题",
+		Content:  `<p>正文</p><script>alert(1)</script>`,
+		Tags:     []string{"go", "go", "gin"},
+		ImageIDs: []int64{img.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Tags) != 2 || len(got.Images) != 1 || got.Images[0].ID != img.ID {
+		t.Errorf("tags = %v, images = %v; want 2 tags and the uploaded image", got.Tags, got.Images)
+	}
+	if got.Content != "<p>正文</p>" {
+		t.Errorf("content = %q, want sanitized HTML", got.Content)
+	}
+}
+
+func TestCreatePostWithOthersImage(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewPostService(repos)
+	img, err := s.SaveImage(ctx, 2, "https://oss.example.com/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Create(ctx, CreatePostInput{AuthorID: 1, BoardID: 1, Title: "标题", Content: "正文", ImageIDs: []int64{img.ID}})
+	if !errors.Is(err, ErrInvalidImages) {
+		t.Fatalf("err = %v, want ErrInvalidImages", err)
+	}
+}
+
+func TestDeletedPostNotFound(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewPostService(repos)
+	post := newTestPost(t, repos, 1)
+	if err := s.Delete(ctx, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, post.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("err = %v, want ErrPostNotFound", err)
+	}
+}
+
+// 版主编辑别人的帖子时，图片的归属按帖子作者检查
+func TestUpdatePostByModerator(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewPostService(repos)
+	kept, err := s.SaveImage(ctx, 1, "https://oss.example.com/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := s.SaveImage(ctx, 1, "https://oss.example.com/b.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	post, err := s.Create(ctx, CreatePostInput{AuthorID: 1, BoardID: 1, Title: "标题", Content: "正文", ImageIDs: []int64{kept.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Update(ctx, post.ID, UpdatePostInput{Title: "版主修改的标题", ImageIDs: []int64{kept.ID, added.ID}})
+	if err != nil {
+		t.Fatalf("moderator keeping the author's images: %v", err)
+	}
+	got, err := s.Get(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "版主修改的标题" || len(got.Images) != 2 {
+		t.Errorf("title = %q, images = %v; want the new title and both images", got.Title, got.Images)
+	}
+
+	// 不属于作者的图片仍然不能加到帖子中
+	own, err := s.SaveImage(ctx, 3, "https://oss.example.com/c.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Update(ctx, post.ID, UpdatePostInput{ImageIDs: []int64{kept.ID, own.ID}})
+	if !errors.Is(err, ErrInvalidImages) {
+		t.Fatalf("moderator's own image: err = %v, want ErrInvalidImages", err)
+	}
+}
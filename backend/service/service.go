@@ -0,0 +1,51 @@
+// Package service 论坛的业务逻辑，方法只依赖 context 和仓储，返回本包定义的错误，
+// gin 接口、命令行工具以及以后的 gRPC/GraphQL 接口都可以直接调用
+// 权限检查不在这一层，由调用方在调用前完成
+package service
+
+import (
+	"errors"
+
+	"github.com/TalkSphere/backend/repository"
+)
+
+var (
+	ErrPostNotFound     = errors.New("post not found")
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrUsernameExists   = errors.New("username already exists")
+	ErrEmailExists      = errors.New("email already exists")
+	ErrInvalidPassword  = errors.New("invalid username or password")
+	ErrIdentityInUse    = errors.New("identity is linked to another user")
+	ErrIdentityLinked   = errors.New("user already linked an identity of this provider")
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrLastLoginMethod  = errors.New("cannot remove the last login method")
+	ErrPostLocked       = errors.New("post is locked")
+	ErrInvalidImages    = errors.New("images not found or not owned by the author")
+	ErrInvalidTarget    = errors.New("invalid like target type")
+)
+
+// Services 汇总各个服务
+type Services struct {
+	Posts        *PostService
+	Comments     *CommentService
+	Interactions *InteractionService
+	Users        *UserService
+}
+
+func New(repos *repository.Repositories) *Services {
+	return &Services{
+		Posts:        NewPostService(repos),
+		Comments:     NewCommentService(repos),
+		Interactions: NewInteractionService(repos),
+		Users:        NewUserService(repos),
+	}
+}
+
+// translate 把仓储的记录不存在错误转换为 notFound，其他错误原样返回
+func translate(err, notFound error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound
+	}
+	return err
+}
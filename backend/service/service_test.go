@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/setting"
+)
+
+func TestMain(m *testing.M) {
+	// 测试中使用最低开销的 bcrypt，注册时需要雪花 ID
+	setting.Conf.EncryptConfig = &setting.EncryptConfig{
+		SecretKey:  "test-secret",
+		Algorithm:  encrypt.AlgorithmBcrypt,
+		BcryptCost: 4,
+	}
+	if err := encrypt.Init(setting.Conf.EncryptConfig); err != nil {
+		panic(err)
+	}
+	if err := snowflake.Init("2024-01-01", 1); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestPost 在内存仓储中创建一个帖子
+func newTestPost(t *testing.T, repos *repository.Repositories, authorID int64) *models.Post {
+	t.Helper()
+	boardID := int64(1)
+	post := &models.Post{Title: "标题", Content: "内容", BoardID: &boardID, AuthorID: &authorID}
+	if err := repos.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	return post
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TalkSphere/backend/models"
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/pkg/session"
+	"github.com/TalkSphere/backend/pkg/snowflake"
+	"github.com/TalkSphere/backend/repository"
+
+	"go.uber.org/zap"
+)
+
+// 用户状态
+const (
+	UserStatusDisabled int8 = 0
+	UserStatusActive   int8 = 1
+)
+
+// RegisterInput 注册的参数，Bio、AvatarURL 的默认值由调用方填充
+type RegisterInput struct {
+	Username  string
+	Password  string
+	Email     string
+	Bio       string
+	AvatarURL string
+}
+
+// UserService 注册、登录、个人资料和用户管理
+type UserService struct {
+	repos *repository.Repositories
+}
+
+func NewUserService(repos *repository.Repositories) *UserService {
+	return &UserService{repos: repos}
+}
+
+// Register 注册新用户，用户名或邮箱已被使用时返回 ErrUsernameExists、ErrEmailExists
+func (s *UserService) Register(ctx context.Context, in RegisterInput) (*models.User, error) {
+	if err := s.checkAvailable(ctx, in.Username, in.Email); err != nil {
+		return nil, err
+	}
+	passwordHash, err := encrypt.HashPassword(in.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		ID:           snowflake.GenID(),
+		Username:     in.Username,
+		PasswordHash: passwordHash,
+		Email:        in.Email,
+		AvatarURL:    in.AvatarURL,
+		Bio:          in.Bio,
+		Status:       UserStatusActive,
+	}
+	if err := s.repos.Users.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			// 并发注册了相同的用户名或邮箱，重新检查以返回准确的错误
+			if err := s.checkAvailable(ctx, in.Username, in.Email); err != nil {
+				return nil, err
+			}
+			return nil, ErrUsernameExists
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) checkAvailable(ctx context.Context, username, email string) error {
+	exists, err := s.repos.Users.ExistsByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUsernameExists
+	}
+	exists, err = s.repos.Users.ExistsByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailExists
+	}
+	return nil
+}
+
+// Authenticate 校验用户名和密码，不检查账号状态
+// 用户不存在和密码错误都返回 ErrInvalidPassword，耗时也保持一致，避免泄露用户名是否已注册
+func (s *UserService) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
+	user, err := s.repos.Users.GetByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		encrypt.DummyVerify(password)
+		return nil, ErrInvalidPassword
+	}
+	if err != nil {
+		return nil, err
+	}
+	ok, rehash, err := encrypt.VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		zap.L().Error("校验密码失败", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
+	if !ok {
+		return nil, ErrInvalidPassword
+	}
+
+	// 旧版 MD5 或参数过期的哈希，在登录成功后透明升级
+	if rehash {
+		if newHash, err := encrypt.HashPassword(password); err != nil {
+			zap.L().Error("升级密码哈希失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		} else if err := s.repos.Users.Update(ctx, user.ID, map[string]interface{}{"password_hash": newHash}); err != nil {
+			zap.L().Error("保存升级后的密码哈希失败", zap.Int64("user_id", user.ID), zap.Error(err))
+		} else {
+			user.PasswordHash = newHash
+		}
+	}
+	return user, nil
+}
+
+// RecordLogin 记录最后登录时间
+func (s *UserService) RecordLogin(ctx context.Context, id int64) error {
+	return s.repos.Users.Update(ctx, id, map[string]interface{}{"last_login_at": time.Now()})
+}
+
+// ChangePassword 校验旧密码后修改密码，旧密码错误时返回 ErrInvalidPassword
+// 修改后由调用方吊销该用户的会话
+func (s *UserService) ChangePassword(ctx context.Context, id int64, oldPassword, newPassword string) error {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return translate(err, ErrUserNotFound)
+	}
+	ok, _, err := encrypt.VerifyPassword(oldPassword, user.PasswordHash)
+	if err != nil {
+		zap.L().Error("校验密码失败", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
+	if !ok {
+		return ErrInvalidPassword
+	}
+	passwordHash, err := encrypt.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.repos.Users.Update(ctx, id, map[string]interface{}{"password_hash": passwordHash})
+}
+
+// Get 查询用户
+func (s *UserService) Get(ctx context.Context, id int64) (*models.User, error) {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return nil, translate(err, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// GetByEmail 按邮箱查询用户，包括已封禁的用户
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.repos.Users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, translate(err, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// UpdateBio 修改个人简介
+func (s *UserService) UpdateBio(ctx context.Context, id int64, bio string) error {
+	exists, err := s.repos.Users.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return s.repos.Users.Update(ctx, id, map[string]interface{}{"bio": bio})
+}
+
+// UpdateAvatar 修改头像，url 为已上传到对象存储的地址
+func (s *UserService) UpdateAvatar(ctx context.Context, id int64, url string) error {
+	return s.repos.Users.Update(ctx, id, map[string]interface{}{"avatar_url": url})
+}
+
+// SetStatus 封禁或解封用户，返回修改前的状态；封禁时立即吊销其所有会话
+func (s *UserService) SetStatus(ctx context.Context, id int64, status int8) (int8, error) {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return 0, translate(err, ErrUserNotFound)
+	}
+	if err := s.repos.Users.Update(ctx, id, map[string]interface{}{"status": status}); err != nil {
+		return 0, err
+	}
+	if status == UserStatusDisabled {
+		if err := session.RevokeUser(id); err != nil {
+			return user.Status, err
+		}
+	}
+	return user.Status, nil
+}
+
+// ListActive 未封禁的用户，keyword 不为空时按用户名、ID、邮箱和简介模糊匹配
+func (s *UserService) ListActive(ctx context.Context, keyword string, page, size int) ([]models.User, int64, error) {
+	return s.repos.Users.ListActive(ctx, keyword, page, size)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/TalkSphere/backend/pkg/encrypt"
+	"github.com/TalkSphere/backend/repository"
+	"github.com/TalkSphere/backend/setting"
+)
+
+func registerTestUser(t *testing.T, s *UserService, username string) int64 {
+	t.Helper()
+	user, err := s.Register(context.Background(), RegisterInput{
+		Username: username,
+		Password: "secret123",
+		Email:    username + "@example.com",
+	})
+	if err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	return user.ID
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	id := registerTestUser(t, s, "alice")
+
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Status != UserStatusActive || user.PasswordHash == "secret123" {
+		t.Errorf("user = %+v, want active user with hashed password", user)
+	}
+
+	_, err = s.Register(ctx, RegisterInput{Username: "alice", Password: "x", Email: "other@example.com"})
+	if !errors.Is(err, ErrUsernameExists) {
+		t.Errorf("same username: err = %v, want ErrUsernameExists", err)
+	}
+	_, err = s.Register(ctx, RegisterInput{Username: "bob", Password: "x", Email: "alice@example.com"})
+	if !errors.Is(err, ErrEmailExists) {
+		t.Errorf("same email: err = %v, want ErrEmailExists", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	id := registerTestUser(t, s, "alice")
+
+	user, err := s.Authenticate(ctx, "alice", "secret123")
+	if err != nil || user.ID != id {
+		t.Fatalf("Authenticate = %v, %v; want user %d", user, err, id)
+	}
+	if _, err := s.Authenticate(ctx, "alice", "wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("wrong password: err = %v, want ErrInvalidPassword", err)
+	}
+	if _, err := s.Authenticate(ctx, "nobody", "secret123"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("unknown user: err = %v, want ErrInvalidPassword", err)
+	}
+}
+
+func TestAuthenticateUpgradesLegacyHash(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	s := NewUserService(repos)
+	id := registerTestUser(t, s, "alice")
+	// 旧版本保存的是不带算法标识的 MD5
+	h := md5.New()
+	h.Write([]byte(setting.Conf.EncryptConfig.SecretKey))
+	legacy := hex.EncodeToString(h.Sum([]byte("secret")))
+	if err := repos.Users.Update(ctx, id, map[string]interface{}{"password_hash": legacy}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Authenticate(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	user, _ := repos.Users.GetByID(ctx, id)
+	if user.PasswordHash == legacy {
+		t.Fatal("legacy hash was not upgraded")
+	}
+	if ok, rehash, _ := encrypt.VerifyPassword("secret", user.PasswordHash); !ok || rehash {
+		t.Errorf("upgraded hash: ok = %v, rehash = %v", ok, rehash)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	id := registerTestUser(t, s, "alice")
+
+	if err := s.ChangePassword(ctx, id, "wrong", "newsecret"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("wrong old password: err = %v, want ErrInvalidPassword", err)
+	}
+	if err := s.ChangePassword(ctx, 404, "secret123", "newsecret"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("unknown user: err = %v, want ErrUserNotFound", err)
+	}
+	if err := s.ChangePassword(ctx, id, "secret123", "newsecret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, "alice", "newsecret"); err != nil {
+		t.Errorf("login with new password: %v", err)
+	}
+}
+
+func TestRecordLogin(t *testing.T) {
+	ctx := context.Background()
+	s := NewUserService(repository.NewMemory())
+	id := registerTestUser(t, s, "alice")
+
+	if err := s.RecordLogin(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.Get(ctx, id); user.LastLoginAt == nil {
+		t.Error("last_login_at was not set")
+	}
+}
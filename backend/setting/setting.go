@@ -2,6 +2,7 @@ package setting
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -21,6 +22,10 @@ type Config struct {
 	*OSSConfig       `mapstructure:"oss"`
 	*DefaultAvatar   `mapstructure:"default_avatar"`
 	*SuperAdmin      `mapstructure:"super_admin"`
+	*MailConfig      `mapstructure:"mail"`
+	*OAuthConfig     `mapstructure:"oauth"`
+	*RBACConfig      `mapstructure:"rbac"`
+	*AuditConfig     `mapstructure:"audit"`
 }
 
 type AppConfig struct {
@@ -39,13 +44,31 @@ type LogConfig struct {
 }
 
 type MysqlConfig struct {
-	Host              string `mapstructure:"host"`
-	Port              int    `mapstructure:"port"`
-	User              string `mapstructure:"user"`
-	PassWord          string `mapstructure:"password"`
-	DB                string `mapstructure:"db"`
-	MaxOpenConnection int    `mapstructure:"max_open_connection"`
-	MaxIdleConnection int    `mapstructure:"max_idle_connection"`
+	// 数据库类型：mysql（默认）、postgres 或 sqlite
+	Driver string `mapstructure:"driver"`
+	// 完整的连接串，配置后忽略 host、port 等字段；sqlite 为数据库文件路径，默认 <db>.db
+	DSN      string `mapstructure:"dsn"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	PassWord string `mapstructure:"password"`
+	DB       string `mapstructure:"db"`
+	// 以下连接池和日志配置修改配置文件后立即生效，主库和从库使用相同的配置
+	MaxOpenConnection int `mapstructure:"max_open_connection"`
+	MaxIdleConnection int `mapstructure:"max_idle_connection"`
+	// 连接最长使用时间和最长空闲时间（秒），0 表示使用默认值 3600 和不限制
+	ConnMaxLifetime int64 `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime int64 `mapstructure:"conn_max_idle_time"`
+	// SQL 日志级别：silent、error、warn（默认，只记录错误和慢查询）或 info（记录全部 SQL）
+	LogLevel string `mapstructure:"log_level"`
+	// 慢查询阈值（毫秒），默认 200
+	SlowThreshold int64 `mapstructure:"slow_threshold"`
+	// 慢查询日志的采样比例，取值 (0, 1]，默认 1 即全部记录
+	SlowLogSampleRate float64 `mapstructure:"slow_log_sample_rate"`
+	// 从库连接串，格式与 dsn 相同；配置后列表、统计等查询分摊到健康的从库
+	Replicas []string `mapstructure:"replicas"`
+	// 检查从库是否可用的间隔（秒），默认 10
+	ReplicaCheckInterval int64 `mapstructure:"replica_check_interval"`
 }
 
 type RedisConfig struct {
@@ -57,7 +80,16 @@ type RedisConfig struct {
 }
 
 type EncryptConfig struct {
+	// 旧版 MD5 密码的盐，只用于校验升级前注册的账号
 	SecretKey string `mapstructure:"secret_key"`
+	// 签名邮件令牌、加密两步验证密钥的密钥，为空时沿用 secret_key。
+	// 更换后未使用的验证、重置邮件链接失效，已启用两步验证的用户需要重新绑定
+	DataKey           string `mapstructure:"data_key"`
+	Algorithm         string `mapstructure:"algorithm"`
+	BcryptCost        int    `mapstructure:"bcrypt_cost"`
+	Argon2Memory      uint32 `mapstructure:"argon2_memory"`
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"`
 }
 
 type GinConfig struct {
@@ -70,7 +102,61 @@ type SnowFlakeConfig struct {
 }
 
 type AuthConfig struct {
-	JwtExpire int64 `mapstructure:"jwt_expire"`
+	AccessTokenExpire  int64             `mapstructure:"access_token_expire"`
+	RefreshTokenExpire int64             `mapstructure:"refresh_token_expire"`
+	Issuer             string            `mapstructure:"issuer"`
+	SigningKeyID       string            `mapstructure:"signing_key_id"`
+	Keys               []JWTKey          `mapstructure:"keys"`
+	MFA                *MFAConfig        `mapstructure:"mfa"`
+	LoginGuard         *LoginGuardConfig `mapstructure:"login_guard"`
+	Guest              *GuestConfig      `mapstructure:"guest"`
+}
+
+type GuestConfig struct {
+	// 访客令牌有效期（秒）
+	TokenExpire int64 `mapstructure:"token_expire"`
+	// 每个访客（没有访客令牌时按 IP）每分钟允许的请求数
+	RatePerMinute int64 `mapstructure:"rate_per_minute"`
+	// 每个 IP 每分钟允许领取的访客令牌数
+	IssuePerMinute int64 `mapstructure:"issue_per_minute"`
+}
+
+type RBACConfig struct {
+	// 多实例部署时同步策略变更：none、db 或 redis，redis 不可用时退回到轮询数据库
+	Watcher string `mapstructure:"watcher"`
+	// 轮询数据库中策略版本号的间隔（秒），redis 方式下作为消息丢失时的兜底
+	PollInterval int64 `mapstructure:"poll_interval"`
+}
+
+type AuditConfig struct {
+	// 审计记录保留的天数，0 表示永久保留
+	RetentionDays int64 `mapstructure:"retention_days"`
+}
+
+type LoginGuardConfig struct {
+	// memory 或 redis，多实例部署时需要使用 redis
+	Store           string `mapstructure:"store"`
+	MaxUserFailures int64  `mapstructure:"max_user_failures"`
+	MaxIPFailures   int64  `mapstructure:"max_ip_failures"`
+	// 以下单位均为秒
+	BaseLockout int64 `mapstructure:"base_lockout"`
+	MaxLockout  int64 `mapstructure:"max_lockout"`
+	Window      int64 `mapstructure:"window"`
+}
+
+type MFAConfig struct {
+	// 验证器 App 中显示的发行方名称
+	Issuer string `mapstructure:"issuer"`
+	// 拥有（或继承）这些角色的用户必须启用两步验证
+	RequiredRoles []string `mapstructure:"required_roles"`
+}
+
+type JWTKey struct {
+	ID             string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
 }
 
 type OSSConfig struct {
@@ -89,6 +175,51 @@ type SuperAdmin struct {
 	Email    string `mapstructure:"email"`
 }
 
+type MailConfig struct {
+	Driver       string `mapstructure:"driver"`
+	From         string `mapstructure:"from"`
+	SMTPHost     string `mapstructure:"smtp_host"`
+	SMTPPort     int    `mapstructure:"smtp_port"`
+	SMTPUsername string `mapstructure:"smtp_username"`
+	SMTPPassword string `mapstructure:"smtp_password"`
+	SMTPTLS      bool   `mapstructure:"smtp_tls"`
+	FileDir      string `mapstructure:"file_dir"`
+	// 邮件中链接指向的前端地址
+	BaseURL              string `mapstructure:"base_url"`
+	VerifyTokenExpire    int64  `mapstructure:"verify_token_expire"`
+	ResetTokenExpire     int64  `mapstructure:"reset_token_expire"`
+	RequireVerifiedEmail bool   `mapstructure:"require_verified_email"`
+}
+
+type OAuthConfig struct {
+	// 第三方登录完成后跳转回的前端地址
+	FrontendURL string          `mapstructure:"frontend_url"`
+	Providers   []OAuthProvider `mapstructure:"providers"`
+}
+
+type OAuthProvider struct {
+	Name         string   `mapstructure:"name"`
+	DisplayName  string   `mapstructure:"display_name"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	// OIDC 提供方只需配置 issuer，其余端点通过 discovery 获取
+	Issuer string `mapstructure:"issuer"`
+	// 普通 OAuth2 提供方需要手动配置端点
+	AuthURL     string `mapstructure:"auth_url"`
+	TokenURL    string `mapstructure:"token_url"`
+	UserInfoURL string `mapstructure:"userinfo_url"`
+	// userinfo 字段映射，默认使用 OIDC 标准字段
+	SubjectField  string `mapstructure:"subject_field"`
+	EmailField    string `mapstructure:"email_field"`
+	UsernameField string `mapstructure:"username_field"`
+	NameField     string `mapstructure:"name_field"`
+	AvatarField   string `mapstructure:"avatar_field"`
+	// 信任提供方返回的已验证邮箱，自动关联同邮箱的本地账号
+	TrustEmail bool `mapstructure:"trust_email"`
+}
+
 func Init() (err error) {
 	viper.SetConfigName("config") // 指定配置文件名称（不需要带后缀）
 	viper.SetConfigType("yaml")   // 指定配置文件类型
@@ -109,7 +240,26 @@ func Init() (err error) {
 		fmt.Println("Configure file changed ...")
 		if err := viper.Unmarshal(Conf); err != nil {
 			fmt.Printf("viper.Unmarshal() failed, err: %v\n", err)
+			return
+		}
+		changeMu.Lock()
+		hooks := append([]func(*Config){}, changeHooks...)
+		changeMu.Unlock()
+		for _, fn := range hooks {
+			fn(Conf)
 		}
 	})
 	return
 }
+
+var (
+	changeMu    sync.Mutex
+	changeHooks []func(*Config)
+)
+
+// OnChange 注册配置文件修改后的回调，新配置反序列化到 Conf 之后按注册顺序调用
+func OnChange(fn func(*Config)) {
+	changeMu.Lock()
+	defer changeMu.Unlock()
+	changeHooks = append(changeHooks, fn)
+}
@@ -29,4 +29,78 @@ export const updateBio = (data) => {
     method: 'post',
     data
   })
-}
\ No newline at end of file
+}
+// 登录第二步：校验两步验证码或恢复码
+export const loginWith2FA = (data) => {
+  return request({
+    url: 'api/login/2fa',
+    method: 'post',
+    data
+  })
+}
+
+// 角色要求两步验证时，在登录过程中开始绑定
+export const loginEnroll2FA = (data) => {
+  return request({
+    url: 'api/login/2fa/enroll',
+    method: 'post',
+    data
+  })
+}
+
+// 角色要求两步验证时，在登录过程中确认绑定
+export const loginActivate2FA = (data) => {
+  return request({
+    url: 'api/login/2fa/activate',
+    method: 'post',
+    data
+  })
+}
+
+// 可用的第三方登录方式
+export const getOAuthProviders = () => {
+  return request({
+    url: 'api/oauth/providers',
+    method: 'get'
+  })
+}
+
+// 第三方登录需要整页跳转，而不是通过 axios 请求
+export const oauthLoginURL = (provider) => {
+  return request.defaults.baseURL + '/api/oauth/' + provider + '/login'
+}
+
+// 用回调中的一次性登录码换取令牌
+export const oauthExchange = (data) => {
+  return request({
+    url: 'api/oauth/exchange',
+    method: 'post',
+    data
+  })
+}
+
+// 获取已绑定的第三方账号
+export const getIdentities = () => {
+  return request({
+    url: 'api/oauth/identities',
+    method: 'get'
+  })
+}
+
+// 绑定第三方账号，返回需要在当前浏览器中打开的地址
+// 绑定流程保存在接口写入的 cookie 中，需要带上 withCredentials
+export const linkIdentity = (provider) => {
+  return request({
+    url: 'api/oauth/' + provider + '/link',
+    method: 'post',
+    withCredentials: true
+  })
+}
+
+// 解绑第三方账号
+export const unlinkIdentity = (provider) => {
+  return request({
+    url: 'api/oauth/identities/' + provider,
+    method: 'delete'
+  })
+}
@@ -28,6 +28,11 @@ const routes = [
     name: 'Login',
     component: Login
   },
+  {
+    path: '/oauth/callback',
+    name: 'OAuthCallback',
+    component: Login
+  },
   {
     path: '/register',
     name: 'Register',
@@ -198,7 +203,8 @@ router.beforeEach(async (to, from, next) => {
     // 检查是否需要管理员权限
     if (to.matched.some(record => record.meta.requiresAdmin)) {
       // 检查用户角色
-      if (store.state.userInfo.role !== 'admin' && store.state.userInfo.role !== 'super_admin') {
+      const roles = store.state.userInfo.roles || []
+      if (!roles.includes('admin') && !roles.includes('super_admin')) {
         ElMessage.error('需要管理员权限')
         next('/')
         return
@@ -11,6 +11,9 @@ export default createStore({
       state.token = token
       localStorage.setItem('token', token)
     },
+    SET_REFRESH_TOKEN(state, refreshToken) {
+      localStorage.setItem('refresh_token', refreshToken)
+    },
     SET_USERINFO(state, userInfo) {
       state.userInfo = {
         ...userInfo,
@@ -22,6 +25,7 @@ export default createStore({
       state.token = ''
       state.userInfo = {}
       localStorage.removeItem('token')
+      localStorage.removeItem('refresh_token')
       localStorage.removeItem('userInfo')
     }
   },
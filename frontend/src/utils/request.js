@@ -6,12 +6,50 @@ const service = axios.create({
   timeout: 5000
 })
 
+// 未登录时使用后端签发的访客令牌，过期前复用
+let fetchingGuest = null
+
+const getGuestToken = () => {
+  const cached = JSON.parse(localStorage.getItem('guest_token') || 'null')
+  if (cached && cached.expires_at > Date.now()) {
+    return Promise.resolve(cached.token)
+  }
+  if (!fetchingGuest) {
+    fetchingGuest = axios.post(service.defaults.baseURL + '/api/guest/token')
+      .then(res => {
+        if (res.data.code !== 1000) {
+          throw new Error(res.data.msg)
+        }
+        const { guest_token, expires_in } = res.data.data
+        const token = 'Bearer ' + guest_token
+        // 提前一分钟视为过期，避免请求途中失效
+        localStorage.setItem('guest_token', JSON.stringify({
+          token,
+          expires_at: Date.now() + (expires_in - 60) * 1000
+        }))
+        return token
+      })
+      .catch(() => null)
+      .finally(() => {
+        fetchingGuest = null
+      })
+  }
+  return fetchingGuest
+}
+
 // 添加请求拦截器
 service.interceptors.request.use(
-  config => {
-    const token = localStorage.getItem('token') || 'guest_token_anonymous'
+  async config => {
+    const token = localStorage.getItem('token')
     if (token) {
       config.headers.Authorization = token
+      return config
+    }
+    // 领取访客令牌失败时不带 token，公开接口仍然可以按 IP 访问
+    const guestToken = await getGuestToken()
+    if (guestToken) {
+      config.headers.Authorization = guestToken
+      config._guest = true
     }
     return config
   },
@@ -20,9 +58,54 @@ service.interceptors.request.use(
   }
 )
 
+// access token 过期后使用 refresh token 换取新令牌，并重试原请求
+let refreshing = null
+
+const refreshToken = () => {
+  const refresh_token = localStorage.getItem('refresh_token')
+  if (!refresh_token) {
+    return Promise.reject(new Error('no refresh token'))
+  }
+  if (!refreshing) {
+    refreshing = axios.post(service.defaults.baseURL + '/api/token/refresh', { refresh_token })
+      .then(res => {
+        if (res.data.code !== 1000) {
+          throw new Error(res.data.msg)
+        }
+        localStorage.setItem('token', res.data.data.token)
+        localStorage.setItem('refresh_token', res.data.data.refresh_token)
+        return res.data.data.token
+      })
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
 // 响应拦截器
 service.interceptors.response.use(
   response => {
+    const config = response.config
+    // 访客令牌失效（例如签名密钥已轮换）时重新领取
+    if (response.data && response.data.code === 1008 && config._guest && !config._retried) {
+      config._retried = true
+      localStorage.removeItem('guest_token')
+      return service(config)
+    }
+    if (response.data && response.data.code === 1008 && !config._retried) {
+      config._retried = true
+      return refreshToken()
+        .then(token => {
+          config.headers.Authorization = token
+          return service(config)
+        })
+        .catch(() => {
+          localStorage.removeItem('token')
+          localStorage.removeItem('refresh_token')
+          return response
+        })
+    }
     return response
   },
   error => {